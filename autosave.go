@@ -0,0 +1,132 @@
+package main
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+)
+
+const (
+	stateDirName       = "cx"
+	bodyStatePrefix    = "body-"
+	autosaveBodyPeriod = 3 * time.Second
+)
+
+// bodyStatePath returns the per-branch file in the git directory where the body being typed is autosaved.
+func bodyStatePath(repos *git.Repository) string {
+	branch := "HEAD"
+	if ref, err := repos.Head(); err == nil && ref.Name().IsBranch() {
+		branch = ref.Name().Short()
+	}
+
+	return statePath(repos, bodyStatePrefix+safeFileName(url.PathEscape(branch)))
+}
+
+// statePath returns the path of the file name in the state directory in the git directory,
+// or "" if the repository is not on a filesystem.
+func statePath(repos *git.Repository, name string) string {
+	fs, ok := repos.Storer.(*filesystem.Storage)
+	if !ok {
+		return ""
+	}
+	return filepath.Join(fs.Filesystem().Root(), stateDirName, name)
+}
+
+func readBodyState(filename string) string {
+	if filename == "" {
+		return ""
+	}
+
+	content, err := os.ReadFile(longPath(filename))
+	if err != nil {
+		return ""
+	}
+	return string(content)
+}
+
+func clearBodyState(filename string) {
+	if filename == "" {
+		return
+	}
+	os.Remove(longPath(filename))
+}
+
+// autosaver writes the latest text to a file periodically, only when it has changed.
+// It never writes to stdout/stderr, so that it does not disturb prompts.
+type autosaver struct {
+	filename string
+
+	mu    sync.Mutex
+	text  string
+	saved string
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func startAutosave(filename string, period time.Duration) *autosaver {
+	a := &autosaver{
+		filename: filename,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	go func() {
+		defer close(a.done)
+
+		ticker := time.NewTicker(period)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				a.save()
+			case <-a.stop:
+				a.save()
+				return
+			}
+		}
+	}()
+
+	return a
+}
+
+func (a *autosaver) Update(text string) {
+	a.mu.Lock()
+	a.text = text
+	a.mu.Unlock()
+}
+
+// Stop saves the last text and stops autosaving.
+func (a *autosaver) Stop() {
+	close(a.stop)
+	<-a.done
+}
+
+func (a *autosaver) save() {
+	a.mu.Lock()
+	text := a.text
+	changed := text != a.saved
+	a.mu.Unlock()
+
+	if !changed || a.filename == "" {
+		return
+	}
+
+	if err := os.MkdirAll(longPath(filepath.Dir(a.filename)), os.ModePerm); err != nil {
+		return
+	}
+	// a half-written draft would be lost with the text being typed
+	if err := writeFileAtomic(a.filename, []byte(text)); err != nil {
+		return
+	}
+
+	a.mu.Lock()
+	a.saved = text
+	a.mu.Unlock()
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// changelogGeneralSection is the section of unscoped commits when grouped by scope.
+const changelogGeneralSection = "general"
+
+type changelogCmd struct {
+	GroupBy groupByFlag      `cli:"group-by=KEY" help:"group the entries by type or scope (default: type)"`
+	Order   sectionOrderFlag `cli:"order=ORDER" help:"order of the sections: alpha or count (default: the rule for types, alpha for scopes)"`
+}
+
+// Run writes the release notes of the commits in the range (A..B), or of the current branch, in Markdown.
+func (c changelogCmd) Run(g globalCmd, args []string) error {
+	repos, err := g.openRepository()
+	if err != nil {
+		return err
+	}
+	g.repository = repos
+
+	if err := g.prepare(repos); err != nil {
+		return err
+	}
+	defer g.commits.save()
+
+	var commits []*object.Commit
+	if len(args) > 0 {
+		commits, err = rangeCommits(repos, args[0])
+	} else {
+		commits, err = branchCommits(repos)
+	}
+	if err != nil {
+		return err
+	}
+
+	var grouping changelogGrouping = typeGrouping{rule: g.rule, order: string(c.Order)}
+	if c.GroupBy == groupByScope {
+		grouping = scopeGrouping{order: string(c.Order)}
+	}
+
+	g.changelogEntries(commits).render(os.Stdout, grouping)
+	return nil
+}
+
+// changelogEntry is a conventional commit in a changelog.
+type changelogEntry struct {
+	Hash        string
+	Type        string
+	Scope       string
+	Description string
+
+	// BreakingChanges are the BREAKING CHANGE footers, or the description if only marked by !
+	BreakingChanges []string
+}
+
+// changelogEntries is the shared model of changelogs, rendered by a changelogGrouping.
+type changelogEntries []changelogEntry
+
+// changelogEntries returns the conventional commits of commits, oldest first, without wip commits.
+func (c globalCmd) changelogEntries(commits []*object.Commit) changelogEntries {
+	var entries changelogEntries
+	for i := len(commits) - 1; i >= 0; i-- {
+		cc, ok := c.commits.parse(commits[i])
+		if !ok || isWip(cc) {
+			continue
+		}
+
+		e := changelogEntry{
+			Hash:            shortHash(commits[i].Hash.String()),
+			Type:            cc.Type,
+			Scope:           cc.Scope,
+			Description:     c.trimEmoji(cc.Type, cc.Scope, cc.Description),
+			BreakingChanges: cc.BreakingChanges,
+		}
+		if cc.Breaking && len(e.BreakingChanges) == 0 {
+			e.BreakingChanges = []string{e.Description}
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// changelogSection is a group of entries under a heading.
+type changelogSection struct {
+	Title   string
+	Entries changelogEntries
+}
+
+// changelogGrouping decides the sections of a changelog, so that groupings share the rendering.
+type changelogGrouping interface {
+	// section returns the title of the section of e.
+	section(e changelogEntry) string
+
+	// badge returns the prefix of e in its section, telling what the title does not.
+	badge(e changelogEntry) string
+
+	// sort orders the sections.
+	sort(sections []changelogSection)
+}
+
+// render writes the sections by grouping, then the breaking changes, in Markdown.
+func (entries changelogEntries) render(w io.Writer, grouping changelogGrouping) {
+	var sections []changelogSection
+	index := make(map[string]int)
+	for _, e := range entries {
+		title := grouping.section(e)
+		i, found := index[title]
+		if !found {
+			i = len(sections)
+			index[title] = i
+			sections = append(sections, changelogSection{Title: title})
+		}
+		sections[i].Entries = append(sections[i].Entries, e)
+	}
+	grouping.sort(sections)
+
+	for _, s := range sections {
+		fmt.Fprintf(w, "## %s\n\n", s.Title)
+		for _, e := range s.Entries {
+			fmt.Fprintf(w, "- %s%s (%s)\n", grouping.badge(e), e.Description, e.Hash)
+		}
+		fmt.Fprintln(w)
+	}
+
+	var breaking []string
+	for _, e := range entries {
+		for _, bc := range e.BreakingChanges {
+			breaking = append(breaking, fmt.Sprintf("- %s%s (%s)", scopeBadge(e), bc, e.Hash))
+		}
+	}
+	if len(breaking) > 0 {
+		fmt.Fprint(w, "## BREAKING CHANGES\n\n")
+		for _, b := range breaking {
+			fmt.Fprintln(w, b)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// typeGrouping makes a section per type, in the order of the rule unless order is count.
+type typeGrouping struct {
+	rule  *Rule
+	order string
+}
+
+func (t typeGrouping) section(e changelogEntry) string {
+	return e.Type
+}
+
+func (t typeGrouping) badge(e changelogEntry) string {
+	return scopeBadge(e)
+}
+
+func (t typeGrouping) sort(sections []changelogSection) {
+	if t.order == sectionOrderCount || t.order == sectionOrderAlpha {
+		sortSections(sections, t.order)
+		return
+	}
+
+	rank := make(map[string]int)
+	for i, k := range t.rule.typeNames() {
+		rank[k] = i + 1
+	}
+	sort.SliceStable(sections, func(i, j int) bool {
+		ri, rj := rank[sections[i].Title], rank[sections[j].Title]
+		switch {
+		case ri != 0 && rj != 0:
+			return ri < rj
+		case ri != 0 || rj != 0:
+			// ad-lib types last
+			return ri != 0
+		default:
+			return sections[i].Title < sections[j].Title
+		}
+	})
+}
+
+// scopeGrouping makes a section per scope, with unscoped commits under general, which comes last.
+type scopeGrouping struct {
+	order string
+}
+
+func (s scopeGrouping) section(e changelogEntry) string {
+	if e.Scope == "" {
+		return changelogGeneralSection
+	}
+	return e.Scope
+}
+
+func (s scopeGrouping) badge(e changelogEntry) string {
+	return "`" + e.Type + "` "
+}
+
+func (s scopeGrouping) sort(sections []changelogSection) {
+	sortSections(sections, s.order)
+
+	for i, sec := range sections {
+		if sec.Title == changelogGeneralSection {
+			copy(sections[i:], sections[i+1:])
+			sections[len(sections)-1] = sec
+			break
+		}
+	}
+}
+
+// scopeBadge returns the scope of e in bold, or "" if unscoped.
+func scopeBadge(e changelogEntry) string {
+	if e.Scope == "" {
+		return ""
+	}
+	return "**" + e.Scope + ":** "
+}
+
+// sortSections orders sections by the number of entries (count) or by the title.
+func sortSections(sections []changelogSection, order string) {
+	sort.SliceStable(sections, func(i, j int) bool {
+		if order == sectionOrderCount && len(sections[i].Entries) != len(sections[j].Entries) {
+			return len(sections[i].Entries) > len(sections[j].Entries)
+		}
+		return strings.ToLower(sections[i].Title) < strings.ToLower(sections[j].Title)
+	})
+}
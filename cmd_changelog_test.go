@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shu-go/git-cx/internal/testutil"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+func TestChangelogRender(t *testing.T) {
+	r := testutil.NewRepo(t)
+	r.Commit("chore: init")
+	r.Tag("v1.0.0")
+	r.Commits(
+		"feat(api): :sparkles:add the retry flag",
+		"fix(ui): align the table",
+		"fix: handle nil",
+		"chore: halfway\n\nCx-Wip: true",
+		"fix(ui): keep the header",
+		"docs(api): describe the retry flag",
+		"feat(api)!: drop v1\n\nBREAKING CHANGE: v1 is removed",
+		"not conventional",
+		"perf(ui): cache the rows",
+		"fix(ui): wrap the cells",
+	)
+	commits, err := rangeCommits(r.Repository, "v1.0.0..")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rule := defaultRule(true)
+	g := globalCmd{rule: &rule}
+	entries := g.changelogEntries(commits)
+
+	tests := []struct {
+		golden   string
+		grouping changelogGrouping
+	}{
+		{golden: "changelog_type.md", grouping: typeGrouping{rule: &rule}},
+		{golden: "changelog_type_count.md", grouping: typeGrouping{rule: &rule, order: sectionOrderCount}},
+		{golden: "changelog_scope.md", grouping: scopeGrouping{}},
+		{golden: "changelog_scope_count.md", grouping: scopeGrouping{order: sectionOrderCount}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			buf := bytes.Buffer{}
+			entries.render(&buf, tt.grouping)
+
+			golden := filepath.Join("testdata", tt.golden)
+			if *update {
+				if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != string(want) {
+				t.Errorf("changelog =\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// branchCommitsLimit bounds the walk when the default branch is unknown.
+const branchCommitsLimit = 100
+
+type checkCmd struct {
+	Branch string `cli:"branch=BRANCH" help:"the branch the commits land on, for forbiddenOnBranches (default: the current branch)"`
+}
+
+// Run checks the commits of the current branch, or those in the range (A..B) if given,
+// such as outstanding wip commits and types forbidden on the branch.
+func (c checkCmd) Run(g globalCmd, args []string) error {
+	repos, err := g.openRepository()
+	if err != nil {
+		return err
+	}
+	g.repository = repos
+
+	if err := g.prepare(repos); err != nil {
+		return err
+	}
+	defer g.commits.save()
+
+	var commits []*object.Commit
+	if len(args) > 0 {
+		commits, err = rangeCommits(repos, args[0])
+	} else {
+		commits, err = branchCommits(repos)
+	}
+	if err != nil {
+		return err
+	}
+
+	branch := c.Branch
+	if branch == "" {
+		branch = currentBranch(repos)
+	}
+
+	wips := 0
+	findings := 0
+	for _, commit := range commits {
+		cc, _ := g.commits.parse(commit)
+		if isWip(cc) {
+			wips++
+		}
+
+		subject, _, _ := strings.Cut(commit.Message, "\n")
+		if f := forbiddenOn(g.rule, branch, cc, subject); f != "" {
+			findings++
+			fmt.Printf("%s %s\n", shortHash(commit.Hash.String()), subject)
+			fmt.Println("  " + tr(msgCheckForbidden, f, branch))
+		}
+	}
+	if wips > 0 {
+		fmt.Fprintln(os.Stderr, tr(msgCheckWipCommits, wips))
+	}
+
+	if findings > 0 {
+		return errors.New(tr(msgCheckFailed, findings))
+	}
+	return nil
+}
+
+// forbiddenOn returns the entry of rule.ForbiddenOnBranches that cc (or its raw subject) matches on branch, or "".
+//
+// An entry is a type, or a prefix of the subject like fixup!.
+func forbiddenOn(rule *Rule, branch string, cc ConventionalCommit, subject string) string {
+	if branch == "" {
+		return ""
+	}
+
+	for _, glob := range sortedKeys(rule.ForbiddenOnBranches) {
+		if ok, _ := path.Match(glob, branch); !ok {
+			continue
+		}
+		for _, f := range rule.ForbiddenOnBranches[glob] {
+			if f == "" {
+				continue
+			}
+			if cc.Type == f || strings.HasPrefix(subject, f+" ") {
+				return f
+			}
+		}
+	}
+	return ""
+}
+
+// currentBranch returns the short name of the branch of HEAD, or "" if detached.
+func currentBranch(repos *git.Repository) string {
+	if repos == nil {
+		return ""
+	}
+	if ref, err := repos.Head(); err == nil && ref.Name().IsBranch() {
+		return ref.Name().Short()
+	}
+	return ""
+}
+
+// branchCommits returns the commits of HEAD that are not in the default branch, newest first.
+// If the default branch is not found, it returns up to branchCommitsLimit commits.
+func branchCommits(repos *git.Repository) ([]*object.Commit, error) {
+	head, err := repos.Head()
+	if err != nil {
+		return nil, err
+	}
+
+	var exclude *plumbing.Hash
+	if ref := defaultBranch(repos); ref != nil {
+		h := ref.Hash()
+		exclude = &h
+	}
+	return walkCommits(repos, exclude, head.Hash())
+}
+
+// rangeCommits returns the commits in rng (A..B, B defaults to HEAD), newest first.
+func rangeCommits(repos *git.Repository, rng string) ([]*object.Commit, error) {
+	from, to, found := strings.Cut(rng, "..")
+	if !found || from == "" {
+		return nil, errors.New(tr(msgCheckInvalidRange, rng))
+	}
+	if to == "" {
+		to = "HEAD"
+	}
+
+	fromHash, err := repos.ResolveRevision(plumbing.Revision(from))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", from, err)
+	}
+	toHash, err := repos.ResolveRevision(plumbing.Revision(to))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", to, err)
+	}
+	return walkCommits(repos, fromHash, *toHash)
+}
+
+// walkCommits returns the commits from to, stopping at the merge bases of to and exclude.
+// If exclude is nil, it returns up to branchCommitsLimit commits.
+func walkCommits(repos *git.Repository, exclude *plumbing.Hash, to plumbing.Hash) ([]*object.Commit, error) {
+	toCommit, err := repos.CommitObject(to)
+	if err != nil {
+		return nil, err
+	}
+
+	bases := make(map[plumbing.Hash]bool)
+	if exclude != nil {
+		if exCommit, err := repos.CommitObject(*exclude); err == nil {
+			mbs, _ := toCommit.MergeBase(exCommit)
+			for _, mb := range mbs {
+				bases[mb.Hash] = true
+			}
+		}
+	}
+
+	iter, err := repos.Log(&git.LogOptions{From: to})
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	var commits []*object.Commit
+	for {
+		commit, err := iter.Next()
+		if err != nil {
+			break
+		}
+		if bases[commit.Hash] {
+			break
+		}
+		if exclude == nil && len(commits) >= branchCommitsLimit {
+			break
+		}
+		commits = append(commits, commit)
+	}
+	return commits, nil
+}
+
+// defaultBranch returns origin/HEAD, main or master, whichever found first.
+func defaultBranch(repos *git.Repository) *plumbing.Reference {
+	candidates := []plumbing.ReferenceName{
+		plumbing.NewRemoteHEADReferenceName("origin"),
+		plumbing.NewBranchReferenceName("main"),
+		plumbing.NewBranchReferenceName("master"),
+	}
+	for _, name := range candidates {
+		if ref, err := repos.Reference(name, true); err == nil {
+			return ref
+		}
+	}
+	return nil
+}
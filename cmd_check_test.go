@@ -0,0 +1,142 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/shu-go/git-cx/internal/testutil"
+)
+
+func TestRangeCommits(t *testing.T) {
+	r := testutil.NewRepo(t)
+	r.Commits("chore: init", "feat: a")
+	r.Tag("v1.0.0")
+	r.Commits("fix: b", "docs: c")
+
+	tests := []struct {
+		rng     string
+		want    []string
+		wantErr bool
+	}{
+		{rng: "v1.0.0..", want: []string{"docs: c", "fix: b"}},
+		{rng: "v1.0.0..HEAD", want: []string{"docs: c", "fix: b"}},
+		{rng: "v1.0.0..HEAD~1", want: []string{"fix: b"}},
+		{rng: "HEAD..v1.0.0"},
+		{rng: "..HEAD", wantErr: true},
+		{rng: "v1.0.0", wantErr: true},
+		{rng: "v9..HEAD", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rng, func(t *testing.T) {
+			commits, err := rangeCommits(r.Repository, tt.rng)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("rangeCommits() = %v, want an error: %v", err, tt.wantErr)
+			}
+			var got []string
+			for _, c := range commits {
+				got = append(got, strings.TrimSpace(c.Message))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("commits = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBranchCommits(t *testing.T) {
+	r := testutil.NewRepo(t)
+	r.Commits("chore: init", "feat: a")
+	r.Branch("topic")
+	r.Commits("fix: b", "docs: c")
+
+	commits, err := branchCommits(r.Repository)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, c := range commits {
+		got = append(got, strings.TrimSpace(c.Message))
+	}
+	if want := []string{"docs: c", "fix: b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("commits = %q, want those not in main %q", got, want)
+	}
+	if b := currentBranch(r.Repository); b != "topic" {
+		t.Errorf("currentBranch() = %q", b)
+	}
+}
+
+func TestForbiddenOn(t *testing.T) {
+	rule := &Rule{ForbiddenOnBranches: map[string][]string{
+		"main":      {"wip", "fixup!"},
+		"release/*": {"feat"},
+	}}
+
+	tests := []struct {
+		branch  string
+		subject string
+		want    string
+	}{
+		{branch: "main", subject: "wip: a", want: "wip"},
+		{branch: "main", subject: "fixup! feat: a", want: "fixup!"},
+		{branch: "main", subject: "feat: a"},
+		{branch: "release/1.0", subject: "feat: a", want: "feat"},
+		{branch: "release/1.0", subject: "fix: a"},
+		{branch: "topic", subject: "wip: a"},
+		{branch: "", subject: "wip: a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.branch+" "+tt.subject, func(t *testing.T) {
+			cc, _ := parseCommitMessage(tt.subject)
+			if got := forbiddenOn(rule, tt.branch, cc, tt.subject); got != tt.want {
+				t.Errorf("forbiddenOn() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckRun(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	r := testutil.NewRepo(t)
+	r.WriteFile(".cx.yaml", "forbiddenonbranches:\n  main: [fixup!]\n")
+	r.Stage(".cx.yaml")
+	r.Commit("chore: init")
+	r.Tag("v1.0.0")
+	r.Commits("feat: a", "fixup! feat: a", "fix: b")
+	r.Chdir()
+
+	tests := []struct {
+		name   string
+		cmd    checkCmd
+		args   []string
+		failed int
+	}{
+		{name: "on main", args: []string{"v1.0.0.."}, failed: 1},
+		{name: "on another branch", cmd: checkCmd{Branch: "topic"}, args: []string{"v1.0.0.."}},
+		{name: "before the fixup", args: []string{"v1.0.0..HEAD~2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			out := captureStdout(t, func() { err = tt.cmd.Run(globalCmd{}, tt.args) })
+			if tt.failed == 0 {
+				if err != nil || out != "" {
+					t.Errorf("check = %v, %q, want no findings", err, out)
+				}
+				return
+			}
+			if err == nil || err.Error() != tr(msgCheckFailed, tt.failed) {
+				t.Errorf("check = %v, want %d findings", err, tt.failed)
+			}
+			if !strings.Contains(out, "fixup! feat: a") {
+				t.Errorf("output = %q, want the fixup commit", out)
+			}
+		})
+	}
+
+	if err := (checkCmd{}).Run(globalCmd{}, []string{"..HEAD"}); err == nil || err.Error() != tr(msgCheckInvalidRange, "..HEAD") {
+		t.Errorf("check of an invalid range = %v", err)
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/shu-go/findcfg"
+)
+
+type composeCmd struct {
+	NoRepo bool   `cli:"no-repo" help:"do not open the repository, for a message of a PR title or another repository"`
+	Config string `cli:"config=FILE" help:"rule file (default: the repository's, or with --no-repo, the one in the user config directory)"`
+}
+
+// Run asks the components and prints the message, without committing nor writing the history.
+// In a repository (without --no-repo), the rule and the suggestions are of the repository;
+// otherwise the features needing it have nothing to suggest.
+func (c composeCmd) Run(g globalCmd, args []string) error {
+	g.composing = true
+
+	var repos *git.Repository
+	if !c.NoRepo {
+		// outside a repository is fine
+		repos, _ = plainOpen(".")
+	}
+	setLang(string(g.Lang), repos)
+	g.repository = repos
+
+	if repos != nil {
+		if err := g.prepare(repos); err != nil {
+			return err
+		}
+		// neither restored nor autosaved
+		g.bodyStateFileName = ""
+	} else {
+		readRule := readUserRuleFile
+		if g.RuleFile != "" {
+			readRule = func() (*Rule, string, error) { return g.readRule(nil) }
+		}
+		rule, rulePath, err := readRule()
+		if err != nil {
+			return err
+		}
+		g.rule, g.rulePath = rule, rulePath
+		g.scopes = make(Scopes)
+	}
+
+	if c.Config != "" {
+		rule, err := tryReadRuleFile(c.Config, configFileLimit(repos))
+		if err != nil {
+			return &RuleInvalidError{Path: c.Config, Cause: err}
+		}
+		if rule == nil {
+			return fmt.Errorf("%s: %w", c.Config, os.ErrNotExist)
+		}
+		g.rule, g.rulePath = rule, c.Config
+	}
+	if err := checkMinVersion(g.rule, g.rulePath); err != nil {
+		return err
+	}
+	if err := checkExtraTrailers(g.rule, g.rulePath); err != nil {
+		return err
+	}
+	if err := checkEmojiOverrides(g.rule, g.rulePath); err != nil {
+		return err
+	}
+	warnNearDuplicateTypes(g.rule)
+	checkPrompts(g.rule, g.rulePath)
+	checkHeaderFormat(g.rule, g.rulePath)
+	checkMessageFormats(g.rule, g.rulePath)
+
+	var err error
+	if g.given, err = answersFromArgs(g.rule, args); err != nil {
+		return err
+	}
+	if err := g.answersFromFlags(); err != nil {
+		return err
+	}
+
+	ctx, cancel := newRootContext()
+	defer cancel()
+
+	msg, _, _, err := g.buildupCommitMessage(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Println(msg)
+	return nil
+}
+
+// readUserRuleFile reads the rule file in the user config directory or the executable directory,
+// not looking into the working directory, or returns the default rule.
+func readUserRuleFile() (*Rule, string, error) {
+	finder := findcfg.New(
+		findcfg.Name(defaultRuleFileName),
+		findcfg.YAML(),
+		findcfg.JSON(),
+		findcfg.UserConfigDir(userConfigFolder),
+		findcfg.ExecutableDir(),
+	)
+	if found := finder.Find(); found != nil {
+		r, err := tryReadRuleFile(found.Path, defaultConfigFileLimit)
+		if err == nil {
+			return r, actualCase(found.Path), nil
+		}
+		if errors.As(err, new(*fileTooLargeError)) {
+			return nil, found.Path, &RuleInvalidError{Path: found.Path, Cause: err}
+		}
+		warn(tr(msgRuleDefaultWarning, &RuleInvalidError{Path: found.Path, Cause: err}))
+	}
+
+	r := defaultRule(false)
+	return &r, "", nil
+}
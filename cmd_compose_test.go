@@ -0,0 +1,106 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"testing"
+
+	"github.com/shu-go/git-cx/internal/testutil"
+)
+
+// captureStdout returns what fn writes to os.Stdout.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+
+	rd, wr, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = wr
+	defer func() { os.Stdout = stdout }()
+
+	done := make(chan string)
+	go func() {
+		b, _ := io.ReadAll(rd)
+		done <- string(b)
+	}()
+	fn()
+	wr.Close()
+	return <-done
+}
+
+// listFiles returns the paths of the files under dir, relative to it.
+func listFiles(t *testing.T, dir string) []string {
+	t.Helper()
+
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		files = append(files, rel)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	return files
+}
+
+func TestComposeNoRepo(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	userRule := filepath.Join(configHome, userConfigFolder, defaultRuleFileName+".yaml")
+	if err := os.MkdirAll(filepath.Dir(userRule), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(userRule, []byte("headerformat: 'user {{.type}}: {{.description}}'\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := testutil.NewRepo(t)
+	r.WriteFile(".cx.yaml", "headerformat: 'repository {{.type}}: {{.description}}'\n")
+	r.SetConfig("cx", "scopes", ".scopes.yaml")
+	r.CommitFile("a.txt", "a", "chore: init")
+	r.WriteFile("a.txt", "b")
+	r.Stage("a.txt")
+	r.Chdir()
+	before := listFiles(t, r.Dir)
+
+	tests := []struct {
+		name string
+		cmd  composeCmd
+		want string
+	}{
+		{name: "no-repo", cmd: composeCmd{NoRepo: true}, want: "user feat: add retry flag\n"},
+		{name: "in the repository", cmd: composeCmd{}, want: "repository feat: add retry flag\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := globalCmd{Type: "feat", Scope: "api", Message: "add retry flag"}
+			var err error
+			got := captureStdout(t, func() { err = tt.cmd.Run(g, nil) })
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("composed %q, want %q", got, tt.want)
+			}
+
+			// neither committed nor recorded
+			if n := r.CommitCount(); n != 1 {
+				t.Errorf("%d commits, want 1", n)
+			}
+			if after := listFiles(t, r.Dir); !slices.Equal(after, before) {
+				t.Errorf("files = %v, want %v", after, before)
+			}
+		})
+	}
+}
@@ -1,16 +1,29 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 
+	prompt "github.com/elk-language/go-prompt"
 	"gopkg.in/yaml.v3"
 )
 
 type genCmd struct {
-	Emoji bool `cli:"emoji"`
+	Emoji       bool `cli:"emoji" help:"give the default types emojis"`
+	Interactive bool `cli:"interactive,i" help:"ask the options of the rule file (emoji, adlib types, BREAKING CHANGE, header length)"`
+	Force       bool `cli:"force,f" help:"overwrite the output file (and the commit-msg hook by --hook) without confirmation"`
+
+	SetConfig bool `cli:"set-config" help:"set gitconfig cx.rule (and cx.scopes) of the repository to the output file"`
+	Hook      bool `cli:"hook" help:"install a commit-msg hook running git cx lint (core.hooksPath if set)"`
+
+	FromRule string `cli:"from-rule=FILE" help:"convert an existing rule file into the format of the output file (.yaml or .json)"`
+	Extends  string `cli:"extends=FILE" help:"generate a rule file only extending FILE (relative to the output file, or an https URL)"`
 }
 
 func (c genCmd) Run(g globalCmd, args []string) error {
@@ -24,45 +37,357 @@ func (c genCmd) Run(g globalCmd, args []string) error {
 		return err
 	}
 
-	fmt.Fprintf(os.Stderr, "output: %v\n", filename)
+	if !c.SetConfig && !c.Hook {
+		return c.writeRule(filename)
+	}
+
+	// the repository is checked before the rule file is written
+	repos, root, err := openGenRepository()
+	if err != nil {
+		return err
+	}
+	var ruleValue string
+	if c.SetConfig {
+		if ruleValue, err = ruleConfigValue(root, filename); err != nil {
+			return err
+		}
+	}
 
-	rule := defaultRule(c.Emoji)
+	if err := c.writeRule(filename); err != nil {
+		// declining to overwrite keeps the existing rule file, and wires it
+		if !errors.Is(err, ErrUserAborted) {
+			return err
+		}
+		fmt.Fprintln(os.Stderr, tr(msgGenKeepRule, filename))
+	}
 
-	if in(filepath.Ext(filename), ".json") {
-		content, err := json.MarshalIndent(rule, "", "  ")
+	if c.SetConfig {
+		if err := setRuleConfig(repos, ruleValue); err != nil {
+			return err
+		}
+	}
+	if c.Hook {
+		if err := installCommitMsgHook(repos, c.Force); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeRule writes the rule file filename as the flags say.
+func (c genCmd) writeRule(filename string) error {
+	if c.Extends != "" {
+		if c.FromRule != "" {
+			return errors.New("--from-rule and --extends can not be used together")
+		}
+		if c.Interactive {
+			return errors.New("--interactive and --extends can not be used together")
+		}
+		if err := c.confirmOverwrite(filename); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "output: %v\n", filename)
+		return writeExtendingRuleFile(filename, c.Extends)
+	}
+
+	var rule Rule
+	var source []byte
+	if c.FromRule != "" {
+		from, err := filepath.Abs(c.FromRule)
 		if err != nil {
 			return err
 		}
+		if from == filename {
+			return errors.New("--from-rule and the output file must differ")
+		}
 
-		file, err := os.Create(filename)
+		content, err := readConfigFile(from, defaultConfigFileLimit)
 		if err != nil {
+			return fmt.Errorf("read %s: %w", from, err)
+		}
+		if content == nil {
+			return fmt.Errorf("read %s: not a file", from)
+		}
+		// as it is written, neither merged with the files it extends nor filled with the defaults
+		r, err := parseRule(from, content)
+		if err != nil {
+			return fmt.Errorf("read %s: %w", from, err)
+		}
+		rule, source = *r, content
+	} else {
+		rule = defaultRule(c.Emoji)
+	}
+
+	if c.Interactive {
+		if err := askGenRule(&rule, c.FromRule == "", c.Emoji); err != nil {
 			return err
 		}
-		defer file.Close()
+	}
+	if err := c.confirmOverwrite(filename); err != nil {
+		return err
+	}
 
-		_, err = file.WriteString(string(content))
+	fmt.Fprintf(os.Stderr, "output: %v\n", filename)
+
+	if c.FromRule != "" {
+		content, err := marshalConvertedRule(filename, rule, source)
 		if err != nil {
 			return err
 		}
+		return writeFileAtomic(filename, content)
+	}
+	if in(fileExt(filename), ".json") {
+		return writeRuleFile(filename, rule)
+	}
 
-		return nil
+	content, err := marshalGeneratedRule(rule)
+	if err != nil {
+		return err
 	}
+	return writeFileAtomic(filename, content)
+}
 
-	content, err := yaml.Marshal(rule)
+// confirmOverwrite asks before overwriting filename if it exists, unless --force.
+func (c genCmd) confirmOverwrite(filename string) error {
+	if c.Force {
+		return nil
+	}
+	if _, err := os.Stat(longPath(filename)); err != nil {
+		return nil
+	}
+	ok, err := confirm(tr(msgGenOverwrite, filename), false)
 	if err != nil {
 		return err
 	}
+	if !ok {
+		return withMessage(ErrUserAborted, tr(msgAborted))
+	}
+	return nil
+}
 
-	file, err := os.Create(filename)
+// askGenRule asks the options of rule, with their current values as the defaults.
+// The default types are replaced by those with or without emojis if askEmoji.
+func askGenRule(rule *Rule, askEmoji, emoji bool) error {
+	var err error
+	if askEmoji {
+		if emoji, err = askYesNo(tr(msgGenAskEmoji), emoji); err != nil {
+			return err
+		}
+		rule.Types = defaultCommitTypes(emoji)
+	}
+	if rule.DenyAdlibType, err = askYesNo(tr(msgGenAskDenyAdlib), rule.DenyAdlibType); err != nil {
+		return err
+	}
+	if rule.UseBreakingChange, err = askYesNo(tr(msgGenAskBreaking), rule.UseBreakingChange); err != nil {
+		return err
+	}
+
+	for {
+		answer, err := promptInput(prompt.WithPrefix(tr(msgGenAskMaxHeader, rule.MaxHeaderLength)))
+		if err != nil {
+			return err
+		}
+		answer = strings.TrimSpace(answer)
+		if answer == "" {
+			return nil
+		}
+		if n, err := strconv.Atoi(answer); err == nil && n >= 0 {
+			rule.MaxHeaderLength = n
+			return nil
+		}
+		fmt.Fprintln(os.Stderr, tr(msgGenInvalidLength))
+	}
+}
+
+// askYesNo asks question with the default def shown.
+func askYesNo(question string, def bool) (bool, error) {
+	if def {
+		return confirm(question+" [Y/n]: ", true)
+	}
+	return confirm(question+" [y/N]: ", false)
+}
+
+// generatedRuleComments are comments on the keys of a generated YAML rule file, for values left to the user.
+var generatedRuleComments = map[string]string{
+	"maxheaderlength": "e.g. 72 (0: unlimited)",
+}
+
+// generatedRuleExamples are commented out at the end of a generated YAML rule file, for options left out by default.
+const generatedRuleExamples = `bodyformat: "{{.body}}\n\nRefs: {{.refs}}"
+  (variables: type, scope, description, body, breaking_change, footers, and the footers by their keys like refs)
+footerformat: "{{.footers}}"`
+
+// generatedRuleOrder is the order of the keys of a generated YAML rule file:
+// types first, then the formats, the booleans, the numbers and the optional sections.
+// Keys not listed (optional sections, and those added later) follow in the order of Rule.
+// The same rule gives the same file, to keep it in the repository without noise in diffs.
+var generatedRuleOrder = []string{
+	"types",
+	"headerformat", "headerformathint", "bodyformat", "footerformat", "emojirendering",
+	"denyemptytype", "denyadlibtype", "usebreakingchange", "ignoresubmodules", "warnpartiallystaged",
+	"maxheaderlength", "stageddirsdepth", "scopetimestampformat",
+}
+
+// generatedTypeOrder is the order of the keys of a type in a generated YAML rule file.
+var generatedTypeOrder = []string{"desc", "emoji", "breaking", "aliases"}
+
+// marshalGeneratedRule returns the YAML of rule in generatedRuleOrder, indented by 2,
+// with generatedRuleComments and generatedRuleExamples.
+func marshalGeneratedRule(rule Rule) ([]byte, error) {
+	node := yaml.Node{}
+	if err := node.Encode(rule); err != nil {
+		return nil, err
+	}
+	orderMapping(&node, generatedRuleOrder)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if comment, found := generatedRuleComments[node.Content[i].Value]; found {
+			node.Content[i+1].LineComment = comment
+		}
+		if node.Content[i].Value == "types" && node.Content[i+1].Kind == yaml.MappingNode {
+			types := node.Content[i+1]
+			for j := 1; j < len(types.Content); j += 2 {
+				orderMapping(types.Content[j], generatedTypeOrder)
+			}
+		}
+	}
+	doc := yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{&node}, FootComment: generatedRuleExamples}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// orderMapping moves the keys of the mapping node in order to the front, in that order.
+// The other keys follow as they are.
+func orderMapping(node *yaml.Node, order []string) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+
+	content := make([]*yaml.Node, 0, len(node.Content))
+	moved := make([]bool, len(node.Content))
+	for _, k := range order {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if !moved[i] && node.Content[i].Value == k {
+				content = append(content, node.Content[i], node.Content[i+1])
+				moved[i] = true
+				break
+			}
+		}
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if !moved[i] {
+			content = append(content, node.Content[i], node.Content[i+1])
+		}
+	}
+	node.Content = content
+}
+
+// writeExtendingRuleFile writes a rule file with extends only, inheriting everything.
+func writeExtendingRuleFile(filename, extends string) error {
+	child := map[string]string{"extends": extends}
+
+	var content []byte
+	var err error
+	if in(fileExt(filename), ".json") {
+		content, err = json.MarshalIndent(child, "", "  ")
+	} else {
+		content, err = yaml.Marshal(child)
+	}
 	if err != nil {
 		return err
 	}
-	defer file.Close()
+	return writeFileAtomic(filename, content)
+}
 
-	_, err = file.WriteString(string(content))
+// writeRuleFile writes rule in JSON if filename ends with .json, otherwise in YAML.
+func writeRuleFile(filename string, rule Rule) error {
+	content, err := marshalRule(filename, rule)
 	if err != nil {
 		return err
 	}
+	return writeFileAtomic(filename, content)
+}
 
-	return nil
+// marshalConvertedRule returns the content of the rule file filename converted from rule,
+// whose rule file has source as its content.
+// The options left out of source are left out unless set (by --interactive), since their zero values
+// would override the rule files extended.
+func marshalConvertedRule(filename string, rule Rule, source []byte) ([]byte, error) {
+	written := make(map[string]bool)
+	// JSON is YAML
+	src := yaml.Node{}
+	if err := yaml.Unmarshal(source, &src); err == nil && len(src.Content) > 0 {
+		for i := 0; i+1 < len(src.Content[0].Content); i += 2 {
+			written[strings.ToLower(src.Content[0].Content[i].Value)] = true
+		}
+	}
+
+	content, err := marshalRule(filename, rule)
+	if err != nil {
+		return nil, err
+	}
+	node := yaml.Node{}
+	if err := yaml.Unmarshal(content, &node); err != nil {
+		return nil, err
+	}
+	root := node.Content[0]
+	kept := root.Content[:0]
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		k, v := root.Content[i], root.Content[i+1]
+		if written[strings.ToLower(k.Value)] || !isZeroNode(v) {
+			kept = append(kept, k, v)
+		}
+	}
+	root.Content = kept
+
+	if !in(fileExt(filename), ".json") {
+		return yaml.Marshal(&node)
+	}
+	compact, err := nodeToJSON(root)
+	if err != nil {
+		return nil, err
+	}
+	buf := bytes.Buffer{}
+	if err := json.Indent(&buf, compact, "", "  "); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// isZeroNode reports whether v is null, false, 0, an empty string or an empty collection.
+func isZeroNode(v *yaml.Node) bool {
+	switch v.Kind {
+	case yaml.ScalarNode:
+		switch v.ShortTag() {
+		case "!!null":
+			return true
+		case "!!bool":
+			return v.Value == "false"
+		case "!!int", "!!float":
+			f, err := strconv.ParseFloat(v.Value, 64)
+			return err == nil && f == 0
+		default:
+			return v.Value == ""
+		}
+	case yaml.MappingNode, yaml.SequenceNode:
+		return len(v.Content) == 0
+	default:
+		return false
+	}
+}
+
+// marshalRule returns the content of the rule file filename.
+func marshalRule(filename string, rule Rule) ([]byte, error) {
+	if in(fileExt(filename), ".json") {
+		return json.MarshalIndent(rule, "", "  ")
+	}
+	return yaml.Marshal(rule)
 }
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestMarshalGeneratedRule(t *testing.T) {
+	tests := []struct {
+		golden string
+		emoji  bool
+	}{
+		{golden: "gen_rule.yaml"},
+		{golden: "gen_rule_emoji.yaml", emoji: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			got, err := marshalGeneratedRule(defaultRule(tt.emoji))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			golden := filepath.Join("testdata", tt.golden)
+			if *update {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("rule file =\n%s\nwant\n%s", got, want)
+			}
+
+			// generated again from the file, as with gen --from-rule
+			rule, err := parseRule(golden, got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			again, err := marshalGeneratedRule(*rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(again, got) {
+				t.Errorf("rule file generated from itself =\n%s\nwant\n%s", again, got)
+			}
+		})
+	}
+}
+
+func TestOrderMapping(t *testing.T) {
+	tests := []struct {
+		name  string
+		yaml  string
+		order []string
+		want  []string
+	}{
+		{name: "reordered", yaml: "c: 1\nb: 2\na: 3\n", order: []string{"a", "b", "c"}, want: []string{"a", "b", "c"}},
+		{name: "unlisted keys follow", yaml: "x: 1\nb: 2\ny: 3\na: 4\n", order: []string{"a", "b"}, want: []string{"a", "b", "x", "y"}},
+		{name: "missing keys", yaml: "b: 1\n", order: []string{"a", "b", "c"}, want: []string{"b"}},
+		{name: "values are keys", yaml: "b: a\na: b\n", order: []string{"a", "b"}, want: []string{"a", "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := yaml.Node{}
+			if err := yaml.Unmarshal([]byte(tt.yaml), &doc); err != nil {
+				t.Fatal(err)
+			}
+			node := doc.Content[0]
+			orderMapping(node, tt.order)
+
+			var keys []string
+			values := make(map[string]string)
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				keys = append(keys, node.Content[i].Value)
+				values[node.Content[i].Value] = node.Content[i+1].Value
+			}
+			if !reflect.DeepEqual(keys, tt.want) {
+				t.Errorf("keys = %v, want %v", keys, tt.want)
+			}
+
+			orig := make(map[string]string)
+			if err := yaml.Unmarshal([]byte(tt.yaml), &orig); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(values, orig) {
+				t.Errorf("values = %v, want %v", values, orig)
+			}
+		})
+	}
+}
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+type lintCmd struct {
+	Batch bool `cli:"batch" help:"lint message files and directories of them concurrently"`
+	JSON  bool `cli:"json" help:"output a JSON line per message"`
+	Fix   bool `cli:"fix" help:"rewrite files in place, fixing type case, trailing period of description, blank line before footers and BREAKING-CHANGE spelling"`
+}
+
+type lintResult struct {
+	Name       string   `json:"name"`
+	OK         bool     `json:"ok"`
+	Violations []string `json:"violations,omitempty"`
+	Fixed      []string `json:"fixed,omitempty"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// Run lints the message files, or the message from stdin without arguments,
+// or the commits in the range (A..B) if the argument is not a file.
+func (c lintCmd) Run(g globalCmd, args []string) error {
+	if c.Batch && len(args) == 0 {
+		return errors.New(tr(msgLintFilesRequired))
+	}
+	if !c.Batch && len(args) > 1 {
+		return errors.New(tr(msgLintUseBatch))
+	}
+
+	repos, _ := plainOpen(".")
+	setLang(string(g.Lang), repos)
+	if disabledBy(repos, nil, "") != "" {
+		return nil
+	}
+	rule, rulePath, err := g.readRule(repos)
+	if err != nil {
+		return err
+	}
+	if disabledBy(nil, rule, rulePath) != "" {
+		return nil
+	}
+	if err := checkMinVersion(rule, rulePath); err != nil {
+		return err
+	}
+
+	var results <-chan lintResult
+	var count int
+	switch {
+	case len(args) == 0:
+		if c.Fix {
+			return errors.New(tr(msgLintFixNeedsFiles))
+		}
+		content, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		results, count = lintMessages(rule, []lintInput{{Name: lintStdinName, Message: stripCommentLines(string(content), commentChar(repos))}}), 1
+
+	case isRangeArg(args[0]):
+		if c.Fix {
+			return errors.New(tr(msgLintFixNeedsFiles))
+		}
+		repos, err := g.openRepository()
+		if err != nil {
+			return err
+		}
+		commits, err := rangeCommits(repos, args[0])
+		if err != nil {
+			return err
+		}
+		inputs := make([]lintInput, 0, len(commits))
+		for i := len(commits) - 1; i >= 0; i-- {
+			inputs = append(inputs, lintInput{Name: shortHash(commits[i].Hash.String()), Message: commits[i].Message})
+		}
+		results, count = lintMessages(rule, inputs), len(inputs)
+
+	default:
+		files, err := listMessageFiles(args)
+		if err != nil {
+			return err
+		}
+		results, count = lintFiles(rule, files, c.Fix, commentChar(repos)), len(files)
+	}
+
+	failed := 0
+	for r := range results {
+		if !r.OK {
+			failed++
+		}
+		c.printResult(r)
+	}
+
+	if c.Batch && !c.JSON {
+		fmt.Fprintln(os.Stderr, tr(msgLintSummary, count, failed))
+	}
+
+	if failed > 0 {
+		return errors.New(tr(msgLintFailed, failed, count))
+	}
+	return nil
+}
+
+// lintStdinName is the name of the message from stdin in the results.
+const lintStdinName = "(stdin)"
+
+// isRangeArg reports whether arg is a range of commits (A..B) rather than a message file.
+func isRangeArg(arg string) bool {
+	if !strings.Contains(arg, "..") {
+		return false
+	}
+	_, err := os.Stat(arg)
+	return err != nil
+}
+
+// lintInput is a message not in a file, such as stdin or a commit.
+type lintInput struct {
+	Name    string
+	Message string
+}
+
+// lintMessages lints inputs and sends the results in their order.
+func lintMessages(rule *Rule, inputs []lintInput) <-chan lintResult {
+	out := make(chan lintResult, len(inputs))
+	for _, in := range inputs {
+		v := lintMessage(rule, in.Message)
+		out <- lintResult{Name: in.Name, OK: len(v) == 0, Violations: v}
+	}
+	close(out)
+	return out
+}
+
+func (c lintCmd) printResult(r lintResult) {
+	if c.JSON {
+		b, _ := json.Marshal(r)
+		fmt.Println(string(b))
+		return
+	}
+
+	switch {
+	case r.Error != "":
+		fmt.Printf("%s: %s\n", r.Name, r.Error)
+		return
+	}
+
+	for _, f := range r.Fixed {
+		fmt.Printf("%s: %s\n", r.Name, tr(msgLintFixed, f))
+	}
+
+	switch {
+	case r.OK:
+		if c.Batch {
+			fmt.Printf("%s: ok\n", r.Name)
+		}
+	default:
+		for _, v := range r.Violations {
+			fmt.Printf("%s: %s\n", r.Name, v)
+		}
+	}
+}
+
+// listMessageFiles expands directories in args into the files in them, sorted by name.
+func listMessageFiles(args []string) ([]string, error) {
+	var files []string
+	for _, a := range args {
+		s, err := os.Stat(a)
+		if err != nil {
+			return nil, err
+		}
+		if !s.IsDir() {
+			files = append(files, a)
+			continue
+		}
+
+		var dirfiles []string
+		err = filepath.WalkDir(a, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.Type().IsRegular() {
+				dirfiles = append(dirfiles, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(dirfiles)
+		files = append(files, dirfiles...)
+	}
+	return files, nil
+}
+
+// lintFiles lints files by GOMAXPROCS workers and sends the results in the order of files.
+// With fix, fixable violations are corrected in the files.
+// Lines starting with comment are not linted.
+func lintFiles(rule *Rule, files []string, fix bool, comment string) <-chan lintResult {
+	results := make([]chan lintResult, len(files))
+	for i := range results {
+		results[i] = make(chan lintResult, 1)
+	}
+
+	jobs := make(chan int)
+	go func() {
+		for i := range files {
+			jobs <- i
+		}
+		close(jobs)
+	}()
+
+	wg := sync.WaitGroup{}
+	for w := 0; w < runtime.GOMAXPROCS(0); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] <- lintFile(rule, files[i], fix, comment)
+			}
+		}()
+	}
+
+	out := make(chan lintResult)
+	go func() {
+		for _, r := range results {
+			out <- <-r
+		}
+		close(out)
+		wg.Wait()
+	}()
+
+	return out
+}
+
+func lintFile(rule *Rule, filename string, fix bool, comment string) lintResult {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return lintResult{Name: filename, Error: err.Error()}
+	}
+
+	msg := stripCommentLines(string(content), comment)
+
+	var fixed []string
+	if fix {
+		msg, fixed = fixMessage(rule, msg)
+		if len(fixed) > 0 {
+			if err := writeFileAtomic(filename, []byte(msg)); err != nil {
+				return lintResult{Name: filename, Error: err.Error()}
+			}
+		}
+	}
+
+	v := lintMessage(rule, msg)
+	return lintResult{
+		Name:       filename,
+		OK:         len(v) == 0,
+		Violations: v,
+		Fixed:      fixed,
+	}
+}
+
+// validateMessage is lintMessage of a message made by the prompts,
+// where a header without a type is valid unless the rule denies empty types.
+func validateMessage(rule *Rule, msg string) []string {
+	if cc, ok := parseCommitMessage(msg); !ok && !rule.DenyEmptyType && strings.TrimSpace(cc.Description) != "" {
+		return nil
+	}
+	return lintMessage(rule, msg)
+}
+
+// lintMessage returns violations of rule in msg.
+func lintMessage(rule *Rule, msg string) []string {
+	var violations []string
+
+	cc, ok := parseCommitMessage(msg)
+	if !ok {
+		if strings.TrimSpace(cc.Description) == "" {
+			return []string{tr(msgLintEmptyMessage)}
+		}
+		return []string{tr(msgLintInvalidHeader, cc.Description)}
+	}
+
+	// made by `git cx wip` to be squashed later
+	if isWip(cc) {
+		return nil
+	}
+
+	ct, found := rule.Types.Get(rule.canonicalType(cc.Type))
+	if found && strings.HasPrefix(cc.Type, "#") {
+		found = false
+	}
+	if rule.DenyAdlibType && !found {
+		violations = append(violations, tr(msgLintUndefinedType, cc.Type))
+	}
+
+	if cc.Description == "" {
+		violations = append(violations, tr(msgLintEmptyDesc))
+	}
+
+	if header, _, _ := strings.Cut(msg, "\n"); rule.MaxHeaderLength > 0 && !rule.WarnOnlyHeaderLength {
+		if n := utf8.RuneCountInString(header); n > rule.MaxHeaderLength {
+			violations = append(violations, tr(msgHeaderTooLong, n, rule.MaxHeaderLength))
+		}
+	}
+
+	for _, key := range missingFooters(rule, cc) {
+		violations = append(violations, tr(msgFooterRequired, key))
+	}
+
+	if found && ct.Breaking == breakingNever && cc.Breaking {
+		violations = append(violations, tr(msgLintBreakingDenied, cc.Type))
+	}
+
+	// corrected by lint --fix, not violations unless the rule says so
+	if rule.DenyFixable {
+		violations = append(violations, fixableViolations(rule, msg)...)
+	}
+
+	return violations
+}
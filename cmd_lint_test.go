@@ -0,0 +1,162 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/shu-go/git-cx/internal/testutil"
+)
+
+func TestLintMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		rule func(r *Rule)
+		msg  string
+		want []string
+	}{
+		{
+			name: "valid",
+			msg:  "feat(api): add retry flag\n",
+		},
+		{
+			name: "trailing period is accepted by default",
+			msg:  "feat: add retry flag.\n",
+		},
+		{
+			name: "uppercase type is accepted by default",
+			msg:  "Feat: add retry flag\n",
+		},
+		{
+			name: "trailing period with denyFixable",
+			rule: func(r *Rule) { r.DenyFixable = true },
+			msg:  "feat: add retry flag.\n",
+			want: []string{tr(msgLintTrailingPeriod)},
+		},
+		{
+			name: "uppercase type with denyFixable",
+			rule: func(r *Rule) { r.DenyFixable = true },
+			msg:  "Feat: add retry flag\n",
+			want: []string{tr(msgLintTypeCase, "Feat", "feat")},
+		},
+		{
+			name: "adlib type",
+			rule: func(r *Rule) { r.DenyAdlibType = true },
+			msg:  "wip: add retry flag\n",
+			want: []string{tr(msgLintUndefinedType, "wip")},
+		},
+		{
+			name: "header too long",
+			rule: func(r *Rule) { r.MaxHeaderLength = 10 },
+			msg:  "feat: add retry flag\n",
+			want: []string{tr(msgHeaderTooLong, 20, 10)},
+		},
+		{
+			name: "header too long, warning only",
+			rule: func(r *Rule) { r.MaxHeaderLength, r.WarnOnlyHeaderLength = 10, true },
+			msg:  "feat: add retry flag\n",
+		},
+		{
+			name: "empty",
+			msg:  "\n",
+			want: []string{tr(msgLintEmptyMessage)},
+		},
+		{
+			name: "not conventional",
+			msg:  "add retry flag\n",
+			want: []string{tr(msgLintInvalidHeader, "add retry flag")},
+		},
+		{
+			name: "no space after the colon",
+			msg:  "feat:add retry flag\n",
+			want: []string{tr(msgLintInvalidHeader, "feat:add retry flag")},
+		},
+		{
+			name: "no description",
+			msg:  "feat:\n",
+			want: []string{tr(msgLintEmptyDesc)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := defaultRule(false)
+			if tt.rule != nil {
+				tt.rule(&rule)
+			}
+			if got := lintMessage(&rule, tt.msg); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lintMessage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		rule func(r *Rule)
+		msg  string
+		want int
+	}{
+		{name: "without type", msg: "add retry flag\n", want: 0},
+		{name: "without type, denied", rule: func(r *Rule) { r.DenyEmptyType = true }, msg: "add retry flag\n", want: 1},
+		{name: "trailing period", msg: "feat: add retry flag.\n", want: 0},
+		{name: "trailing period, denied", rule: func(r *Rule) { r.DenyFixable = true }, msg: "feat: add retry flag.\n", want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := defaultRule(false)
+			if tt.rule != nil {
+				tt.rule(&rule)
+			}
+			if got := validateMessage(&rule, tt.msg); len(got) != tt.want {
+				t.Errorf("validateMessage() = %q, want %d violations", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLintFileCutsAtScissors(t *testing.T) {
+	// as written by git commit -v
+	content := "feat: add retry flag\n\n" +
+		"# ------------------------ >8 ------------------------\n" +
+		"# Do not modify or remove the line above.\n" +
+		"diff --git a/a.go b/a.go\n" +
+		"BREAKING-CHANGE: in the diff\n"
+	filename := filepath.Join(t.TempDir(), "COMMIT_EDITMSG")
+	if err := os.WriteFile(filename, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	rule := defaultRule(false)
+	rule.DenyFixable = true
+	if r := lintFile(&rule, filename, false, "#"); !r.OK {
+		t.Errorf("violations = %q, want none", r.Violations)
+	}
+}
+
+func TestLintRange(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	r := testutil.NewRepo(t)
+	r.Commit("chore: init")
+	r.Tag("v1.0.0")
+	r.Commits("feat: add retry flag", "add retry flag", "fix: handle nil")
+	r.Chdir()
+
+	var err error
+	out := captureStdout(t, func() { err = (lintCmd{}).Run(globalCmd{}, []string{"v1.0.0.."}) })
+	if err == nil || err.Error() != tr(msgLintFailed, 1, 3) {
+		t.Errorf("lint = %v, want 1 of 3 failed", err)
+	}
+	if !strings.Contains(out, tr(msgLintInvalidHeader, "add retry flag")) {
+		t.Errorf("output = %q, want the violation of the commit", out)
+	}
+
+	out = captureStdout(t, func() { err = (lintCmd{}).Run(globalCmd{}, []string{"HEAD~1.."}) })
+	if err != nil {
+		t.Errorf("lint = %v, %q, want no violations", err, out)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+type parseCmd struct {
+}
+
+type parsedMessage struct {
+	Conventional bool `json:"conventional"`
+	ConventionalCommit
+}
+
+func (c parseCmd) Run(args []string) error {
+	var content []byte
+	var err error
+	if len(args) > 0 {
+		content, err = os.ReadFile(args[0])
+	} else {
+		content, err = io.ReadAll(os.Stdin)
+	}
+	if err != nil {
+		return err
+	}
+
+	repos, _ := plainOpen(".")
+	cc, ok := parseCommitMessage(stripCommentLines(string(content), commentChar(repos)))
+
+	b, err := json.MarshalIndent(parsedMessage{Conventional: ok, ConventionalCommit: cc}, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(b))
+
+	return nil
+}
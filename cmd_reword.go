@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type rewordLastCmd struct {
+	Type    string `cli:"type=TYPE" help:"new type"`
+	Scope   string `cli:"scope=SCOPE" help:"new scope"`
+	NoScope bool   `cli:"no-scope" help:"remove the scope"`
+	Desc    string `cli:"desc=DESCRIPTION" help:"new description"`
+}
+
+// Run amends the header of HEAD without prompts.
+// Components not specified, and the lines after the header, are kept as they are.
+func (c rewordLastCmd) Run(g globalCmd) error {
+	if c.Type == "" && c.Scope == "" && !c.NoScope && c.Desc == "" {
+		return errors.New(tr(msgRewordNothing))
+	}
+
+	ctx, cancel := newRootContext()
+	defer cancel()
+
+	repos, err := g.openRepository()
+	if err != nil {
+		return err
+	}
+	g.repository = repos
+
+	if err := g.prepare(repos); err != nil {
+		return err
+	}
+
+	ref, err := repos.Head()
+	if err != nil {
+		return err
+	}
+	head, err := repos.CommitObject(ref.Hash())
+	if err != nil {
+		return err
+	}
+
+	oldHeader, rest, found := strings.Cut(head.Message, "\n")
+	if found {
+		rest = "\n" + rest
+	}
+
+	cc, ok := parseCommitMessage(oldHeader)
+	if !ok && c.Type == "" {
+		return errors.New(tr(msgRewordNotConventional))
+	}
+
+	typ, scope, desc := cc.Type, cc.Scope, g.trimEmoji(cc.Type, cc.Scope, cc.Description)
+	if c.Type != "" {
+		typ = g.rule.canonicalType(c.Type)
+		if _, found := g.rule.Types.Get(typ); !found && g.rule.DenyAdlibType {
+			return errors.New(tr(msgAdlibTypeNotAllowed))
+		}
+	}
+	if c.Scope != "" {
+		scope = c.Scope
+	}
+	if c.NoScope {
+		scope = ""
+	}
+	if c.Desc != "" {
+		desc = c.Desc
+	}
+	if desc == "" {
+		return errors.New(tr(msgDescRequired))
+	}
+
+	header, _, formatErr := g.renderHeaders(typ, scope, desc, cc.Bang, changedFiles(head))
+	if formatErr != nil {
+		if err := g.acknowledgeHeaderFallback(formatErr, header, false); err != nil {
+			return err
+		}
+	}
+	msg := header + rest
+
+	if g.Debug {
+		fmt.Print(msg)
+		return nil
+	}
+
+	// --only without paths amends nothing but the message
+	return g.gitCommit(ctx, msg, "--amend", "--only", "--cleanup=verbatim")
+}
+
+// trimEmoji removes the emoji of typ in scope (rendered by the header format) from the head of desc.
+func (c globalCmd) trimEmoji(typ, scope, desc string) string {
+	for _, e := range []string{c.emojiOf(typ, scope, true), c.emojiOf(typ, scope, false)} {
+		if e != "" && strings.HasPrefix(desc, e) {
+			return strings.TrimSpace(strings.TrimPrefix(desc, e))
+		}
+	}
+	return desc
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	prompt "github.com/elk-language/go-prompt"
+	"github.com/shu-go/orderedmap"
+	"gopkg.in/yaml.v3"
+)
+
+type ruleCmd struct {
+	Yes bool `cli:"yes,y" help:"write without confirmation"`
+
+	AddType ruleAddTypeCmd `cli:"add-type" help:"add a type to the rule file" usage:"git cx rule add-type --desc DESCRIPTION [--emoji EMOJI] [--after KEY] KEY"`
+	RmType  ruleRmTypeCmd  `cli:"rm-type" help:"remove a type from the rule file" usage:"git cx rule rm-type KEY"`
+	Set     ruleSetCmd     `cli:"set" help:"set a field of the rule file" usage:"git cx rule set FIELD VALUE"`
+	Dedupe  ruleDedupeCmd  `cli:"dedupe" help:"merge types differing only by case or punctuation, keeping the others as aliases" usage:"git cx rule dedupe"`
+}
+
+type ruleAddTypeCmd struct {
+	Desc  string `cli:"desc=DESCRIPTION" help:"description of the type"`
+	Emoji string `cli:"emoji=EMOJI" help:"emoji of the type (like :sparkles:)"`
+	After string `cli:"after=KEY" help:"insert after this type (default: last)"`
+}
+
+type ruleRmTypeCmd struct {
+}
+
+type ruleSetCmd struct {
+}
+
+func (c ruleAddTypeCmd) Run(g globalCmd, rc ruleCmd, args []string) error {
+	if len(args) != 1 {
+		return errors.New(tr(msgRuleArgs, "KEY"))
+	}
+	key := args[0]
+
+	return rc.editRule(g, func(rule *Rule) error {
+		if rule.Types.Contains(key) {
+			return errors.New(tr(msgRuleTypeExists, key))
+		}
+		if c.After != "" && !rule.Types.Contains(c.After) {
+			return errors.New(tr(msgRuleTypeNotFound, c.After))
+		}
+
+		ct := CommitType{Desc: c.Desc, Emoji: c.Emoji}
+		if c.After == "" {
+			rule.Types.Set(key, ct)
+			return nil
+		}
+
+		// orderedmap can not insert in the middle
+		types := orderedmap.New[string, CommitType]()
+		for _, k := range rule.Types.Keys() {
+			v, _ := rule.Types.Get(k)
+			types.Set(k, v)
+			if k == c.After {
+				types.Set(key, ct)
+			}
+		}
+		rule.Types = types
+		return nil
+	})
+}
+
+func (c ruleRmTypeCmd) Run(g globalCmd, rc ruleCmd, args []string) error {
+	if len(args) != 1 {
+		return errors.New(tr(msgRuleArgs, "KEY"))
+	}
+	key := args[0]
+
+	return rc.editRule(g, func(rule *Rule) error {
+		if !rule.Types.Contains(key) {
+			return errors.New(tr(msgRuleTypeNotFound, key))
+		}
+		rule.Types.Delete(key)
+		return nil
+	})
+}
+
+func (c ruleSetCmd) Run(g globalCmd, rc ruleCmd, args []string) error {
+	if len(args) != 2 {
+		return errors.New(tr(msgRuleArgs, "FIELD VALUE"))
+	}
+
+	return rc.editRule(g, func(rule *Rule) error {
+		return setRuleField(rule, args[0], args[1])
+	})
+}
+
+// editRule applies edit to the rule file and writes it back after showing the diff and confirmation.
+// If there is no rule file, it offers to create one from the default rule.
+func (c ruleCmd) editRule(g globalCmd, edit func(*Rule) error) error {
+	repos, _ := plainOpen(".")
+	setLang(string(g.Lang), repos)
+
+	// a file given by --rule may be created
+	_, path, err := g.readRule(repos)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if isRuleURL(path) {
+		return errors.New(tr(msgRuleURLReadOnly, path))
+	}
+
+	var before []byte
+	var rule *Rule
+	if s, err := os.Stat(longPath(path)); err == nil && !s.IsDir() {
+		// do not overwrite a broken file with the default
+		if rule, err = tryReadRuleFile(path, configFileLimit(repos)); err != nil {
+			return &RuleInvalidError{Path: path, Cause: err}
+		}
+		if rule.Extends != "" {
+			return errors.New(tr(msgRuleExtendsEdit, path, rule.Extends))
+		}
+		if before, err = os.ReadFile(longPath(path)); err != nil {
+			return err
+		}
+	} else {
+		if !c.Yes {
+			if ok, err := confirm(tr(msgRuleCreate, path), true); err != nil {
+				return err
+			} else if !ok {
+				return withMessage(ErrUserAborted, tr(msgAborted))
+			}
+		}
+		r := defaultRule(false)
+		rule = &r
+	}
+
+	if err := edit(rule); err != nil {
+		return err
+	}
+	if unknown := rule.unknownOrderedTypes(); len(unknown) > 0 {
+		warn(tr(msgTypeOrderUnknown, unknown))
+	}
+
+	after, err := marshalRule(path, *rule)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(before, after) {
+		fmt.Fprintln(os.Stderr, tr(msgRuleUnchanged))
+		return nil
+	}
+
+	printLineDiff(os.Stdout, string(before), string(after))
+	if !c.Yes {
+		if ok, err := confirm(tr(msgRuleConfirm, path), false); err != nil {
+			return err
+		} else if !ok {
+			return withMessage(ErrUserAborted, tr(msgAborted))
+		}
+	}
+
+	return writeFileAtomic(path, after)
+}
+
+// setRuleField sets field (a key in the rule file, case-insensitive) to value in YAML syntax.
+// Types are edited by add-type and rm-type instead.
+func setRuleField(rule *Rule, field, value string) error {
+	key := strings.ToLower(field)
+	if !fieldNames(reflect.TypeOf(rawRule{}), "yaml")[key] || key == "types" {
+		return errors.New(tr(msgRuleUnknownField, field))
+	}
+
+	valueNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null"}
+	doc := yaml.Node{}
+	if err := yaml.Unmarshal([]byte(value), &doc); err != nil {
+		return err
+	}
+	if len(doc.Content) > 0 {
+		valueNode = doc.Content[0]
+	}
+
+	node := yaml.Node{}
+	if err := node.Encode(rule); err != nil {
+		return err
+	}
+
+	found := false
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content[i+1] = valueNode
+			found = true
+		}
+	}
+	if !found {
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, valueNode)
+	}
+
+	r := Rule{
+		Types: orderedmap.New[string, CommitType](),
+	}
+	if err := node.Decode(&r); err != nil {
+		return fmt.Errorf("%s: %w", field, err)
+	}
+	*rule = r
+	return nil
+}
+
+// confirm asks a yes/no question. def is the answer for an empty input.
+func confirm(question string, def bool) (bool, error) {
+	answer, err := promptInput(prompt.WithPrefix(question))
+	if err != nil {
+		return false, err
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	if answer == "" {
+		return def, nil
+	}
+	return in(answer, "y", "yes"), nil
+}
+
+// printLineDiff prints the lines removed from a (-) and added in b (+).
+func printLineDiff(w io.Writer, a, b string) {
+	al := strings.Split(strings.TrimRight(a, "\n"), "\n")
+	bl := strings.Split(strings.TrimRight(b, "\n"), "\n")
+	if a == "" {
+		al = nil
+	}
+
+	// longest common subsequence
+	lcs := make([][]int, len(al)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(bl)+1)
+	}
+	for i := len(al) - 1; i >= 0; i-- {
+		for j := len(bl) - 1; j >= 0; j-- {
+			if al[i] == bl[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(al) || j < len(bl) {
+		switch {
+		case i < len(al) && j < len(bl) && al[i] == bl[j]:
+			i++
+			j++
+		case j < len(bl) && (i == len(al) || lcs[i][j+1] >= lcs[i+1][j]):
+			fmt.Fprintln(w, "+ "+bl[j])
+			j++
+		default:
+			fmt.Fprintln(w, "- "+al[i])
+			i++
+		}
+	}
+}
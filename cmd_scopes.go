@@ -0,0 +1,260 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+type scopesCmd struct {
+	List   scopesListCmd   `cli:"list,ls" help:"list the scope history, newest first"`
+	Add    scopesAddCmd    `cli:"add" help:"add a scope to the history as used now" usage:"git cx scopes add SCOPE"`
+	Rm     scopesRmCmd     `cli:"rm" help:"remove a scope from the history" usage:"git cx scopes rm SCOPE"`
+	Rename scopesRenameCmd `cli:"rename" help:"rename a scope in the history" usage:"git cx scopes rename OLD NEW"`
+	Prune  scopesPruneCmd  `cli:"prune" help:"drop the entries beyond scopeHistoryLimit or older than scopeHistoryMaxAge"`
+	Clear  scopesClearCmd  `cli:"clear" help:"remove all the scopes from the history after a confirmation"`
+	Undo   scopesUndoCmd   `cli:"undo" help:"restore the history backed up before the last rm, rename, prune or clear"`
+}
+
+type scopesListCmd struct {
+}
+
+type scopesAddCmd struct {
+}
+
+type scopesRmCmd struct {
+}
+
+type scopesRenameCmd struct {
+}
+
+type scopesPruneCmd struct {
+}
+
+type scopesClearCmd struct {
+	Yes bool `cli:"yes,y" help:"do not ask"`
+}
+
+type scopesUndoCmd struct {
+}
+
+// Run lists the scope history, newest first.
+func (c scopesCmd) Run(g globalCmd) error {
+	return scopesListCmd{}.Run(g)
+}
+
+// Run lists the scope history, newest first.
+func (c scopesListCmd) Run(g globalCmd) error {
+	if err := g.loadScopeHistory(false); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, name := range sortedScopes(g.scopes) {
+		fmt.Println(scopeLine(name, g.scopes[name], now))
+	}
+	return nil
+}
+
+// Run adds the scope as used now, or updates the time of the existing one.
+func (c scopesAddCmd) Run(g globalCmd, args []string) error {
+	if len(args) != 1 {
+		return errors.New(tr(msgRuleArgs, "SCOPE"))
+	}
+	if err := g.loadScopeHistory(true); err != nil {
+		return err
+	}
+
+	entry := g.scopes[args[0]]
+	entry.LastUsed = time.Now()
+	g.scopes[args[0]] = entry
+	return writeScopesFile(g.scopesFileName, g.scopes, g.rule.ScopeTimestampFormat)
+}
+
+// Run removes the scope.
+func (c scopesRmCmd) Run(g globalCmd, args []string) error {
+	if len(args) != 1 {
+		return errors.New(tr(msgRuleArgs, "SCOPE"))
+	}
+	if err := g.loadScopeHistory(true); err != nil {
+		return err
+	}
+
+	if _, found := g.scopes[args[0]]; !found {
+		return errors.New(tr(msgScopeNotFound, args[0]))
+	}
+	if err := backupScopesFile(g.scopesFileName); err != nil {
+		return err
+	}
+	delete(g.scopes, args[0])
+	return writeScopesFile(g.scopesFileName, g.scopes, g.rule.ScopeTimestampFormat)
+}
+
+// Run renames the scope. If the new name is also in the history, the entry used later is kept.
+func (c scopesRenameCmd) Run(g globalCmd, args []string) error {
+	if len(args) != 2 {
+		return errors.New(tr(msgRuleArgs, "OLD NEW"))
+	}
+	if err := g.loadScopeHistory(true); err != nil {
+		return err
+	}
+
+	oldName, newName := args[0], args[1]
+	entry, found := g.scopes[oldName]
+	if !found {
+		return errors.New(tr(msgScopeNotFound, oldName))
+	}
+	if oldName == newName {
+		return nil
+	}
+	if err := backupScopesFile(g.scopesFileName); err != nil {
+		return err
+	}
+	if existing, found := g.scopes[newName]; found && existing.LastUsed.After(entry.LastUsed) {
+		entry = existing
+	}
+	delete(g.scopes, oldName)
+	g.scopes[newName] = entry
+	return writeScopesFile(g.scopesFileName, g.scopes, g.rule.ScopeTimestampFormat)
+}
+
+// Run applies the pruning policy to the scope history and prints the entries removed.
+func (c scopesPruneCmd) Run(g globalCmd) error {
+	if err := g.loadScopeHistory(true); err != nil {
+		return err
+	}
+
+	p, err := scopePruningOf(g.rule, g.repository)
+	if err != nil {
+		return err
+	}
+
+	before := make(Scopes)
+	for name, e := range g.scopes {
+		before[name] = e
+	}
+	now := time.Now()
+	pruned := pruneScopes(g.scopes, p, now)
+	if len(pruned) == 0 {
+		fmt.Println(tr(msgScopePruneNothing))
+		return nil
+	}
+
+	if err := backupScopesFile(g.scopesFileName); err != nil {
+		return err
+	}
+	if err := writeScopesFile(g.scopesFileName, g.scopes, g.rule.ScopeTimestampFormat); err != nil {
+		return err
+	}
+	for _, name := range pruned {
+		fmt.Println(tr(msgScopePruned, scopeLine(name, before[name], now)))
+	}
+	return nil
+}
+
+// Run removes all the scopes, keeping the histories of the prompts.
+func (c scopesClearCmd) Run(g globalCmd) error {
+	if err := g.loadScopeHistory(true); err != nil {
+		return err
+	}
+	if len(g.scopes) == 0 {
+		return nil
+	}
+
+	if !c.Yes {
+		ok, err := confirm(tr(msgScopeClearAsk, len(g.scopes), g.scopesFileName), false)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return withMessage(ErrUserAborted, tr(msgAborted))
+		}
+	}
+
+	if err := backupScopesFile(g.scopesFileName); err != nil {
+		return err
+	}
+	return writeScopesFile(g.scopesFileName, make(Scopes), g.rule.ScopeTimestampFormat)
+}
+
+// Run restores the backup made before the last change by rm, rename, prune or clear.
+func (c scopesUndoCmd) Run(g globalCmd) error {
+	if err := g.loadScopeHistory(false); err != nil {
+		return err
+	}
+	if g.scopesFileName == "" {
+		return errors.New(tr(msgScopeHistoryDisabled))
+	}
+
+	if err := undoScopesFile(g.scopesFileName); err != nil {
+		return err
+	}
+	fmt.Println(tr(msgScopeUndone, g.scopesFileName))
+	return nil
+}
+
+// loadScopeHistory opens the repository and reads the rule and the scope history, found as the commit does.
+// To be written, the history must be enabled, and an existing file must be readable not to be overwritten.
+func (c *globalCmd) loadScopeHistory(writing bool) error {
+	repos, err := c.openRepository()
+	if err != nil {
+		return err
+	}
+	c.repository = repos
+
+	if err := c.prepare(repos); err != nil {
+		return err
+	}
+	if !writing {
+		return nil
+	}
+
+	if c.scopesFileName == "" {
+		return errors.New(tr(msgScopeHistoryDisabled))
+	}
+	if s, err := os.Stat(longPath(c.scopesFileName)); err == nil && !s.IsDir() {
+		scopes, err := tryReadScopesFile(c.scopesFileName, configFileLimit(repos))
+		if err != nil {
+			return fmt.Errorf("%s: %w", c.scopesFileName, err)
+		}
+		c.scopes = scopes
+	}
+	return nil
+}
+
+// scopeLine describes a history entry like `api — last used in 1a2b3c4 (2 days ago)`.
+// Entries without the commit, written by older versions or --debug runs, omit it.
+func scopeLine(name string, e ScopeEntry, now time.Time) string {
+	if e.LastUsed.IsZero() {
+		return name
+	}
+
+	ago := humanizeAge(now.Sub(e.LastUsed))
+	if e.Commit == "" {
+		return tr(msgScopeLastUsed, name, ago)
+	}
+	return tr(msgScopeLastUsedIn, name, shortHash(e.Commit), ago)
+}
+
+// humanizeAge formats d like `2 days ago`.
+func humanizeAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return tr(msgAgoJustNow)
+	case d < time.Hour:
+		return tr(msgAgoMinutes, int(d/time.Minute))
+	case d < 24*time.Hour:
+		return tr(msgAgoHours, int(d/time.Hour))
+	default:
+		return tr(msgAgoDays, int(d/(24*time.Hour)))
+	}
+}
+
+// shortHash abbreviates a commit hash as git log --oneline does.
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
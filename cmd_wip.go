@@ -0,0 +1,119 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+const (
+	defaultQuickCommitType = "wip"
+
+	// wipTrailerToken marks commits by `git cx wip` so that lint and check can find them.
+	wipTrailerToken = "Cx-Wip"
+)
+
+var defaultProtectedBranches = []string{"main", "master"}
+
+type wipCmd struct {
+}
+
+// Run commits the staged files with the quick commit type and a description generated from them, without prompts.
+func (c wipCmd) Run(g globalCmd) error {
+	ctx, cancel := newRootContext()
+	defer cancel()
+
+	repos, err := g.openRepository()
+	if err != nil {
+		return err
+	}
+	g.repository = repos
+
+	if err := g.prepare(repos); err != nil {
+		return err
+	}
+
+	if ref, err := repos.Head(); err == nil && ref.Name().IsBranch() {
+		if branch := ref.Name().Short(); isProtectedBranch(g.rule, branch) {
+			return errors.New(tr(msgWipProtectedBranch, branch))
+		}
+	}
+
+	wt, err := repos.Worktree()
+	if err != nil {
+		return err
+	}
+	st, err := g.worktreeStatus(ctx, wt)
+	if err != nil {
+		return err
+	}
+	staged := stagedFiles(st)
+	if len(staged) == 0 {
+		return withMessage(ErrNothingStaged, tr(msgNoChanges))
+	}
+
+	typ := g.rule.QuickCommitType
+	if typ == "" {
+		typ = defaultQuickCommitType
+	}
+	header, rendered, formatErr := g.renderHeaders(typ, "", wipDescription(staged), false, staged)
+	if formatErr != nil {
+		if err := g.acknowledgeHeaderFallback(formatErr, header, false); err != nil {
+			return err
+		}
+	}
+	msg := header + "\n\n" + wipTrailerToken + ": true"
+
+	if g.Debug {
+		g.status = st
+		g.renames = stagedRenames(repos, st)
+		if rendered != header {
+			g.renderedHeader = rendered
+		}
+		g.printDebugSummary(os.Stderr)
+		fmt.Println(msg)
+		return nil
+	}
+
+	return g.gitCommit(ctx, msg)
+}
+
+// wipDescription is like "api, web — 4 files".
+func wipDescription(staged []string) string {
+	count := fmt.Sprintf("%d files", len(staged))
+	if len(staged) == 1 {
+		count = "1 file"
+	}
+
+	dirs := stagedDirs(staged, 1)
+	if len(dirs) == 0 {
+		return count
+	}
+	return strings.Join(dirs, ", ") + " — " + count
+}
+
+func isProtectedBranch(rule *Rule, branch string) bool {
+	globs := rule.ProtectedBranches
+	if globs == nil {
+		globs = defaultProtectedBranches
+	}
+
+	for _, g := range globs {
+		if ok, _ := path.Match(g, branch); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// isWip reports whether cc is made by `git cx wip`.
+func isWip(cc ConventionalCommit) bool {
+	for _, f := range cc.Footers {
+		if strings.EqualFold(f.Token, wipTrailerToken) {
+			return true
+		}
+	}
+	return false
+}
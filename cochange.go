@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+const (
+	// cochangeMaxCommits is how many commits from HEAD are looked up for co-changed scopes.
+	cochangeMaxCommits = 200
+
+	// cochangePerFile is how many commits changing each staged file are enough.
+	cochangePerFile = 3
+
+	// cochangeBudget is the time limit of the lookup; what has been found by then is used.
+	cochangeBudget = 300 * time.Millisecond
+)
+
+// scopeCount is a scope and how many commits used it.
+type scopeCount struct {
+	Scope string
+	Count int
+}
+
+// cochangedScopes returns the scopes of the recent commits that changed the staged files, most used first,
+// if Rule.ScopeSources has cochange.
+func (c globalCmd) cochangedScopes(ctx context.Context) []scopeCount {
+	if c.rule == nil || !c.rule.usesScopeSource(scopeSourceCochange) {
+		return nil
+	}
+
+	return cochangedScopes(ctx, c.commits, c.scopeFiles(), cochangeMaxCommits, time.Now().Add(cochangeBudget))
+}
+
+// cochangedScopes looks up the latest maxCommits commits until each of files has been changed
+// by cochangePerFile commits or the deadline passes, and counts the scopes of the commits changing any of files.
+// It returns nil if ctx is done.
+func cochangedScopes(ctx context.Context, log *commitLog, files []string, maxCommits int, deadline time.Time) []scopeCount {
+	if len(files) == 0 {
+		return nil
+	}
+
+	remaining := make(map[string]int, len(files))
+	for _, f := range files {
+		remaining[f] = cochangePerFile
+	}
+
+	counts := make(map[string]int)
+	for i := 0; i < maxCommits && len(remaining) > 0 && time.Now().Before(deadline); i++ {
+		if ctx.Err() != nil {
+			return nil
+		}
+		commits := log.latest(ctx, i+1)
+		if len(commits) <= i {
+			break
+		}
+		commit := commits[i]
+
+		touched := false
+		for _, f := range log.changedFiles(commit) {
+			if n, found := remaining[f]; found {
+				touched = true
+				if n <= 1 {
+					delete(remaining, f)
+				} else {
+					remaining[f] = n - 1
+				}
+			}
+		}
+		if !touched {
+			continue
+		}
+
+		if cc, ok := log.parse(commit); ok && cc.Scope != "" {
+			counts[cc.Scope]++
+		}
+	}
+
+	result := make([]scopeCount, 0, len(counts))
+	for s, n := range counts {
+		result = append(result, scopeCount{Scope: s, Count: n})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Scope < result[j].Scope
+	})
+	return result
+}
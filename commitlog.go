@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+)
+
+const (
+	commitCacheDirName  = "cx-cache"
+	commitCacheFileName = "commits.json"
+
+	// commitCacheVersion must be incremented when parseCommitMessage changes its results,
+	// so that older caches are discarded.
+	commitCacheVersion = 1
+)
+
+// commitLog walks the history from HEAD at most once per invocation and parses the messages once per commit,
+// for all the features reading the history (check, type frequency, scope paths and the default scope).
+//
+// The parsed messages are also cached on disk in <gitdir>/cx-cache, keyed by the commit hash.
+// Since commits are immutable, the cache never needs invalidation.
+type commitLog struct {
+	repos *git.Repository
+
+	// walked are the commits from HEAD, newest first
+	walked []*object.Commit
+	iter   object.CommitIter
+	done   bool
+
+	cacheFile string
+	parsed    map[string]parsedCommit
+	dirty     bool
+
+	// files are the changed files per commit, for this run only
+	files map[plumbing.Hash][]string
+}
+
+// parsedCommit is the cached result of parseCommitMessage.
+type parsedCommit struct {
+	Conventional bool               `json:"conventional"`
+	Commit       ConventionalCommit `json:"commit"`
+}
+
+type commitCache struct {
+	Version int                     `json:"version"`
+	Commits map[string]parsedCommit `json:"commits"`
+}
+
+// newCommitLog returns the commit log of repos, with the on-disk cache unless noCache.
+func newCommitLog(repos *git.Repository, noCache bool) *commitLog {
+	l := &commitLog{
+		repos:  repos,
+		parsed: make(map[string]parsedCommit),
+		files:  make(map[plumbing.Hash][]string),
+	}
+	if repos == nil || noCache {
+		return l
+	}
+
+	fs, ok := repos.Storer.(*filesystem.Storage)
+	if !ok {
+		return l
+	}
+	l.cacheFile = filepath.Join(fs.Filesystem().Root(), commitCacheDirName, commitCacheFileName)
+
+	content, err := os.ReadFile(longPath(l.cacheFile))
+	if err != nil {
+		return l
+	}
+	cache := commitCache{}
+	if err := json.Unmarshal(content, &cache); err == nil && cache.Version == commitCacheVersion && cache.Commits != nil {
+		l.parsed = cache.Commits
+	}
+	return l
+}
+
+// latest returns up to n commits from HEAD, newest first.
+// It returns nil once ctx is done; the walk goes on from where it stopped in the next call.
+func (l *commitLog) latest(ctx context.Context, n int) []*object.Commit {
+	if l == nil || l.repos == nil {
+		return nil
+	}
+
+	if l.iter == nil && !l.done {
+		iter, err := l.repos.Log(&git.LogOptions{})
+		if err != nil {
+			l.done = true
+			return nil
+		}
+		l.iter = iter
+	}
+
+	for !l.done && len(l.walked) < n {
+		if ctx.Err() != nil {
+			return nil
+		}
+		commit, err := l.iter.Next()
+		if err != nil {
+			l.done = true
+			l.iter.Close()
+			break
+		}
+		l.walked = append(l.walked, commit)
+	}
+
+	if len(l.walked) < n {
+		return l.walked
+	}
+	return l.walked[:n]
+}
+
+// parse is parseCommitMessage of commit, cached.
+func (l *commitLog) parse(commit *object.Commit) (ConventionalCommit, bool) {
+	if l == nil {
+		return parseCommitMessage(commit.Message)
+	}
+
+	key := commit.Hash.String()
+	if p, found := l.parsed[key]; found {
+		return p.Commit, p.Conventional
+	}
+
+	cc, ok := parseCommitMessage(commit.Message)
+	l.parsed[key] = parsedCommit{Conventional: ok, Commit: cc}
+	l.dirty = true
+	return cc, ok
+}
+
+// parseHash is parse of the commit hash, without reading the commit if cached.
+func (l *commitLog) parseHash(hash plumbing.Hash) (ConventionalCommit, bool) {
+	if p, found := l.parsed[hash.String()]; found {
+		return p.Commit, p.Conventional
+	}
+
+	commit, err := l.repos.CommitObject(hash)
+	if err != nil {
+		return ConventionalCommit{}, false
+	}
+	return l.parse(commit)
+}
+
+// changedFiles is changedFiles of commit, cached during the run.
+func (l *commitLog) changedFiles(commit *object.Commit) []string {
+	if l == nil {
+		return changedFiles(commit)
+	}
+
+	if files, found := l.files[commit.Hash]; found {
+		return files
+	}
+	files := changedFiles(commit)
+	l.files[commit.Hash] = files
+	return files
+}
+
+// save writes the on-disk cache if anything has been parsed.
+// Errors are ignored since it is only a cache.
+func (l *commitLog) save() {
+	if l == nil || !l.dirty || l.cacheFile == "" {
+		return
+	}
+
+	content, err := json.Marshal(commitCache{Version: commitCacheVersion, Commits: l.parsed})
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(longPath(filepath.Dir(l.cacheFile)), os.ModePerm); err != nil {
+		return
+	}
+	if writeFileAtomic(l.cacheFile, content) == nil {
+		l.dirty = false
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shu-go/git-cx/internal/testutil"
+)
+
+func TestCommitLogLatest(t *testing.T) {
+	r := testutil.NewRepo(t)
+	hashes := r.Commits("feat: a", "fix: b", "docs: c")
+
+	l := newCommitLog(r.Repository, true)
+	tests := []struct {
+		n    int
+		want int
+	}{
+		{n: 1, want: 1},
+		{n: 2, want: 2},
+		{n: 10, want: 3},
+		{n: 2, want: 2},
+	}
+	for _, tt := range tests {
+		got := l.latest(context.Background(), tt.n)
+		if len(got) != tt.want {
+			t.Fatalf("latest(%d) = %d commits, want %d", tt.n, len(got), tt.want)
+		}
+		for i, c := range got {
+			if want := hashes[len(hashes)-1-i]; c.Hash != want {
+				t.Errorf("latest(%d)[%d] = %s, want %s", tt.n, i, c.Hash, want)
+			}
+		}
+	}
+}
+
+// The providers reading the history give up with empty results once the context is done.
+func TestHistoryCanceled(t *testing.T) {
+	r := testutil.NewRepo(t)
+	r.CommitFile("api/a.go", "package api\n", "feat(api): a")
+	r.CommitFile("api/b.go", "package api\n", "fix(api): b")
+	r.Tag("v1.2.0")
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	l := newCommitLog(r.Repository, true)
+	if got := l.latest(canceled, 10); got != nil {
+		t.Errorf("latest() = %d commits", len(got))
+	}
+	if got := cochangedScopes(canceled, l, []string{"api/a.go"}, 10, time.Now().Add(time.Minute)); got != nil {
+		t.Errorf("cochangedScopes() = %v", got)
+	}
+	if got := countTypes(canceled, l, 10); got != nil {
+		t.Errorf("countTypes() = %v", got)
+	}
+	if got := scopePathsFromLog(canceled, l, 10, 1); got != nil {
+		t.Errorf("scopePathsFromLog() = %v", got)
+	}
+	if got := latestVersionTag(canceled, r.Repository); got != "" {
+		t.Errorf("latestVersionTag() = %q", got)
+	}
+
+	// the walk goes on with another context
+	if got := l.latest(context.Background(), 10); len(got) != 2 {
+		t.Errorf("latest() = %d commits, want 2", len(got))
+	}
+	if got := countTypes(context.Background(), l, 10); got["feat"] != 1 || got["fix"] != 1 {
+		t.Errorf("countTypes() = %v", got)
+	}
+	if got := latestVersionTag(context.Background(), r.Repository); got != "v1.2.0" {
+		t.Errorf("latestVersionTag() = %q, want v1.2.0", got)
+	}
+}
+
+func TestCommitLogCache(t *testing.T) {
+	r := testutil.NewRepo(t)
+	hashes := r.Commits("feat(api)!: a", "not conventional")
+	cacheFile := filepath.Join(r.Dir, ".git", commitCacheDirName, commitCacheFileName)
+
+	// --no-cache
+	l := newCommitLog(r.Repository, true)
+	for _, c := range l.latest(context.Background(), 10) {
+		l.parse(c)
+	}
+	l.save()
+	if _, err := os.Stat(cacheFile); !os.IsNotExist(err) {
+		t.Fatalf("cache is written with noCache: %v", err)
+	}
+
+	l = newCommitLog(r.Repository, false)
+	for _, c := range l.latest(context.Background(), 10) {
+		l.parse(c)
+	}
+	l.save()
+
+	content, err := os.ReadFile(cacheFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache := commitCache{}
+	if err := json.Unmarshal(content, &cache); err != nil {
+		t.Fatal(err)
+	}
+	if cache.Version != commitCacheVersion || len(cache.Commits) != 2 {
+		t.Fatalf("cache = %+v", cache)
+	}
+
+	// a cached result is used without parsing the message
+	key := hashes[0].String()
+	cache.Commits[key] = parsedCommit{Conventional: true, Commit: ConventionalCommit{Type: "cached"}}
+	write := func(cache commitCache) {
+		content, err := json.Marshal(cache)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(cacheFile, content, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(cache)
+	if cc, ok := newCommitLog(r.Repository, false).parseHash(hashes[0]); !ok || cc.Type != "cached" {
+		t.Errorf("parseHash() = %+v, %v, want the cached one", cc, ok)
+	}
+	if cc, ok := newCommitLog(r.Repository, true).parseHash(hashes[0]); !ok || cc.Type != "feat" || !cc.Breaking {
+		t.Errorf("parseHash() with noCache = %+v, %v, want parsed", cc, ok)
+	}
+
+	// a cache of another version is discarded
+	cache.Version = commitCacheVersion - 1
+	write(cache)
+	if cc, ok := newCommitLog(r.Repository, false).parseHash(hashes[0]); !ok || cc.Type != "feat" {
+		t.Errorf("parseHash() with an old cache = %+v, %v, want parsed", cc, ok)
+	}
+	if _, ok := newCommitLog(r.Repository, false).parseHash(hashes[1]); ok {
+		t.Error("a message not conventional is parsed as conventional")
+	}
+}
+
+// BenchmarkCommitLog compares parsing the history with and without the on-disk cache.
+func BenchmarkCommitLog(b *testing.B) {
+	r := testutil.NewRepo(b)
+	msgs := make([]string, 500)
+	for i := range msgs {
+		msgs[i] = fmt.Sprintf("feat(api): change %d\n\nThe body.\n\nRefs: #%d", i, i)
+	}
+	r.Commits(msgs...)
+
+	// fill the cache
+	l := newCommitLog(r.Repository, false)
+	for _, c := range l.latest(context.Background(), len(msgs)) {
+		l.parse(c)
+	}
+	l.save()
+
+	for _, noCache := range []bool{true, false} {
+		b.Run(fmt.Sprintf("noCache=%v", noCache), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				l := newCommitLog(r.Repository, noCache)
+				for _, c := range l.latest(context.Background(), len(msgs)) {
+					l.parse(c)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	git "github.com/go-git/go-git/v5"
+)
+
+const (
+	// defaultConfigFileLimit is the size limit of rule and scope history files.
+	defaultConfigFileLimit = 1 << 20
+
+	configMaxFileSize = "maxFileSize"
+)
+
+const (
+	formatYAML = "YAML"
+	formatJSON = "JSON"
+)
+
+type fileTooLargeError struct {
+	Name  string
+	Size  int64
+	Limit int64
+}
+
+func (e *fileTooLargeError) Error() string {
+	return tr(msgFileTooLarge, e.Name, e.Size, e.Limit)
+}
+
+// configFileLimit returns the size limit by gitconfig cx.maxFileSize (bytes, with k, m or g suffix), or the default.
+func configFileLimit(repos *git.Repository) int64 {
+	if repos == nil {
+		return defaultConfigFileLimit
+	}
+	cfg := getGitConfig(repos, configMaxFileSize)
+	if cfg == nil {
+		return defaultConfigFileLimit
+	}
+	if n, err := parseSize(*cfg); err == nil && n > 0 {
+		return n
+	}
+	return defaultConfigFileLimit
+}
+
+// parseSize parses a size like git does (1024, 512k, 1m, 1g).
+func parseSize(s string) (int64, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	unit := int64(1)
+	switch {
+	case strings.HasSuffix(s, "k"):
+		unit = 1 << 10
+	case strings.HasSuffix(s, "m"):
+		unit = 1 << 20
+	case strings.HasSuffix(s, "g"):
+		unit = 1 << 30
+	}
+	if unit != 1 {
+		s = s[:len(s)-1]
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return n * unit, nil
+}
+
+// readConfigFile reads filename up to limit bytes.
+// It returns nil without an error if filename is a directory.
+func readConfigFile(filename string, limit int64) ([]byte, error) {
+	s, err := os.Stat(longPath(filename))
+	if err != nil || s.IsDir() {
+		return nil, err
+	}
+	if s.Size() > limit {
+		return nil, &fileTooLargeError{Name: filename, Size: s.Size(), Limit: limit}
+	}
+
+	file, err := os.Open(longPath(filename))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	// the size may change, or may not be known (named pipes, ...)
+	content, err := io.ReadAll(io.LimitReader(file, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(content)) > limit {
+		return nil, &fileTooLargeError{Name: filename, Size: int64(len(content)), Limit: limit}
+	}
+	return content, nil
+}
+
+// isJSONContent reports whether content should be parsed as JSON:
+// by the extension of filename, or by the first non-whitespace character ({ or [) if unknown.
+func isJSONContent(filename string, content []byte) bool {
+	switch fileExt(filename) {
+	case ".json":
+		return true
+	case ".yaml", ".yml":
+		return false
+	}
+
+	i := strings.IndexFunc(string(content), func(r rune) bool {
+		return !unicode.IsSpace(r) && r != '\ufeff'
+	})
+	return i >= 0 && (content[i] == '{' || content[i] == '[')
+}
+
+// withJSONPosition prefixes err with the line and the column in content, if err tells the offset.
+func withJSONPosition(content []byte, err error) error {
+	var offset int64 = -1
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset
+	case errors.As(err, &typeErr):
+		offset = typeErr.Offset
+	}
+	if offset < 0 || offset > int64(len(content)) {
+		return err
+	}
+
+	before := content[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := utf8.RuneCount(before[bytes.LastIndexByte(before, '\n')+1:])
+	return fmt.Errorf("line %d, column %d: %w", line, column, err)
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shu-go/git-cx/internal/testutil"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    int64
+		wantErr bool
+	}{
+		{s: "1024", want: 1024},
+		{s: "512k", want: 512 << 10},
+		{s: "1M", want: 1 << 20},
+		{s: " 2g ", want: 2 << 30},
+		{s: "1.5m", wantErr: true},
+		{s: "m", wantErr: true},
+		{s: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got, err := parseSize(tt.s)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("parseSize(%q) = %d, %v, want %d (error: %v)", tt.s, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigFileLimit(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int64
+	}{
+		{value: "", want: defaultConfigFileLimit},
+		{value: "4m", want: 4 << 20},
+		{value: "0", want: defaultConfigFileLimit},
+		{value: "huge", want: defaultConfigFileLimit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+			r := testutil.NewRepo(t)
+			if tt.value != "" {
+				r.SetConfig("cx", configMaxFileSize, tt.value)
+			}
+			if got := configFileLimit(r.Repository); got != tt.want {
+				t.Errorf("configFileLimit() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	small := filepath.Join(dir, "small.yaml")
+	large := filepath.Join(dir, "large.yaml")
+	if err := os.WriteFile(small, []byte("types: {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(large, []byte(strings.Repeat("# padding\n", 20)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		filename string
+		want     string
+		tooLarge bool
+		notExist bool
+	}{
+		{name: "within the limit", filename: small, want: "types: {}\n"},
+		{name: "over the limit", filename: large, tooLarge: true},
+		{name: "directory", filename: dir},
+		{name: "missing", filename: filepath.Join(dir, "missing.yaml"), notExist: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readConfigFile(tt.filename, 100)
+			var tooLarge *fileTooLargeError
+			switch {
+			case tt.tooLarge:
+				if !errors.As(err, &tooLarge) || tooLarge.Name != tt.filename || tooLarge.Size != 200 || tooLarge.Limit != 100 {
+					t.Errorf("readConfigFile() = %v, want too large", err)
+				}
+				if err != nil && !strings.Contains(err.Error(), tt.filename) {
+					t.Errorf("%q does not name the file", err)
+				}
+			case tt.notExist:
+				if !errors.Is(err, os.ErrNotExist) {
+					t.Errorf("readConfigFile() = %v, want not exist", err)
+				}
+			default:
+				if err != nil || string(got) != tt.want {
+					t.Errorf("readConfigFile() = %q, %v, want %q", got, err, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestIsJSONContent(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		content  string
+		want     bool
+	}{
+		{name: "json by extension", filename: "rule.json", content: "types: {}", want: true},
+		{name: "yaml by extension", filename: "rule.yaml", content: `{"types": {}}`},
+		{name: "yml in upper case", filename: "RULE.YML", content: `{"types": {}}`},
+		{name: "object", filename: ".cx", content: "\n  {\"types\": {}}", want: true},
+		{name: "array", filename: ".cx", content: "[]", want: true},
+		{name: "with BOM", filename: ".cx", content: "\ufeff{}", want: true},
+		{name: "yaml", filename: ".cx", content: "types:\n  feat: {}\n"},
+		{name: "yaml flow mapping after a comment", filename: ".cx", content: "# {\n{types: {}}"},
+		{name: "empty", filename: ".cx", content: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isJSONContent(tt.filename, []byte(tt.content)); got != tt.want {
+				t.Errorf("isJSONContent(%q, %q) = %v, want %v", tt.filename, tt.content, got, tt.want)
+			}
+		})
+	}
+}
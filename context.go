@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"os/signal"
+
+	git "github.com/go-git/go-git/v5"
+)
+
+// newRootContext returns a context canceled by Ctrl+C, with the cause ErrInterrupted.
+//
+// The process does not exit on Ctrl+C but returns the error through the callers,
+// so that their defers, such as removing temporary files and saving the autosave, are run.
+// Reading prompts does not watch the context; Ctrl+C in a prompt is a key, not a signal.
+// The body is read from stdin line by line, not in a prompt, so it does (see readLineContext).
+func newRootContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+	go func() {
+		select {
+		case <-sig:
+			cancel(errInterrupted())
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(sig)
+		cancel(nil)
+	}
+}
+
+// opContext returns a context for an operation that may stall, limited by --timeout.
+func (c globalCmd) opContext(parent context.Context) (context.Context, context.CancelFunc) {
+	if c.Timeout <= 0 {
+		return context.WithCancel(parent)
+	}
+	return context.WithTimeout(parent, c.Timeout)
+}
+
+// statusContext is wt.Status that returns the cause of ctx when ctx is done first.
+func statusContext(ctx context.Context, wt *git.Worktree) (git.Status, error) {
+	type result struct {
+		st  git.Status
+		err error
+	}
+
+	ch := make(chan result, 1)
+	go func() {
+		st, err := wt.Status()
+		ch <- result{st: st, err: err}
+	}()
+
+	select {
+	case r := <-ch:
+		return r.st, r.err
+	case <-ctx.Done():
+		return nil, context.Cause(ctx)
+	}
+}
+
+// readLineContext is r.ReadLine that returns the cause of ctx when ctx is done first.
+//
+// Reading stdin can not be canceled, so the read is left behind; the process is going to exit anyway.
+func readLineContext(ctx context.Context, r *bufio.Reader) (string, error) {
+	type result struct {
+		line string
+		err  error
+	}
+
+	ch := make(chan result, 1)
+	go func() {
+		line, _, err := r.ReadLine()
+		ch <- result{line: string(line), err: err}
+	}()
+
+	select {
+	case r := <-ch:
+		return r.line, r.err
+	case <-ctx.Done():
+		return "", context.Cause(ctx)
+	}
+}
+
+func (c globalCmd) worktreeStatus(ctx context.Context, wt *git.Worktree) (git.Status, error) {
+	ctx, cancel := c.opContext(ctx)
+	defer cancel()
+
+	st, err := statusContext(ctx, wt)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.IgnoreSubmodules || (c.rule != nil && c.rule.IgnoreSubmodules) {
+		st = excludePaths(st, submodulePaths(c.repository, wt))
+	}
+	return st, nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestReadLineContext(t *testing.T) {
+	t.Run("a line", func(t *testing.T) {
+		pr, pw := io.Pipe()
+		defer pr.Close()
+		go pw.Write([]byte("the body\n"))
+
+		got, err := readLineContext(context.Background(), bufio.NewReader(pr))
+		if err != nil || got != "the body" {
+			t.Errorf("readLineContext() = %q, %v", got, err)
+		}
+	})
+
+	// Ctrl+C while nothing is typed
+	t.Run("interrupted", func(t *testing.T) {
+		pr, _ := io.Pipe()
+		defer pr.Close()
+
+		ctx, cancel := context.WithCancelCause(context.Background())
+		defer cancel(nil)
+		time.AfterFunc(50*time.Millisecond, func() { cancel(errInterrupted()) })
+
+		_, err := readLineContext(ctx, bufio.NewReader(pr))
+		if !errors.Is(err, ErrInterrupted) {
+			t.Errorf("readLineContext() = %v, want %v", err, ErrInterrupted)
+		}
+	})
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	prompt "github.com/elk-language/go-prompt"
+	pstrings "github.com/elk-language/go-prompt/strings"
+	"gopkg.in/yaml.v3"
+)
+
+// Rule.Prompts are questions of the project, like a ticket ID, asked after the standard prompts.
+// The answers are the variables of HeaderFormat, BodyFormat and FooterFormat by the names of the prompts.
+// The histories of the prompts are kept in the scope history file under histories, a prompt each:
+//
+//	version: 2
+//	scopes: ...
+//	histories:
+//	  ticket:
+//	    ABC-123: timestamp
+
+// PromptDef is a question asked after the standard prompts.
+type PromptDef struct {
+	// Name is the variable of the answer in the templates, like ticket for {{.ticket}}
+	Name string `json:"name"`
+
+	// Label is the prefix of the prompt (default: Name)
+	Label string `json:"label,omitempty" yaml:",omitempty"`
+
+	// Required asks again on an empty answer
+	Required bool `json:"required,omitempty" yaml:",omitempty"`
+
+	// Suggestions are completed in the prompt
+	Suggestions []string `json:"suggestions,omitempty" yaml:",omitempty"`
+
+	// History records the answers in the scope history file and completes them, newest first
+	History bool `json:"history,omitempty" yaml:",omitempty"`
+}
+
+// PromptHistories are the answers of Rule.Prompts used, by the name of the prompt.
+type PromptHistories map[string]map[string]time.Time
+
+// promptHistoryLimit is the number of answers kept a prompt.
+const promptHistoryLimit = 50
+
+var promptNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// checkPrompts drops the prompts of rule with a warning if their names can not be variables of the templates:
+// not an identifier, the name of a standard variable, or used twice.
+func checkPrompts(rule *Rule, rulePath string) {
+	reserved := append(append([]string{}, headerVariables...), messageVariables...)
+	for _, def := range rule.Footers {
+		if def.Key != "" {
+			reserved = append(reserved, footerVariable(def.Key))
+		}
+	}
+
+	var prompts []PromptDef
+	for _, def := range rule.Prompts {
+		if !promptNamePattern.MatchString(def.Name) || in(def.Name, reserved...) {
+			warn(tr(msgPromptNameInvalid, def.Name, rulePath))
+			continue
+		}
+		reserved = append(reserved, def.Name)
+		prompts = append(prompts, def)
+	}
+	rule.Prompts = prompts
+}
+
+// promptVariables returns the names of the prompts of the rule.
+func (r Rule) promptVariables() []string {
+	names := make([]string, 0, len(r.Prompts))
+	for _, def := range r.Prompts {
+		names = append(names, def.Name)
+	}
+	return names
+}
+
+// addPromptAnswers adds the answers of the prompts of rule to data, empty if not answered.
+func addPromptAnswers(data map[string]string, rule *Rule, answers map[string]string) {
+	for _, def := range rule.Prompts {
+		data[def.Name] = answers[def.Name]
+	}
+}
+
+// promptCustom asks the prompts of the rule in order, and returns the answers by name.
+// Without prompts, a required one is an error.
+func (c globalCmd) promptCustom() (map[string]string, error) {
+	answers := make(map[string]string)
+
+	for _, def := range c.rule.Prompts {
+		if c.promptless() {
+			if def.Required {
+				return nil, withMessage(ErrInvalidMessage, tr(msgPromptRequired, def.Name))
+			}
+			continue
+		}
+
+		label := def.Label
+		if label == "" {
+			label = def.Name
+		}
+
+		var items []prompt.Suggest
+		if def.History {
+			for _, v := range sortedPromptHistory(c.promptHistories[def.Name]) {
+				items = append(items, prompt.Suggest{Text: v})
+			}
+		}
+		for _, s := range def.Suggestions {
+			if !def.History || !c.promptHistories.has(def.Name, s) {
+				items = append(items, prompt.Suggest{Text: s})
+			}
+		}
+		completer := func(in prompt.Document) ([]prompt.Suggest, pstrings.RuneNumber, pstrings.RuneNumber) {
+			endIndex := in.CurrentRuneIndex()
+			return prompt.FilterHasPrefix(items, in.TextBeforeCursor(), true), 0, endIndex
+		}
+
+		for {
+			opts := []prompt.Option{prompt.WithPrefix(label + ": "), prompt.WithCompleter(completer)}
+			if len(items) > 0 {
+				opts = append(opts, prompt.WithShowCompletionAtStart())
+			}
+			value, err := promptInput(opts...)
+			if err != nil {
+				return nil, err
+			}
+			value = strings.TrimSpace(value)
+			if value != "" {
+				answers[def.Name] = value
+				break
+			}
+			if !def.Required {
+				break
+			}
+			fmt.Fprintln(os.Stderr, tr(msgPromptRequired, label))
+		}
+	}
+
+	return answers, nil
+}
+
+// recordPromptHistories adds the answers of the prompts with History to c.promptHistories,
+// and reports whether any is added.
+func (c globalCmd) recordPromptHistories(answers map[string]string, now time.Time) bool {
+	recorded := false
+	for _, def := range c.rule.Prompts {
+		v := answers[def.Name]
+		if !def.History || v == "" {
+			continue
+		}
+
+		h := c.promptHistories[def.Name]
+		if h == nil {
+			h = make(map[string]time.Time)
+			c.promptHistories[def.Name] = h
+		}
+		h[v] = now
+		for _, old := range sortedPromptHistory(h)[min(len(h), promptHistoryLimit):] {
+			delete(h, old)
+		}
+		recorded = true
+	}
+	return recorded
+}
+
+// has reports whether v is in the history of the prompt name.
+func (h PromptHistories) has(name, v string) bool {
+	_, found := h[name][v]
+	return found
+}
+
+// sortedPromptHistory returns the answers of a history, newest first.
+func sortedPromptHistory(h map[string]time.Time) []string {
+	values := make([]string, 0, len(h))
+	for v := range h {
+		values = append(values, v)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		ti, tj := h[values[i]], h[values[j]]
+		if ti.Equal(tj) {
+			return values[i] < values[j]
+		}
+		return ti.After(tj)
+	})
+	return values
+}
+
+// readPromptHistories reads the histories of the scope history file.
+// A file without them, or not readable, has none.
+func readPromptHistories(filename string, limit int64) PromptHistories {
+	histories := make(PromptHistories)
+	if filename == "" {
+		return histories
+	}
+	content, err := readConfigFile(filename, limit)
+	if err != nil || content == nil {
+		return histories
+	}
+	parsePromptHistories(content, histories)
+	return histories
+}
+
+// parsePromptHistories adds the histories in content of the scope history file to histories.
+// Entries that do not parse are skipped.
+func parsePromptHistories(content []byte, histories PromptHistories) {
+	// JSON is YAML
+	node := yaml.Node{}
+	if err := yaml.Unmarshal(content, &node); err != nil || node.Kind != yaml.DocumentNode || len(node.Content) == 0 {
+		return
+	}
+	root := node.Content[0]
+	if !isScopesV2(root) {
+		return
+	}
+	hs := mappingValue(root, "histories")
+	if hs == nil || hs.Kind != yaml.MappingNode {
+		return
+	}
+
+	for i := 0; i+1 < len(hs.Content); i += 2 {
+		name, entries := hs.Content[i].Value, hs.Content[i+1]
+		if entries.Kind != yaml.MappingNode {
+			continue
+		}
+		h := make(map[string]time.Time)
+		for j := 0; j+1 < len(entries.Content); j += 2 {
+			t, err := parseScopeTimestamp(entries.Content[j+1].Value)
+			if err != nil {
+				continue
+			}
+			h[entries.Content[j].Value] = t
+		}
+		histories[name] = h
+	}
+}
+
+// promptHistoriesNode builds the histories of the scope history file, the prompts by name and the answers newest first.
+func promptHistoriesNode(histories PromptHistories, format string) (*yaml.Node, error) {
+	names := make([]string, 0, len(histories))
+	for name, h := range histories {
+		if len(h) > 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for _, name := range names {
+		entries := &yaml.Node{Kind: yaml.MappingNode}
+		for _, v := range sortedPromptHistory(histories[name]) {
+			k, t := yaml.Node{}, yaml.Node{}
+			if err := k.Encode(v); err != nil {
+				return nil, err
+			}
+			if err := t.Encode(formatScopeTimestamp(histories[name][v], format)); err != nil {
+				return nil, err
+			}
+			entries.Content = append(entries.Content, &k, &t)
+		}
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: name}, entries)
+	}
+	return node, nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const (
+	descHistoryFileName = "descriptions.json"
+
+	descHistoryMaxPerPair = 20
+	descHistoryMax        = 200
+)
+
+// descEntry is a description used in a commit, with its type and scope.
+// Plain entries (only descriptions) have neither.
+type descEntry struct {
+	Description string    `json:"description"`
+	Type        string    `json:"type,omitempty"`
+	Scope       string    `json:"scope,omitempty"`
+	LastUsed    time.Time `json:"lastUsed,omitempty"`
+}
+
+// UnmarshalJSON accepts a plain string as well.
+func (e *descEntry) UnmarshalJSON(b []byte) error {
+	var desc string
+	if err := json.Unmarshal(b, &desc); err == nil {
+		*e = descEntry{Description: desc}
+		return nil
+	}
+
+	type rawDescEntry descEntry
+	return json.Unmarshal(b, (*rawDescEntry)(e))
+}
+
+func readDescHistory(filename string) []descEntry {
+	if filename == "" {
+		return nil
+	}
+
+	content, err := os.ReadFile(longPath(filename))
+	if err != nil {
+		return nil
+	}
+
+	var entries []descEntry
+	if err := json.Unmarshal(content, &entries); err != nil {
+		return nil
+	}
+	return entries
+}
+
+func writeDescHistory(filename string, entries []descEntry) error {
+	if filename == "" {
+		return nil
+	}
+
+	content, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(longPath(filepath.Dir(filename)), os.ModePerm); err != nil {
+		return err
+	}
+	return writeFileAtomic(filename, content)
+}
+
+// addDescHistory puts e at the top of entries, newest first,
+// keeping up to descHistoryMaxPerPair entries per type and scope and descHistoryMax in total.
+func addDescHistory(entries []descEntry, e descEntry) []descEntry {
+	result := []descEntry{e}
+	perPair := map[[2]string]int{{e.Type, e.Scope}: 1}
+
+	for _, old := range entries {
+		if old.Description == e.Description && old.Type == e.Type && old.Scope == e.Scope {
+			continue
+		}
+
+		pair := [2]string{old.Type, old.Scope}
+		if perPair[pair] >= descHistoryMaxPerPair {
+			continue
+		}
+		perPair[pair]++
+
+		result = append(result, old)
+		if len(result) >= descHistoryMax {
+			break
+		}
+	}
+	return result
+}
+
+// rankDescHistory returns the descriptions used with typ and scope first, then the others, newest first.
+func rankDescHistory(entries []descEntry, typ, scope string) []string {
+	sorted := make([]descEntry, len(entries))
+	copy(sorted, entries)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		pi := sorted[i].Type == typ && sorted[i].Scope == scope
+		pj := sorted[j].Type == typ && sorted[j].Scope == scope
+		if pi != pj {
+			return pi
+		}
+		return sorted[i].LastUsed.After(sorted[j].LastUsed)
+	})
+
+	seen := make(map[string]bool)
+	var descs []string
+	for _, e := range sorted {
+		if !seen[e.Description] {
+			seen[e.Description] = true
+			descs = append(descs, e.Description)
+		}
+	}
+	return descs
+}
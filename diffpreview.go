@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+)
+
+// While the prompts of a commit are shown, F2 or the answer ?diff shows the staged diff and asks the same prompt again.
+// promptInput leaves the prompt for it, so that go-prompt gives the terminal back before the pager takes it,
+// and enters the prompt again with the text typed so far.
+
+// diffToken is the answer that shows the staged diff, for terminals that do not send F2.
+const diffToken = "?diff"
+
+// stagedDiff shows the staged diff. It is nil outside the prompts of a commit, when F2 and ?diff are not handled.
+var stagedDiff func() error
+
+// enableDiffPreview lets the prompts show the staged diff until the flow ends.
+func (c *globalCmd) enableDiffPreview(f *commitFlow) error {
+	if c.promptless() {
+		return nil
+	}
+
+	stagedDiff = func() error {
+		return c.showStagedDiff(f.ctx)
+	}
+	f.onEnd(func() {
+		stagedDiff = nil
+	})
+	return nil
+}
+
+// showStagedDiff runs git diff --cached in the root of the worktree, which pages and colors it as configured
+// (core.pager, $GIT_PAGER or $PAGER) when the output is a terminal.
+// The output is stdout if it is a terminal, or else stderr, not to mix the diff into the message written to stdout.
+func (c globalCmd) showStagedDiff(ctx context.Context) error {
+	args := []string{"diff", "--cached"}
+	if c.IgnoreSubmodules || c.rule.IgnoreSubmodules {
+		args = append(args, "--ignore-submodules")
+	}
+
+	var out io.Writer = os.Stderr
+	if isTerminal(os.Stdout) {
+		out = os.Stdout
+	}
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	if wt, err := c.repository.Worktree(); err == nil {
+		cmd.Dir = wt.Filesystem.Root()
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = out
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// isTerminal reports whether f is a terminal (a character device).
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	git "github.com/go-git/go-git/v5"
+)
+
+// A repository can opt out of git-cx, for vendored or mirror repositories whose hooks run git-cx anyway:
+// by gitconfig cx.disabled, a .cx-disable file at the root of the worktree, or disabled: true in the rule.
+// Then `git cx` is a plain `git commit` and `git cx lint` accepts any message.
+
+const (
+	configDisabled     = "disabled"
+	disabledMarkerFile = ".cx-disable"
+)
+
+// disabledBy returns what disables git-cx in repos, or "".
+// rule is nil if it is not read yet.
+func disabledBy(repos *git.Repository, rule *Rule, rulePath string) string {
+	if repos != nil {
+		if cfg := getGitConfig(repos, configDisabled); cfg != nil {
+			if on, _ := strconv.ParseBool(strings.TrimSpace(*cfg)); on {
+				return "gitconfig cx." + configDisabled
+			}
+		}
+		if root := worktreeRoot(repos); root != "" {
+			marker := filepath.Join(root, disabledMarkerFile)
+			if _, err := os.Stat(longPath(marker)); err == nil {
+				return marker
+			}
+		}
+	}
+	if rule != nil && rule.Disabled {
+		return rulePath
+	}
+	return ""
+}
+
+// passThroughIfDisabled commits by plain git commit and ends the flow if git-cx is disabled.
+// It runs twice, before the rule is read so that a broken rule does not matter, and after.
+func (c *globalCmd) passThroughIfDisabled(f *commitFlow) error {
+	source := disabledBy(c.repository, c.rule, c.rulePath)
+	if source == "" {
+		return nil
+	}
+
+	fmt.Fprintln(os.Stderr, tr(msgDisabled, source))
+	f.stop()
+	return c.passThroughCommit(f.ctx, f.args)
+}
+
+// passThroughCommit runs git commit with the options git-cx shares with it, and args.
+// --debug and --dry-run are git commit --dry-run.
+func (c globalCmd) passThroughCommit(ctx context.Context, args []string) error {
+	gitArgs := []string{"commit"}
+	if c.All {
+		gitArgs = append(gitArgs, "--all")
+	}
+	if c.Amend {
+		gitArgs = append(gitArgs, "--amend")
+	}
+	if c.Signoff {
+		gitArgs = append(gitArgs, "--signoff")
+	}
+	if c.NoVerify {
+		gitArgs = append(gitArgs, "--no-verify")
+	}
+	if c.Edit {
+		gitArgs = append(gitArgs, "--edit")
+	}
+	if c.Debug || c.DryRun {
+		gitArgs = append(gitArgs, "--dry-run")
+	}
+	if c.Message != "" {
+		gitArgs = append(gitArgs, "-m", c.Message)
+	}
+	if c.Body != "" {
+		gitArgs = append(gitArgs, "-m", c.Body)
+	}
+	gitArgs = append(gitArgs, args...)
+
+	cmd := exec.CommandContext(ctx, "git", gitArgs...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git commit: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+
+	git "github.com/go-git/go-git/v5"
+)
+
+const defaultEditor = "vi"
+
+// editMessage lets the user edit msg in the editor, with a commented summary of the staged files as git commit does.
+// It returns ErrUserAborted if the edited message is empty.
+func (c globalCmd) editMessage(ctx context.Context, msg string) (string, error) {
+	f, err := os.CreateTemp(longPath(os.TempDir()), "COMMIT_EDITMSG-*")
+	if err != nil {
+		return "", err
+	}
+	_, err = f.WriteString(msg + "\n" + c.editComment())
+	f.Close()
+	defer os.Remove(f.Name())
+	if err != nil {
+		return "", err
+	}
+
+	if err := runEditor(ctx, editorCommand(c.repository), f.Name()); err != nil {
+		return "", err
+	}
+
+	content, err := os.ReadFile(f.Name())
+	if err != nil {
+		return "", err
+	}
+	edited := strings.ReplaceAll(string(content), "\r\n", "\n")
+	edited = strings.TrimSpace(stripCommentLines(edited, commentChar(c.repository)))
+	if edited == "" {
+		return "", withMessage(ErrUserAborted, tr(msgEditEmpty))
+	}
+	return edited, nil
+}
+
+// editComment is the comment lines after the message, starting with core.commentChar.
+func (c globalCmd) editComment() string {
+	comment := commentChar(c.repository)
+	lines := []string{""}
+	lines = append(lines, strings.Split(tr(msgEditHelp), "\n")...)
+	lines = append(lines, "", tr(msgEditStaged))
+	for _, s := range stagedSummary(c.status, c.renames) {
+		lines = append(lines, "\t"+s)
+	}
+
+	var b strings.Builder
+	for _, l := range lines {
+		if !strings.HasPrefix(l, "\t") {
+			l = " " + l
+		}
+		b.WriteString(strings.TrimRight(comment+l, " ") + "\n")
+	}
+	return b.String()
+}
+
+// editorCommand returns the editor by (in order) core.editor, $GIT_EDITOR and $EDITOR, or vi.
+func editorCommand(repos *git.Repository) string {
+	if e := gitConfigOption(repos, "core", "editor"); e != "" {
+		return e
+	}
+
+	for _, env := range []string{"GIT_EDITOR", "EDITOR"} {
+		if e := os.Getenv(env); e != "" {
+			return e
+		}
+	}
+	return defaultEditor
+}
+
+// runEditor runs editor, which may have arguments, on filename.
+// Like git, the editor is run by sh if available, so that it can be like `code --wait`.
+func runEditor(ctx context.Context, editor, filename string) error {
+	var cmd *exec.Cmd
+	if _, err := exec.LookPath("sh"); err == nil {
+		cmd = exec.CommandContext(ctx, "sh", "-c", editor+` "$@"`, editor, filename)
+	} else {
+		args := strings.Fields(editor)
+		cmd = exec.CommandContext(ctx, args[0], append(args[1:], filename)...)
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
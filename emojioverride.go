@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/kyokomi/emoji/v2"
+)
+
+// EmojiOverride is the emoji of a type for the scopes matching a pattern, like 💄 for feat(ui).
+type EmojiOverride struct {
+	// Type is the type overridden
+	Type string `json:"type"`
+
+	// ScopePattern is a glob of the scope (path.Match), like ui or ui/*
+	ScopePattern string `json:"scopePattern"`
+
+	// Emoji replaces the emoji of the type, a shortcode or unicode ("" for no emoji)
+	Emoji string `json:"emoji"`
+}
+
+var emojiShortcodePattern = regexp.MustCompile(`:[A-Za-z0-9_+-]+:`)
+
+// checkEmojiOverrides returns an error if a pattern of rule.EmojiOverrides is not a valid glob
+// or an emoji has an unknown shortcode.
+func checkEmojiOverrides(rule *Rule, rulePath string) error {
+	for _, o := range rule.EmojiOverrides {
+		if _, err := path.Match(o.ScopePattern, ""); err != nil {
+			return &RuleInvalidError{Path: rulePath, Cause: fmt.Errorf("emojiOverrides: %s(%s): %w", o.Type, o.ScopePattern, err)}
+		}
+		for _, code := range emojiShortcodePattern.FindAllString(o.Emoji, -1) {
+			if emoji.Emojize(code) == code {
+				return &RuleInvalidError{Path: rulePath, Cause: fmt.Errorf("emojiOverrides: %s(%s): %s", o.Type, o.ScopePattern, tr(msgInvalidEmojiShortcode, code))}
+			}
+		}
+	}
+	return nil
+}
+
+// emojiOverride returns the emoji of the first entry of rule.EmojiOverrides matching typ and scope.
+func emojiOverride(rule *Rule, typ, scope string) (string, bool) {
+	for _, o := range rule.EmojiOverrides {
+		if o.Type != typ {
+			continue
+		}
+		if ok, _ := path.Match(o.ScopePattern, scope); ok {
+			return o.Emoji, true
+		}
+	}
+	return "", false
+}
+
+// emojiOf returns the emoji of typ in scope, overridden by Rule.EmojiOverrides, in unicode if emojize.
+func (c globalCmd) emojiOf(typ, scope string, emojize bool) string {
+	e, found := emojiOverride(c.rule, typ, scope)
+	if !found {
+		ct, ok := c.rule.Types.Get(typ)
+		if !ok {
+			return ""
+		}
+		e = ct.Emoji
+	}
+
+	if emojize {
+		e = strings.TrimSpace(emoji.Emojize(e))
+	}
+	return e
+}
+
+// emojiMarker stands for the emoji in the header with emojiRendering: none, removed after rendering with a space around it.
+const emojiMarker = "\x00"
+
+// emojiVariables returns the variables of the emoji of typ in scope for the header format:
+// .emoji, .emoji_unicode and .emoji_shortcode, and the emoji as GitHub shows it.
+// With emojiRendering: none, all of them are emojiMarker.
+func (c globalCmd) emojiVariables(typ, scope string) (emoji, unicode, shortcode, glyph string) {
+	shortcode = c.emojiOf(typ, scope, false)
+	unicode = c.emojiOf(typ, scope, true)
+	emoji = shortcode
+	glyph = unicode
+
+	switch c.rule.EmojiRendering {
+	case emojiRenderingUnicode:
+		emoji = unicode
+	case emojiRenderingNone:
+		emoji, unicode, shortcode, glyph = emojiMarker, emojiMarker, emojiMarker, emojiMarker
+	}
+	return emoji, unicode, shortcode, glyph
+}
+
+// stripEmojiMarker removes emojiMarker from header, and a space next to it not to leave two spaces,
+// or one at the start or the end of header.
+func stripEmojiMarker(header string) string {
+	for {
+		i := strings.Index(header, emojiMarker)
+		if i < 0 {
+			return header
+		}
+
+		before, after := header[:i], header[i+len(emojiMarker):]
+		if (before == "" || strings.HasSuffix(before, " ")) && strings.HasPrefix(after, " ") {
+			after = after[1:]
+		} else if after == "" && strings.HasSuffix(before, " ") {
+			before = before[:len(before)-1]
+		}
+		header = before + after
+	}
+}
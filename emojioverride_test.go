@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/shu-go/orderedmap"
+)
+
+func emojiTestRule() *Rule {
+	types := orderedmap.New[string, CommitType]()
+	types.Set("feat", CommitType{Desc: "A new feature", Emoji: ":sparkles:"})
+	types.Set("fix", CommitType{Desc: "A bug fix", Emoji: ":bug:"})
+	types.Set("docs", CommitType{Desc: "Documentation only changes"})
+	return &Rule{
+		Types:          types,
+		EmojiRendering: emojiRenderingShortcode,
+		EmojiOverrides: []EmojiOverride{
+			{Type: "feat", ScopePattern: "ui", Emoji: ":lipstick:"},
+			{Type: "feat", ScopePattern: "ui*", Emoji: ":art:"},
+			{Type: "feat", ScopePattern: "api/*", Emoji: "🔌"},
+			{Type: "fix", ScopePattern: "legacy", Emoji: ""},
+			{Type: "docs", ScopePattern: "*", Emoji: ":memo:"},
+		},
+	}
+}
+
+func TestEmojiOf(t *testing.T) {
+	tests := []struct {
+		typ, scope string
+		want       string
+	}{
+		{typ: "feat", scope: "", want: ":sparkles:"},
+		{typ: "feat", scope: "api", want: ":sparkles:"},
+		{typ: "feat", scope: "ui", want: ":lipstick:"},
+		{typ: "feat", scope: "ui-kit", want: ":art:"},
+		{typ: "feat", scope: "api/v2", want: "🔌"},
+		{typ: "fix", scope: "ui", want: ":bug:"},
+		{typ: "fix", scope: "legacy", want: ""},
+		{typ: "docs", scope: "", want: ":memo:"},
+		{typ: "docs", scope: "api", want: ":memo:"},
+		{typ: "chore", scope: "ui", want: ""},
+	}
+	c := globalCmd{rule: emojiTestRule()}
+	for _, tt := range tests {
+		t.Run(tt.typ+"("+tt.scope+")", func(t *testing.T) {
+			if got := c.emojiOf(tt.typ, tt.scope, false); got != tt.want {
+				t.Errorf("emojiOf(%q, %q) = %q, want %q", tt.typ, tt.scope, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckEmojiOverrides(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides []EmojiOverride
+		wantErr   string
+	}{
+		{name: "valid", overrides: []EmojiOverride{{Type: "feat", ScopePattern: "ui/*", Emoji: ":lipstick:"}}},
+		{name: "unicode", overrides: []EmojiOverride{{Type: "feat", ScopePattern: "ui", Emoji: "💄"}}},
+		{name: "bad glob", overrides: []EmojiOverride{{Type: "feat", ScopePattern: "ui[", Emoji: ":lipstick:"}}, wantErr: "feat(ui[)"},
+		{name: "unknown shortcode", overrides: []EmojiOverride{{Type: "feat", ScopePattern: "ui", Emoji: ":lipstik:"}}, wantErr: tr(msgInvalidEmojiShortcode, ":lipstik:")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkEmojiOverrides(&Rule{EmojiOverrides: tt.overrides}, ".cx.yaml")
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkEmojiOverrides() = %v", err)
+				}
+				return
+			}
+			var rerr *RuleInvalidError
+			if !errors.As(err, &rerr) || rerr.Path != ".cx.yaml" || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("checkEmojiOverrides() = %v, want an error of .cx.yaml with %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRenderHeaderEmoji(t *testing.T) {
+	tests := []struct {
+		name      string
+		rendering string
+		format    string
+		scope     string
+		want      string
+	}{
+		{name: "shortcode", scope: "ui", want: "feat(ui): :lipstick:add"},
+		{name: "rendering unicode", rendering: emojiRenderingUnicode, scope: "ui", want: "feat(ui): 💄add"},
+		{name: "explicit shortcode", rendering: emojiRenderingUnicode, format: "{{.emoji_shortcode}} {{.type}}: {{.description}}", want: ":sparkles: feat: add"},
+		{name: "explicit unicode", rendering: emojiRenderingShortcode, format: "{{.emoji_unicode}} {{.type}}: {{.description}}", want: "✨ feat: add"},
+		{name: "none", rendering: emojiRenderingNone, format: "{{.emoji}} {{.type}}: {{.description}}", want: "feat: add"},
+		{name: "none in the middle", rendering: emojiRenderingNone, format: "{{.type}}: {{.emoji}} {{.description}}", want: "feat: add"},
+		{name: "none of explicit variables", rendering: emojiRenderingNone, format: "{{.emoji_unicode}}{{.emoji_shortcode}} {{.type}}: {{.description}}", want: "feat: add"},
+		{name: "none with the default format", rendering: emojiRenderingNone, scope: "ui", want: "feat(ui): add"},
+		{name: "no emoji", scope: "docs", format: "{{.type}}{{.scope_with_parens}}: {{.emoji}}{{.description}}", want: "feat(docs): :sparkles:add"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := emojiTestRule()
+			rule.HeaderFormat = defaultHeaderFormat
+			if tt.format != "" {
+				rule.HeaderFormat = tt.format
+			}
+			if tt.rendering != "" {
+				rule.EmojiRendering = tt.rendering
+			}
+
+			c := globalCmd{rule: rule}
+			if got := c.renderHeader("feat", tt.scope, "add", false, nil); got != tt.want {
+				t.Errorf("header = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
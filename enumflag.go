@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// Flags taking one of fixed values are types implementing gli.OptionParser,
+// so that a typo fails while parsing the command line instead of falling through to a default.
+
+// unknownValueError is the error for value not in valid.
+func unknownValueError(kind, value string, valid []string) error {
+	return errors.New(tr(msgUnknownValue, kind, value, strings.Join(valid, ", ")))
+}
+
+// langFlag is --lang: a supported language, or a locale of it like ja_JP.UTF-8.
+type langFlag string
+
+func (l *langFlag) Parse(s string) error {
+	if normalizeLang(s) == "" {
+		return unknownValueError("language", s, supportedLangs())
+	}
+	*l = langFlag(s)
+	return nil
+}
+
+// supportedLangs returns the languages of the catalog.
+func supportedLangs() []string {
+	langs := make([]string, 0, len(catalog))
+	for l := range catalog {
+		langs = append(langs, l)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// groupByFlag is --group-by of changelog: type or scope.
+type groupByFlag string
+
+const (
+	groupByType  = "type"
+	groupByScope = "scope"
+)
+
+func (f *groupByFlag) Parse(s string) error {
+	if !in(s, groupByType, groupByScope) {
+		return unknownValueError("grouping", s, []string{groupByType, groupByScope})
+	}
+	*f = groupByFlag(s)
+	return nil
+}
+
+// sectionOrderFlag is --order of changelog: alpha or count.
+type sectionOrderFlag string
+
+const (
+	sectionOrderAlpha = "alpha"
+	sectionOrderCount = "count"
+)
+
+func (f *sectionOrderFlag) Parse(s string) error {
+	if !in(s, sectionOrderAlpha, sectionOrderCount) {
+		return unknownValueError("order", s, []string{sectionOrderAlpha, sectionOrderCount})
+	}
+	*f = sectionOrderFlag(s)
+	return nil
+}
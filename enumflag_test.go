@@ -0,0 +1,71 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEnumFlagParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		flag    interface{ Parse(string) error }
+		value   string
+		wantErr string
+	}{
+		{name: "lang", flag: new(langFlag), value: "ja"},
+		{name: "lang locale", flag: new(langFlag), value: "ja_JP.UTF-8"},
+		{name: "lang typo", flag: new(langFlag), value: "jp", wantErr: tr(msgUnknownValue, "language", "jp", "en, ja")},
+		{name: "group-by", flag: new(groupByFlag), value: "scope"},
+		{name: "group-by typo", flag: new(groupByFlag), value: "scopes", wantErr: tr(msgUnknownValue, "grouping", "scopes", "type, scope")},
+		{name: "order", flag: new(sectionOrderFlag), value: "count"},
+		{name: "order typo", flag: new(sectionOrderFlag), value: "alphabet", wantErr: tr(msgUnknownValue, "order", "alphabet", "alpha, count")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.flag.Parse(tt.value)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Parse(%q) = %v", tt.value, err)
+				} else if got := reflect.ValueOf(tt.flag).Elem().String(); got != tt.value {
+					t.Errorf("Parse(%q) set %q", tt.value, got)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Parse(%q) = %v, want %q", tt.value, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// The help of an enum flag lists its values.
+func TestEnumFlagHelp(t *testing.T) {
+	valid := map[reflect.Type][]string{
+		reflect.TypeOf(langFlag("")):         supportedLangs(),
+		reflect.TypeOf(groupByFlag("")):      {groupByType, groupByScope},
+		reflect.TypeOf(sectionOrderFlag("")): {sectionOrderAlpha, sectionOrderCount},
+	}
+
+	flags := 0
+	for _, cmd := range []any{globalCmd{}, changelogCmd{}} {
+		ct := reflect.TypeOf(cmd)
+		for i := 0; i < ct.NumField(); i++ {
+			f := ct.Field(i)
+			values, found := valid[f.Type]
+			if !found {
+				continue
+			}
+			flags++
+			help := f.Tag.Get("help")
+			for _, v := range values {
+				if !strings.Contains(help, v) {
+					t.Errorf("%s.%s: help %q does not list %s", ct.Name(), f.Name, help, v)
+				}
+			}
+		}
+	}
+	if flags != len(valid) {
+		t.Errorf("%d enum flags are found, want %d", flags, len(valid))
+	}
+}
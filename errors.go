@@ -0,0 +1,115 @@
+package main
+
+import (
+	"errors"
+	"strings"
+)
+
+// Errors are classified by the values and types here with errors.Is and errors.As,
+// so that main can decide the exit code whatever context is wrapped around them.
+
+var (
+	// ErrNoRepository means git-cx runs outside a git repository.
+	ErrNoRepository = errors.New("not a git repository")
+
+	// ErrNothingStaged means there is nothing to commit.
+	ErrNothingStaged = errors.New("nothing staged")
+
+	// ErrUserAborted means the user declined a confirmation.
+	ErrUserAborted = errors.New("aborted")
+
+	// ErrInvalidMessage means the message violates the rule.
+	ErrInvalidMessage = errors.New("invalid message")
+
+	// ErrInterrupted means the user canceled a prompt by Ctrl+C or Ctrl+D, or interrupted git-cx by Ctrl+C.
+	ErrInterrupted = errors.New("interrupted")
+)
+
+// exit codes
+const (
+	exitError       = 1   // the command failed (including lint and check findings)
+	exitEnvironment = 2   // the command cannot run here, such as outside a git repository or with a broken rule file
+	exitInvalid     = 3   // --dry-run: the message violates the rule
+	exitInterrupted = 130 // a prompt is canceled, as the shell tells for Ctrl+C
+)
+
+// kindError is an error of kind with a user-facing message.
+type kindError struct {
+	kind error
+	msg  string
+}
+
+func (e *kindError) Error() string {
+	return e.msg
+}
+
+func (e *kindError) Unwrap() error {
+	return e.kind
+}
+
+// withMessage returns an error that is kind and reads msg.
+func withMessage(kind error, msg string) error {
+	return &kindError{kind: kind, msg: msg}
+}
+
+// RuleInvalidError is a rule file that can not be used.
+type RuleInvalidError struct {
+	Path  string
+	Cause error
+}
+
+func (e *RuleInvalidError) Error() string {
+	return tr(msgRuleInvalid, e.Path, e.Cause)
+}
+
+func (e *RuleInvalidError) Unwrap() error {
+	return e.Cause
+}
+
+// FormatError is a rule file that none of the formats tried can parse.
+// The first attempt is of the format detected by the extension or the content.
+type FormatError struct {
+	Attempts []FormatAttempt
+}
+
+// FormatAttempt is a format tried and its error, with the line and the column if known.
+type FormatAttempt struct {
+	Format string
+	Err    error
+}
+
+func (e *FormatError) Error() string {
+	parts := make([]string, 0, len(e.Attempts))
+	for _, a := range e.Attempts {
+		parts = append(parts, tr(msgFormatAttempt, a.Format, a.Err))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// HookRejectedError is git commit failed while hooks are installed.
+type HookRejectedError struct {
+	Hook   string
+	Output string
+}
+
+func (e *HookRejectedError) Error() string {
+	msg := tr(msgHookRejected, e.Hook)
+	if out := strings.TrimSpace(e.Output); out != "" {
+		msg += "\n" + out
+	}
+	return msg
+}
+
+// exitCode returns the exit code for err.
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, ErrNoRepository), errors.As(err, new(*RuleInvalidError)):
+		return exitEnvironment
+	case errors.Is(err, ErrInvalidMessage):
+		return exitInvalid
+	case errors.Is(err, ErrInterrupted):
+		return exitInterrupted
+	default:
+		return exitError
+	}
+}
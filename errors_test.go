@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		want     int
+		wantText string
+	}{
+		{
+			name:     "no repository",
+			err:      withMessage(ErrNoRepository, tr(msgNotRepository)),
+			want:     exitEnvironment,
+			wantText: tr(msgNotRepository),
+		},
+		{
+			name:     "rule invalid",
+			err:      &RuleInvalidError{Path: "/r/.cx.yaml", Cause: errors.New("types: broken")},
+			want:     exitEnvironment,
+			wantText: tr(msgRuleInvalid, "/r/.cx.yaml", errors.New("types: broken")),
+		},
+		{
+			name:     "rule invalid, wrapped",
+			err:      fmt.Errorf("prepare: %w", &RuleInvalidError{Path: "/r/.cx.yaml", Cause: errors.New("types: broken")}),
+			want:     exitEnvironment,
+			wantText: "prepare: " + tr(msgRuleInvalid, "/r/.cx.yaml", errors.New("types: broken")),
+		},
+		{
+			name:     "invalid message",
+			err:      withMessage(ErrInvalidMessage, "header too long"),
+			want:     exitInvalid,
+			wantText: "header too long",
+		},
+		{
+			name:     "interrupted",
+			err:      errInterrupted(),
+			want:     exitInterrupted,
+			wantText: tr(msgInterrupted),
+		},
+		{
+			name:     "nothing staged",
+			err:      withMessage(ErrNothingStaged, tr(msgNoChanges)),
+			want:     exitError,
+			wantText: tr(msgNoChanges),
+		},
+		{
+			name:     "aborted",
+			err:      withMessage(ErrUserAborted, tr(msgAborted)),
+			want:     exitError,
+			wantText: tr(msgAborted),
+		},
+		{
+			name:     "hook rejected",
+			err:      &HookRejectedError{Hook: "commit-msg", Output: "  subject too long\n"},
+			want:     exitError,
+			wantText: tr(msgHookRejected, "commit-msg") + "\nsubject too long",
+		},
+		{
+			name:     "format error",
+			err:      &FormatError{Attempts: []FormatAttempt{{Format: formatJSON, Err: errors.New("bad")}, {Format: formatYAML, Err: errors.New("worse")}}},
+			want:     exitError,
+			wantText: tr(msgFormatAttempt, formatJSON, errors.New("bad")) + "; " + tr(msgFormatAttempt, formatYAML, errors.New("worse")),
+		},
+		{
+			name:     "other",
+			err:      errors.New("disk full"),
+			want:     exitError,
+			wantText: "disk full",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitCode(tt.err); got != tt.want {
+				t.Errorf("exitCode() = %d, want %d", got, tt.want)
+			}
+			if got := tt.err.Error(); got != tt.wantText {
+				t.Errorf("Error() = %q, want %q", got, tt.wantText)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/shu-go/orderedmap"
+)
+
+// A rule file can be based on another one by extends: a path relative to the file, ~/ or absolute, or an https URL.
+// The options in the file overwrite those of the base, and the others are inherited.
+// The types are of both, those in the file overriding the base's of the same key or of their aliases.
+// Without types in either, the rule has the default types (see normalizeRule).
+
+// extendRule returns r, read from ref with content, on top of the rule file r extends if any.
+// chain is the rule files read so far, to detect a cycle.
+func extendRule(r *Rule, ref string, content []byte, limit int64, chain []string) (*Rule, error) {
+	if r.Extends == "" {
+		r.setTypeSources(ref)
+		return r, nil
+	}
+
+	baseRef, err := resolveExtends(ref, r.Extends)
+	if err != nil {
+		return nil, fmt.Errorf("extends %s: %w", r.Extends, err)
+	}
+	chain = append(chain, baseRef)
+	for _, c := range chain[:len(chain)-1] {
+		if c == baseRef {
+			return nil, errors.New(tr(msgExtendsCycle, strings.Join(chain, " -> ")))
+		}
+	}
+
+	base, err := readRuleRef(baseRef, limit, chain)
+	if err != nil {
+		return nil, fmt.Errorf("extends %s: %w", r.Extends, err)
+	}
+
+	extended, err := decodeRule(ruleRefName(ref), content, *base)
+	if err != nil {
+		return nil, err
+	}
+	types := base.Types
+	if types == nil && extended.Types != nil {
+		types = orderedmap.New[string, CommitType]()
+	}
+	sources := base.typeSources
+	if sources == nil {
+		sources = make(map[string]string)
+	}
+	for _, k := range extended.Types.Keys() {
+		ct, _ := extended.Types.Get(k)
+		types.Set(k, ct)
+		sources[k] = ref
+		// the base's types merged into this one
+		for _, a := range ct.Aliases {
+			if a != k && !extended.Types.Contains(a) {
+				types.Delete(a)
+				delete(sources, a)
+			}
+		}
+	}
+	extended.Types = types
+	extended.typeSources = sources
+	return extended, nil
+}
+
+// readRuleRef reads the rule file at ref, a path or a URL, with the rule files it extends.
+func readRuleRef(ref string, limit int64, chain []string) (*Rule, error) {
+	var content []byte
+	var err error
+	if isRuleURL(ref) {
+		content, err = readRemoteRuleContent(ref, limit)
+	} else {
+		content, err = readConfigFile(ref, limit)
+		if err == nil && content == nil {
+			err = fmt.Errorf("%s: %w", ref, os.ErrNotExist)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := parseRule(ruleRefName(ref), content)
+	if err != nil {
+		return nil, &RuleInvalidError{Path: ref, Cause: err}
+	}
+	return extendRule(r, ref, content, limit, chain)
+}
+
+// resolveExtends returns the rule file extends refers to from the rule file ref.
+func resolveExtends(ref, extends string) (string, error) {
+	if isRuleURL(extends) {
+		return extends, nil
+	}
+
+	if isRuleURL(ref) && !strings.HasPrefix(extends, "~/") && !filepath.IsAbs(extends) {
+		base, err := url.Parse(ref)
+		if err != nil {
+			return "", err
+		}
+		rel, err := url.Parse(filepath.ToSlash(extends))
+		if err != nil {
+			return "", err
+		}
+		return base.ResolveReference(rel).String(), nil
+	}
+	if isRuleURL(ref) {
+		// a remote rule file can not refer to a local one
+		return "", fmt.Errorf("%s: %w", extends, os.ErrPermission)
+	}
+
+	path := expandHome(extends)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(ref), path)
+	}
+	return absRuleRef(path), nil
+}
+
+// absRuleRef returns the absolute path of a rule file, or the URL as it is.
+func absRuleRef(ref string) string {
+	if isRuleURL(ref) {
+		return ref
+	}
+	if abs, err := filepath.Abs(ref); err == nil {
+		return abs
+	}
+	return ref
+}
+
+// ruleRefName returns the name of ref to tell the format by the extension: the path of a URL.
+func ruleRefName(ref string) string {
+	if !isRuleURL(ref) {
+		return ref
+	}
+	if u, err := url.Parse(ref); err == nil {
+		return u.Path
+	}
+	return ref
+}
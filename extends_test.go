@@ -0,0 +1,162 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtendRule(t *testing.T) {
+	const base = `{"headerFormat": "[{{.type}}] {{.description}}", "denyAdlibType": true, "maxHeaderLength": 50,` +
+		` "types": {"feat": {"desc": "A new feature"}, "fix": {"desc": "A bug fix"}}}`
+
+	tests := []struct {
+		name  string
+		child string
+		check func(t *testing.T, r *Rule)
+	}{
+		{
+			name:  "inherits everything",
+			child: "extends: ../base.json\n",
+			check: func(t *testing.T, r *Rule) {
+				if r.HeaderFormat != "[{{.type}}] {{.description}}" || !r.DenyAdlibType || r.MaxHeaderLength != 50 {
+					t.Errorf("rule = %+v, want the base's options", r)
+				}
+			},
+		},
+		{
+			name:  "overwrites options",
+			child: "extends: ../base.json\nmaxheaderlength: 72\ndenyadlibtype: false\n",
+			check: func(t *testing.T, r *Rule) {
+				if r.MaxHeaderLength != 72 || r.DenyAdlibType {
+					t.Errorf("rule = %+v, want the options of the file", r)
+				}
+				if r.HeaderFormat != "[{{.type}}] {{.description}}" {
+					t.Errorf("headerFormat = %q, want the base's", r.HeaderFormat)
+				}
+			},
+		},
+		{
+			name:  "merges types",
+			child: "extends: ../base.json\ntypes:\n  fix:\n    desc: Fixes\n  ui:\n    desc: UI changes\n",
+			check: func(t *testing.T, r *Rule) {
+				if got := r.Types.Keys(); !reflect.DeepEqual(got, []string{"feat", "fix", "ui"}) {
+					t.Errorf("types = %v", got)
+				}
+				if ct, _ := r.Types.Get("fix"); ct.Desc != "Fixes" {
+					t.Errorf("fix = %+v, want that of the file", ct)
+				}
+			},
+		},
+		{
+			name:  "an alias replaces the base's type",
+			child: "extends: ../base.json\ntypes:\n  feature:\n    desc: Features\n    aliases: [feat]\n",
+			check: func(t *testing.T, r *Rule) {
+				if got := r.Types.Keys(); !reflect.DeepEqual(got, []string{"fix", "feature"}) {
+					t.Errorf("types = %v", got)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.Mkdir(filepath.Join(dir, "pkg"), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(dir, "base.json"), []byte(base), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			filename := filepath.Join(dir, "pkg", ".cx.yaml")
+			if err := os.WriteFile(filename, []byte(tt.child), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			r, err := tryReadRuleFile(filename, defaultConfigFileLimit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, r)
+		})
+	}
+}
+
+func TestExtendRuleErrors(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.yaml":       "extends: b.yaml\n",
+		"b.yaml":       "extends: ./a.yaml\n",
+		"self.yaml":    "extends: self.yaml\n",
+		"missing.yaml": "extends: none.yaml\n",
+		"broken.yaml":  "extends: broken.json\n",
+		"broken.json":  "{\"types\": ",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		file    string
+		wantErr func(err error) bool
+	}{
+		{file: "a.yaml", wantErr: func(err error) bool { return strings.Contains(err.Error(), "a.yaml -> ") }},
+		{file: "self.yaml", wantErr: func(err error) bool { return strings.Contains(err.Error(), "self.yaml -> ") }},
+		{file: "missing.yaml", wantErr: func(err error) bool { return errors.Is(err, os.ErrNotExist) }},
+		{
+			file: "broken.yaml",
+			wantErr: func(err error) bool {
+				var rerr *RuleInvalidError
+				return errors.As(err, &rerr) && filepath.Base(rerr.Path) == "broken.json"
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			_, err := tryReadRuleFile(filepath.Join(dir, tt.file), defaultConfigFileLimit)
+			if err == nil || !tt.wantErr(err) {
+				t.Errorf("error = %v", err)
+			}
+		})
+	}
+}
+
+func TestResolveExtends(t *testing.T) {
+	dir := t.TempDir()
+	ref := filepath.Join(dir, "pkg", ".cx.yaml")
+
+	tests := []struct {
+		name    string
+		ref     string
+		extends string
+		want    string
+		wantErr error
+	}{
+		{name: "relative", ref: ref, extends: "../base.yaml", want: filepath.Join(dir, "base.yaml")},
+		{name: "absolute", ref: ref, extends: filepath.Join(dir, "base.yaml"), want: filepath.Join(dir, "base.yaml")},
+		{name: "URL", ref: ref, extends: "https://example.com/cx.yaml", want: "https://example.com/cx.yaml"},
+		{name: "relative to a URL", ref: "https://example.com/rules/cx.yaml", extends: "../base.yaml", want: "https://example.com/base.yaml"},
+		{name: "local from a URL", ref: "https://example.com/cx.yaml", extends: "~/base.yaml", wantErr: os.ErrPermission},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveExtends(tt.ref, tt.extends)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("resolveExtends(%q, %q) = %q, want %q", tt.ref, tt.extends, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	prompt "github.com/elk-language/go-prompt"
+)
+
+// FooterDef is a footer asked after the body, like Refs for issue references.
+type FooterDef struct {
+	// Key is the token of the footer, like Refs or Reviewed-by
+	Key string `json:"key"`
+
+	// Prompt is the prefix of the prompt (default: Key)
+	Prompt string `json:"prompt,omitempty" yaml:",omitempty"`
+
+	// Required asks again on an empty answer, instead of leaving the footer out
+	Required bool `json:"required,omitempty" yaml:",omitempty"`
+}
+
+// promptFooters asks the footers of the rule in order, and returns them as `Key: value` lines.
+// Without prompts, a required footer is an error.
+func (c globalCmd) promptFooters() ([]string, error) {
+	var footers []string
+
+	for _, def := range c.rule.Footers {
+		if def.Key == "" {
+			continue
+		}
+
+		if c.promptless() {
+			if def.Required {
+				return nil, withMessage(ErrInvalidMessage, tr(msgFooterRequired, def.Key))
+			}
+			continue
+		}
+
+		prefix := def.Prompt
+		if prefix == "" {
+			prefix = def.Key
+		}
+
+		var initial string
+		for _, f := range c.prefill.Footers {
+			if strings.EqualFold(f.Token, def.Key) {
+				initial = f.Value
+				break
+			}
+		}
+
+		for {
+			value, err := promptInput(prompt.WithPrefix(prefix+": "), prompt.WithInitialText(initial))
+			if err != nil {
+				return nil, err
+			}
+			value = strings.TrimSpace(value)
+			if value != "" {
+				footers = append(footers, def.Key+": "+value)
+				break
+			}
+			if !def.Required {
+				break
+			}
+			fmt.Fprintln(os.Stderr, tr(msgFooterRequired, def.Key))
+		}
+	}
+
+	return footers, nil
+}
+
+// isRuleFooter reports whether token is a footer defined by rule.
+func isRuleFooter(rule *Rule, token string) bool {
+	for _, def := range rule.Footers {
+		if strings.EqualFold(def.Key, token) {
+			return true
+		}
+	}
+	return false
+}
+
+// missingFooters returns the keys of the required footers of rule not in cc.
+func missingFooters(rule *Rule, cc ConventionalCommit) []string {
+	var missing []string
+	for _, def := range rule.Footers {
+		if !def.Required || def.Key == "" {
+			continue
+		}
+
+		found := false
+		for _, f := range cc.Footers {
+			found = found || strings.EqualFold(f.Token, def.Key)
+		}
+		if !found {
+			missing = append(missing, def.Key)
+		}
+	}
+	return missing
+}
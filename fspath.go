@@ -0,0 +1,114 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// windowsReservedNames are device names that can not be file names on Windows, with or without extensions.
+var windowsReservedNames = []string{
+	"CON", "PRN", "AUX", "NUL",
+	"COM1", "COM2", "COM3", "COM4", "COM5", "COM6", "COM7", "COM8", "COM9",
+	"LPT1", "LPT2", "LPT3", "LPT4", "LPT5", "LPT6", "LPT7", "LPT8", "LPT9",
+}
+
+// safeFileName makes a user-derived name (branch, scope, ...) usable as a file name on every platform.
+// Files are the same across platforms, so that a repository can be shared.
+func safeFileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || strings.ContainsRune(`<>:"/\|?*`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+
+	// Windows drops them
+	name = strings.TrimRight(name, ". ")
+	if name == "" {
+		return "_"
+	}
+
+	base, _, _ := strings.Cut(name, ".")
+	base = strings.TrimRight(base, " ")
+	for _, r := range windowsReservedNames {
+		if strings.EqualFold(base, r) {
+			return "_" + name
+		}
+	}
+	return name
+}
+
+// realPath resolves symlinks in p, as git does, so that relative paths in gitconfig (with ..) point to the same file
+// whether the worktree is reached through a symlink or not.
+// p is returned as is if it can not be resolved.
+func realPath(p string) string {
+	if p == "" {
+		return p
+	}
+	if r, err := filepath.EvalSymlinks(p); err == nil {
+		return r
+	}
+	return p
+}
+
+// actualCase returns p with its base name as stored in the directory.
+// On case-insensitive filesystems, .cx.yaml is found even if the file is .CX.yaml.
+func actualCase(p string) string {
+	dir, base := filepath.Split(p)
+	entries, err := os.ReadDir(longPath(filepath.Clean(dir)))
+	if err != nil {
+		return p
+	}
+
+	for _, e := range entries {
+		if e.Name() == base {
+			return p
+		}
+	}
+	for _, e := range entries {
+		if strings.EqualFold(e.Name(), base) {
+			return filepath.Join(dir, e.Name())
+		}
+	}
+	return p
+}
+
+// fileExt is filepath.Ext in lower case, since file names may be in any case on case-insensitive filesystems.
+func fileExt(p string) string {
+	return strings.ToLower(filepath.Ext(p))
+}
+
+// writeFileAtomic writes content to a temporary file next to filename and renames it,
+// so that filename is never left half-written.
+func writeFileAtomic(filename string, content []byte) error {
+	dir, base := filepath.Split(filename)
+	if dir == "" {
+		dir = "."
+	}
+
+	f, err := os.CreateTemp(longPath(dir), "."+base+".*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+
+	_, err = f.Write(content)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		mode := os.FileMode(0o644)
+		if s, serr := os.Stat(longPath(filename)); serr == nil {
+			mode = s.Mode().Perm()
+		}
+		err = os.Chmod(tmp, mode)
+	}
+	if err == nil {
+		err = os.Rename(tmp, longPath(filename))
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
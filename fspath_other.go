@@ -0,0 +1,8 @@
+//go:build !windows
+
+package main
+
+// longPath returns p as is; paths are limited only on Windows.
+func longPath(p string) string {
+	return p
+}
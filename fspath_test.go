@@ -0,0 +1,92 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSafeFileName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "api", want: "api"},
+		{name: "feature/api", want: "feature_api"},
+		{name: `a<b>c:d"e\f|g?h*i`, want: "a_b_c_d_e_f_g_h_i"},
+		{name: "tab\there", want: "tab_here"},
+		{name: "con", want: "_con"},
+		{name: "CON", want: "_CON"},
+		{name: "aux.yaml", want: "_aux.yaml"},
+		{name: "nul .txt", want: "_nul .txt"},
+		{name: "com1", want: "_com1"},
+		{name: "console", want: "console"},
+		{name: "lpt10", want: "lpt10"},
+		{name: "trailing. ", want: "trailing"},
+		{name: "...", want: "_"},
+		{name: "", want: "_"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := safeFileName(tt.name); got != tt.want {
+				t.Errorf("safeFileName(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRealPath(t *testing.T) {
+	dir := realPath(t.TempDir())
+	target := filepath.Join(dir, "work")
+	if err := os.Mkdir(target, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink(target, link); err != nil {
+		t.Skip(err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{name: "symlink", path: link, want: target},
+		{name: "below a symlink", path: filepath.Join(link, ".cx.yaml"), want: filepath.Join(link, ".cx.yaml")},
+		{name: "dot-dot through a symlink", path: filepath.Join(link, ".."), want: dir},
+		{name: "empty", path: "", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := realPath(tt.path); got != tt.want {
+				t.Errorf("realPath(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestActualCase(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{".CX.yaml", "scopes.yaml"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: filepath.Join(dir, ".cx.yaml"), want: filepath.Join(dir, ".CX.yaml")},
+		{path: filepath.Join(dir, "scopes.yaml"), want: filepath.Join(dir, "scopes.yaml")},
+		{path: filepath.Join(dir, "missing.yaml"), want: filepath.Join(dir, "missing.yaml")},
+		{path: filepath.Join(dir, "no", "dir.yaml"), want: filepath.Join(dir, "no", "dir.yaml")},
+	}
+	for _, tt := range tests {
+		t.Run(filepath.Base(tt.path), func(t *testing.T) {
+			if got := actualCase(tt.path); got != tt.want {
+				t.Errorf("actualCase(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// maxPathLen is the limit of CreateDirectory, which is a little shorter than MAX_PATH (260).
+const maxPathLen = 248
+
+// longPath prefixes a long path with \\?\ so that it is not limited by MAX_PATH.
+func longPath(p string) string {
+	if len(p) < maxPathLen || strings.HasPrefix(p, `\\?\`) {
+		return p
+	}
+
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return p
+	}
+	// \\?\ paths are not normalized by Windows
+	abs = filepath.Clean(abs)
+
+	if strings.HasPrefix(abs, `\\`) {
+		return `\\?\UNC\` + abs[2:]
+	}
+	return `\\?\` + abs
+}
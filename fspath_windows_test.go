@@ -0,0 +1,39 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLongPath(t *testing.T) {
+	long := `C:\` + strings.Repeat(`a\`, maxPathLen/2) + "COMMIT_EDITMSG"
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{name: "short", path: `C:\Users\a\.cx.yaml`, want: `C:\Users\a\.cx.yaml`},
+		{name: "long", path: long, want: `\\?\` + long},
+		{name: "long, not clean", path: long[:3] + `b\..\` + long[3:], want: `\\?\` + long},
+		{name: "long UNC", path: `\\server\share\` + long[3:], want: `\\?\UNC\server\share\` + long[3:]},
+		{name: "prefixed", path: `\\?\` + long, want: `\\?\` + long},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := longPath(tt.path); got != tt.want {
+				t.Errorf("longPath(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+
+	// relative paths are made absolute
+	rel := strings.Repeat(`a\`, maxPathLen/2) + "x"
+	abs, err := filepath.Abs(rel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := longPath(rel); got != `\\?\`+abs && got != `\\?\UNC\`+strings.TrimPrefix(abs, `\\`) {
+		t.Errorf("longPath(%q) = %q, want %q", rel, got, `\\?\`+abs)
+	}
+}
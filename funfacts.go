@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// With funFacts: true, a line like `🎉 3rd commit today, 17 this week on this repo` is shown after committing.
+// The commits are counted by git-cx as they are made, not from the history,
+// per worktree in the user config dir.
+
+const commitCountsFileName = "commit-counts.json"
+
+// commitCount is the number of commits made by git-cx today and this week in a worktree.
+type commitCount struct {
+	// Day is the date of Today, like 2026-10-16
+	Day   string `json:"day"`
+	Today int    `json:"today"`
+
+	// Week is the ISO week of ThisWeek, like 2026-W42
+	Week     string `json:"week"`
+	ThisWeek int    `json:"thisWeek"`
+}
+
+// countUp adds a commit made at now, starting over on another day or week.
+func (c commitCount) countUp(now time.Time) commitCount {
+	day := now.Format("2006-01-02")
+	year, w := now.ISOWeek()
+	week := fmt.Sprintf("%d-W%02d", year, w)
+
+	if c.Day != day {
+		c.Day, c.Today = day, 0
+	}
+	if c.Week != week {
+		c.Week, c.ThisWeek = week, 0
+	}
+	c.Today++
+	c.ThisWeek++
+	return c
+}
+
+// notifyFunFacts shows the fun facts of a new commit, with funFacts: true.
+func (c *globalCmd) notifyFunFacts(f *commitFlow) error {
+	if c.rule.FunFacts && !c.readOnly() && !c.Amend {
+		c.showFunFacts()
+	}
+	return nil
+}
+
+// showFunFacts counts the commit just made and prints the counts to stderr.
+// Errors are ignored since it is only for fun.
+func (c globalCmd) showFunFacts() {
+	wt, err := c.repository.Worktree()
+	if err != nil {
+		return
+	}
+	root := wt.Filesystem.Root()
+
+	filename := commitCountsPath()
+	if filename == "" {
+		return
+	}
+	counts := readCommitCounts(filename)
+	count := counts[root].countUp(time.Now())
+	counts[root] = count
+
+	content, err := json.MarshalIndent(counts, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(longPath(filepath.Dir(filename)), 0o755); err != nil {
+		return
+	}
+	if err := writeFileAtomic(filename, content); err != nil {
+		return
+	}
+
+	fmt.Fprintln(os.Stderr, tr(msgFunFacts, ordinal(count.Today), count.ThisWeek))
+}
+
+func commitCountsPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, userConfigFolder, commitCountsFileName)
+}
+
+func readCommitCounts(filename string) map[string]commitCount {
+	counts := make(map[string]commitCount)
+	content, err := os.ReadFile(longPath(filename))
+	if err != nil {
+		return counts
+	}
+	if err := json.Unmarshal(content, &counts); err != nil {
+		return make(map[string]commitCount)
+	}
+	return counts
+}
+
+// ordinal formats n like 1st, 2nd, 3rd and 11th, or 3 件目 in Japanese.
+func ordinal(n int) string {
+	if currentLang == langJapanese {
+		return fmt.Sprintf("%d 件目", n)
+	}
+
+	suffix := "th"
+	switch n % 100 {
+	case 11, 12, 13:
+		//nop
+	default:
+		switch n % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return fmt.Sprintf("%d%s", n, suffix)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/shu-go/git-cx/internal/testutil"
+)
+
+func TestCommitCountUp(t *testing.T) {
+	// 2026-10-12 is a Monday
+	mon := time.Date(2026, 10, 12, 23, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name  string
+		times []time.Time
+		today int
+		week  int
+	}{
+		{name: "first", times: []time.Time{mon}, today: 1, week: 1},
+		{name: "same day", times: []time.Time{mon, mon.Add(30 * time.Minute)}, today: 2, week: 2},
+		{name: "next day", times: []time.Time{mon, mon, mon.Add(2 * time.Hour)}, today: 1, week: 3},
+		{name: "next week", times: []time.Time{mon, mon.AddDate(0, 0, 7)}, today: 1, week: 1},
+		{name: "same weekday of another year", times: []time.Time{mon.AddDate(-1, 0, 0), mon}, today: 1, week: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := commitCount{}
+			for _, tm := range tt.times {
+				c = c.countUp(tm)
+			}
+			if c.Today != tt.today || c.ThisWeek != tt.week {
+				t.Errorf("counts = %d today, %d this week, want %d, %d", c.Today, c.ThisWeek, tt.today, tt.week)
+			}
+		})
+	}
+}
+
+func TestOrdinal(t *testing.T) {
+	defer func(l string) { currentLang = l }(currentLang)
+
+	currentLang = langEnglish
+	for n, want := range map[int]string{
+		1: "1st", 2: "2nd", 3: "3rd", 4: "4th",
+		11: "11th", 12: "12th", 13: "13th",
+		21: "21st", 22: "22nd", 23: "23rd",
+		101: "101st", 111: "111th", 112: "112th",
+	} {
+		if got := ordinal(n); got != want {
+			t.Errorf("ordinal(%d) = %q, want %q", n, got, want)
+		}
+	}
+
+	currentLang = langJapanese
+	if got := ordinal(3); got != "3 件目" {
+		t.Errorf("ordinal(3) = %q in Japanese", got)
+	}
+}
+
+func TestShowFunFacts(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("AppData", t.TempDir())
+
+	stderr := os.Stderr
+	devnull, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devnull.Close()
+	os.Stderr = devnull
+	defer func() { os.Stderr = stderr }()
+
+	r1 := testutil.NewRepo(t)
+	r2 := testutil.NewRepo(t)
+	(globalCmd{repository: r1.Repository}).showFunFacts()
+	(globalCmd{repository: r1.Repository}).showFunFacts()
+	(globalCmd{repository: r2.Repository}).showFunFacts()
+
+	counts := readCommitCounts(commitCountsPath())
+	if len(counts) != 2 {
+		t.Fatalf("counts = %v, want per worktree", counts)
+	}
+	if c := counts[r1.Dir]; c.Today != 2 || c.ThisWeek != 2 {
+		t.Errorf("counts of the first repository = %+v", c)
+	}
+	if c := counts[r2.Dir]; c.Today != 1 || c.ThisWeek != 1 {
+		t.Errorf("counts of the second repository = %+v", c)
+	}
+}
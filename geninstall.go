@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+)
+
+// gen --set-config and --hook wire the generated rule file into the repository:
+// gitconfig cx.rule and cx.scopes in .git/config, and a commit-msg hook linting the messages.
+// What is written is printed with the previous values, so that it can be undone by hand.
+
+// commitMsgHook is the commit-msg hook installed by gen --hook.
+const commitMsgHook = `#!/bin/sh
+# installed by git cx gen --hook
+exec git cx lint "$1"
+`
+
+// openGenRepository opens the repository of the current directory for --set-config and --hook.
+func openGenRepository() (*git.Repository, string, error) {
+	repos, err := plainOpen(".")
+	if err != nil {
+		return nil, "", withMessage(ErrNoRepository, tr(msgNotRepository))
+	}
+	root := worktreeRoot(repos)
+	if root == "" {
+		return nil, "", withMessage(ErrNoRepository, tr(msgNotRepository))
+	}
+	return repos, root, nil
+}
+
+// ruleConfigValue returns the rule file filename relative to the worktree root, as gitconfig cx.rule is.
+func ruleConfigValue(root, filename string) (string, error) {
+	// the file may not be written yet
+	dir, base := filepath.Split(filename)
+	rel, err := filepath.Rel(root, filepath.Join(realPath(filepath.Clean(dir)), base))
+	if err != nil || !filepath.IsLocal(rel) {
+		return "", errors.New(tr(msgGenOutsideWorktree, filename, root))
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// setRuleConfig sets gitconfig cx.rule to rule,
+// and cx.scopes to the default scope history file unless it is set.
+func setRuleConfig(repos *git.Repository, rule string) error {
+	cfg, err := repos.Config()
+	if err != nil {
+		return err
+	}
+	section := cfg.Raw.Section(configSection)
+
+	if old := section.Option(configRule); old == "" || old == rule {
+		fmt.Fprintln(os.Stderr, tr(msgGenConfigSet, configSection, configRule, rule))
+	} else {
+		fmt.Fprintln(os.Stderr, tr(msgGenConfigReplaced, configSection, configRule, rule, old))
+	}
+	section.SetOption(configRule, rule)
+
+	// a scope history file chosen already, or none, is kept
+	if old := section.Option(configScopeHistory); old != "" {
+		fmt.Fprintln(os.Stderr, tr(msgGenConfigKept, configSection, configScopeHistory, old))
+	} else {
+		scopes := defaultScopesFileName + ".yaml"
+		section.SetOption(configScopeHistory, scopes)
+		fmt.Fprintln(os.Stderr, tr(msgGenConfigSet, configSection, configScopeHistory, scopes))
+	}
+
+	if err := repos.SetConfig(cfg); err != nil {
+		return err
+	}
+	if fs, ok := repos.Storer.(*filesystem.Storage); ok {
+		fmt.Fprintln(os.Stderr, tr(msgGenConfigFile, filepath.Join(fs.Filesystem().Root(), "config")))
+	}
+	return nil
+}
+
+// installCommitMsgHook writes commitMsgHook into the hooks directory (core.hooksPath if set).
+// An existing hook is refused unless force.
+func installCommitMsgHook(repos *git.Repository, force bool) error {
+	dir := hooksDir(repos)
+	if dir == "" {
+		return errors.New(tr(msgGenNoHooksDir))
+	}
+	path := filepath.Join(dir, "commit-msg")
+	if _, err := os.Stat(longPath(path)); err == nil && !force {
+		return errors.New(tr(msgGenHookExists, path))
+	}
+
+	if err := os.MkdirAll(longPath(dir), 0o755); err != nil {
+		return err
+	}
+	if err := writeFileAtomic(path, []byte(commitMsgHook)); err != nil {
+		return err
+	}
+	if runtime.GOOS != "windows" {
+		if err := os.Chmod(longPath(path), 0o755); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintln(os.Stderr, tr(msgGenHookInstalled, path))
+	return nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	prompt "github.com/elk-language/go-prompt"
+)
+
+// headerCutoffMarker is put at Rule.MaxHeaderLength in a header too long.
+const headerCutoffMarker = "|"
+
+// fitHeader asks the description again until the rendered header fits Rule.MaxHeaderLength.
+// The length is in characters (runes), since emojis and CJK descriptions are common.
+// With Rule.WarnOnlyHeaderLength, a longer header can be accepted by a confirmation instead.
+func (c globalCmd) fitHeader(typ, scope, desc string, breaking bool) (string, error) {
+	limit := c.rule.MaxHeaderLength
+	if limit <= 0 {
+		return desc, nil
+	}
+
+	for {
+		header := c.renderHeader(typ, scope, desc, breaking, stagedFiles(c.status))
+		n := utf8.RuneCountInString(header)
+		if n <= limit {
+			return desc, nil
+		}
+
+		if c.promptless() && !c.rule.WarnOnlyHeaderLength {
+			fmt.Fprintln(os.Stderr, "  "+markCutoff(header, limit))
+			return "", withMessage(ErrInvalidMessage, tr(msgHeaderTooLong, n, limit))
+		}
+
+		fmt.Fprintln(os.Stderr, tr(msgHeaderTooLong, n, limit))
+		fmt.Fprintln(os.Stderr, "  "+markCutoff(header, limit))
+		if c.promptless() {
+			return desc, nil
+		}
+
+		if c.rule.WarnOnlyHeaderLength {
+			answer, err := promptInput(prompt.WithPrefix(tr(msgHeaderTooLongAsk)))
+			if err != nil {
+				return "", err
+			}
+			if in(strings.ToLower(strings.TrimSpace(answer)), "y", "yes") {
+				return desc, nil
+			}
+		}
+
+		c.given.Description = ""
+		c.prefill.Description = desc
+		var err error
+		if desc, err = c.promptDesc(typ, scope); err != nil {
+			return "", err
+		}
+	}
+}
+
+// markCutoff puts headerCutoffMarker after the first limit characters of header.
+func markCutoff(header string, limit int) string {
+	r := []rune(header)
+	if len(r) <= limit {
+		return header
+	}
+	return string(r[:limit]) + headerCutoffMarker + string(r[limit:])
+}
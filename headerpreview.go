@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	prompt "github.com/elk-language/go-prompt"
+	pstrings "github.com/elk-language/go-prompt/strings"
+)
+
+// While the description is typed, the line above the prompt shows the header rendered with it and its length,
+// like `feat(api): ✨ add retry flag (27/72)`.
+//
+// go-prompt has no status line, so previewWriter draws it: the renderer erases down from the start of the prompt
+// on every render, where the line above is the preview. The line is reserved at the first render of a prompt,
+// and left with the last header when the prompt ends.
+
+// previewMinWidth is the width of the terminal below which the preview is not shown.
+const previewMinWidth = 30
+
+type previewState int
+
+const (
+	// previewIdle waits for the first render of a prompt
+	previewIdle previewState = iota
+	// previewShown draws on the line above the prompt
+	previewShown
+	// previewOff draws nothing until the prompt ends, in a terminal too narrow
+	previewOff
+	// previewClosing skips the erase of the prompt ending
+	previewClosing
+)
+
+// headerPreview renders the header of the description being typed.
+type headerPreview struct {
+	render func(desc string) string
+	limit  int
+	width  func() int
+
+	desc  string
+	state previewState
+}
+
+// headerPreview returns the preview of the header of typ and scope for the prompt of the description,
+// or nil if stdout is not a terminal.
+// reader is the reader of the prompt, giving the width of the terminal.
+func (c globalCmd) headerPreview(typ, scope string, reader prompt.Reader) *headerPreview {
+	if !isTerminal(os.Stdout) {
+		return nil
+	}
+
+	staged := stagedFiles(c.status)
+	return &headerPreview{
+		render: func(desc string) string {
+			return c.renderHeader(typ, scope, desc, false, staged)
+		},
+		limit: c.rule.MaxHeaderLength,
+		width: func() int { return int(reader.GetWinSize().Col) },
+		desc:  c.prefill.Description,
+	}
+}
+
+// update sets the description being typed. It is to be called by the completer, which runs before every render.
+func (p *headerPreview) update(in prompt.Document) {
+	// the completion is also reset by an empty document, before the prompt ends by a key binding
+	if in.Text == "" && in.LastKeyStroke() == prompt.Escape {
+		return
+	}
+	p.desc = expandPlaceholders(in.Text)
+}
+
+// options returns the options of the prompt drawing the preview.
+func (p *headerPreview) options() []prompt.Option {
+	return []prompt.Option{
+		prompt.WithWriter(previewWriter{Writer: prompt.NewStdoutWriter(), preview: p}),
+		prompt.WithBreakLineCallback(func(*prompt.Document) {
+			p.state = previewClosing
+		}),
+	}
+}
+
+// line returns the preview within width columns, cutting the header to keep the length shown.
+func (p *headerPreview) line(width int) string {
+	header := p.render(p.desc)
+	n := utf8.RuneCountInString(header)
+	// a pasted newline would break the line
+	header = strings.NewReplacer("\r", " ", "\n", " ").Replace(header)
+
+	var length string
+	if p.limit > 0 {
+		header = markCutoff(header, p.limit)
+		length = fmt.Sprintf("%d/%d", n, p.limit)
+	} else {
+		length = strconv.Itoa(n)
+	}
+	rest := int(pstrings.GetWidth(tr(msgHeaderPreview, "", length)))
+	return tr(msgHeaderPreview, truncateWidth(header, width-rest), length)
+}
+
+// draw draws the preview by out, at the start of the prompt.
+func (p *headerPreview) draw(out prompt.Writer) {
+	switch p.state {
+	case previewIdle:
+		width := p.width()
+		if width < previewMinWidth {
+			p.state = previewOff
+			return
+		}
+		out.WriteRawString("\r" + p.line(width-1) + "\r\n")
+		p.state = previewShown
+
+	case previewShown:
+		width := p.width()
+		out.CursorUp(1)
+		out.WriteRawString("\r")
+		out.EraseLine()
+		if width >= previewMinWidth {
+			out.WriteRawString(p.line(width - 1))
+		}
+		out.CursorDown(1)
+		out.WriteRawString("\r")
+
+	case previewClosing:
+		p.state = previewIdle
+	}
+}
+
+// truncateWidth cuts s to width columns of the terminal, counting wide runes as 2.
+func truncateWidth(s string, width int) string {
+	var b strings.Builder
+	w := 0
+	for _, r := range s {
+		w += int(pstrings.GetRuneWidth(r))
+		if w > width {
+			break
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// previewWriter is the output of a prompt, drawing the preview whenever the prompt is erased to be rendered.
+type previewWriter struct {
+	prompt.Writer
+	preview *headerPreview
+}
+
+func (w previewWriter) EraseDown() {
+	w.Writer.EraseDown()
+	w.preview.draw(w.Writer)
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+)
+
+// hooks that can reject git commit
+var commitHookNames = []string{"pre-commit", "prepare-commit-msg", "commit-msg"}
+
+// commitHooks returns the names of the installed hooks that can reject git commit.
+func commitHooks(repos *git.Repository) []string {
+	dir := hooksDir(repos)
+	if dir == "" {
+		return nil
+	}
+
+	var hooks []string
+	for _, name := range commitHookNames {
+		if hookInstalled(filepath.Join(dir, name)) {
+			hooks = append(hooks, name)
+		}
+	}
+	return hooks
+}
+
+// hookPath returns the path of the hook name if it is installed, or "".
+func hookPath(repos *git.Repository, name string) string {
+	dir := hooksDir(repos)
+	if dir == "" {
+		return ""
+	}
+	path := filepath.Join(dir, name)
+	if !hookInstalled(path) {
+		return ""
+	}
+	return path
+}
+
+// hookInstalled reports whether path is a hook git runs.
+func hookInstalled(path string) bool {
+	s, err := os.Stat(longPath(path))
+	if err != nil || !s.Mode().IsRegular() {
+		return false
+	}
+	// git for Windows runs hooks without the executable bit
+	return runtime.GOOS == "windows" || s.Mode().Perm()&0o111 != 0
+}
+
+// hooksDir returns core.hooksPath (relative to the worktree), or the hooks directory in the git directory.
+func hooksDir(repos *git.Repository) string {
+	if repos == nil {
+		return ""
+	}
+
+	if cfg, err := repos.Config(); err == nil {
+		if p := cfg.Raw.Section("core").Option("hooksPath"); p != "" {
+			if filepath.IsAbs(p) {
+				return p
+			}
+			if wt, err := repos.Worktree(); err == nil {
+				return filepath.Join(wt.Filesystem.Root(), p)
+			}
+		}
+	}
+
+	fs, ok := repos.Storer.(*filesystem.Storage)
+	if !ok {
+		return ""
+	}
+	return filepath.Join(fs.Filesystem().Root(), "hooks")
+}
+
+// Where git commit does not run, in --debug, --dry-run and the native commit, git-cx runs the message hooks as git would:
+// prepare-commit-msg before the prompts to pre-fill them, and commit-msg (unless --no-verify) on the message built.
+
+// hookedMessage writes msg to a file, runs the hook name with it and args, and returns the file as the hook left it.
+// If the hook is not installed, msg is returned as it is.
+func (c globalCmd) hookedMessage(ctx context.Context, name, msg string, args ...string) (string, error) {
+	path := hookPath(c.repository, name)
+	if path == "" {
+		return msg, nil
+	}
+
+	f, err := os.CreateTemp(longPath(os.TempDir()), "COMMIT_EDITMSG-*")
+	if err != nil {
+		return "", err
+	}
+	_, err = f.WriteString(msg)
+	f.Close()
+	defer os.Remove(f.Name())
+	if err != nil {
+		return "", err
+	}
+
+	cmdctx, cmdcancel := c.opContext(ctx)
+	defer cmdcancel()
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		// as git for Windows does, since hooks are shell scripts
+		cmd = exec.CommandContext(cmdctx, "sh", append([]string{path, f.Name()}, args...)...)
+	} else {
+		cmd = exec.CommandContext(cmdctx, path, append([]string{f.Name()}, args...)...)
+	}
+	if wt, err := c.repository.Worktree(); err == nil {
+		cmd.Dir = wt.Filesystem.Root()
+	}
+	output := bytes.Buffer{}
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		return "", &HookRejectedError{Hook: name, Output: output.String()}
+	}
+	os.Stderr.Write(output.Bytes())
+
+	content, err := os.ReadFile(f.Name())
+	if err != nil {
+		return "", err
+	}
+	return strings.ReplaceAll(string(content), "\r\n", "\n"), nil
+}
+
+// decideCommitMode tells whether to commit by go-git, and so whether git-cx runs the message hooks.
+func (c *globalCmd) decideCommitMode(f *commitFlow) error {
+	if !c.Debug && !c.DryRun && c.commitsNatively() {
+		if problem := c.nativeCommitProblem(f.noGPGSign); problem != "" {
+			warn(tr(msgNativeFallback, problem))
+		} else {
+			f.native = true
+		}
+	}
+	f.runsHooks = c.Debug || c.DryRun || f.native
+	return nil
+}
+
+// prefillByHook pre-fills the prompts with what prepare-commit-msg writes, such as a ticket ID from the branch name.
+// A conventional message of a type of the rule fills all the prompts; otherwise its subject is the description.
+func (c *globalCmd) prefillByHook(f *commitFlow) error {
+	if !f.runsHooks || hookPath(c.repository, "prepare-commit-msg") == "" {
+		return nil
+	}
+
+	var initial string
+	var args []string
+	switch {
+	case c.Amend:
+		initial, args = c.prefillMessage("HEAD"), []string{"commit", "HEAD"}
+	case c.Like != "":
+		initial, args = c.prefillMessage(c.Like), []string{"commit", c.Like}
+	}
+
+	msg, err := c.hookedMessage(f.ctx, "prepare-commit-msg", initial, args...)
+	if err != nil {
+		return err
+	}
+	msg = strings.TrimLeft(strings.TrimRight(stripCommentLines(msg, commentChar(c.repository)), "\n"), "\n")
+	if strings.TrimSpace(msg) == "" || strings.TrimSpace(msg) == strings.TrimSpace(initial) {
+		return nil
+	}
+	if cc, ok := parseCommitMessage(msg); ok {
+		if _, found := c.rule.Types.Get(cc.Type); found {
+			c.prefill = c.prefillFromMessage(msg)
+			return nil
+		}
+	}
+	// such as `T-42: `, keeping the trailing space to type after
+	subject, _, _ := strings.Cut(msg, "\n")
+	c.prefill.Description = strings.TrimRight(subject, "\r")
+	return nil
+}
+
+// prefillMessage returns the message of the commit rev, or "" if it can not be read.
+func (c globalCmd) prefillMessage(rev string) string {
+	msg, err := commitMessage(c.repository, rev)
+	if err != nil {
+		return ""
+	}
+	return msg
+}
+
+// checkByHook runs commit-msg on the message, which may rewrite or reject it.
+func (c *globalCmd) checkByHook(f *commitFlow) error {
+	if !f.runsHooks || c.NoVerify || hookPath(c.repository, "commit-msg") == "" {
+		return nil
+	}
+
+	msg, err := c.hookedMessage(f.ctx, "commit-msg", f.msg+"\n")
+	if err != nil {
+		return err
+	}
+	msg = strings.TrimSpace(stripCommentLines(msg, commentChar(c.repository)))
+	if msg == "" {
+		return withMessage(ErrUserAborted, tr(msgEditEmpty))
+	}
+	if header, _, _ := strings.Cut(f.msg, "\n"); !strings.HasPrefix(msg, header+"\n") && msg != header {
+		c.renderedHeader = ""
+		f.rendered = ""
+	}
+	f.msg = msg
+	return nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+)
+
+// go-git's status leaves out new files ignored by .gitignore and .git/info/exclude, but not by core.excludesFile,
+// and reports the changes of tracked files in ignored directories as git does.
+// ignoreMatcher makes the same decision as git for untracked files, from all the three.
+
+// ignoreMatcher tells the untracked files git ignores.
+type ignoreMatcher struct {
+	m gitignore.Matcher
+}
+
+// newIgnoreMatcher reads the patterns of the .gitignore files, .git/info/exclude and core.excludesFile of the worktree.
+// Patterns that can not be read are skipped.
+func newIgnoreMatcher(repos *git.Repository, wt *git.Worktree) ignoreMatcher {
+	// in the ascending order of priority
+	patterns := readExcludesFile(excludesFilePath(repos))
+	ps, _ := gitignore.ReadPatterns(wt.Filesystem, nil)
+	patterns = append(patterns, ps...)
+	patterns = append(patterns, wt.Excludes...)
+	return ignoreMatcher{m: gitignore.NewMatcher(patterns)}
+}
+
+// ignored reports whether git ignores file (slash-separated) if it is not tracked.
+// Tracked files are never ignored, whatever the patterns say.
+func (m ignoreMatcher) ignored(file string) bool {
+	if m.m == nil {
+		return false
+	}
+	return m.m.Match(strings.Split(file, "/"), false)
+}
+
+// excludesFilePath returns core.excludesFile, or $XDG_CONFIG_HOME/git/ignore as git defaults to.
+func excludesFilePath(repos *git.Repository) string {
+	if f := gitConfigOption(repos, "core", "excludesFile"); f != "" {
+		return expandHome(f)
+	}
+
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "git", "ignore")
+}
+
+// readExcludesFile reads the patterns in filename, relative to the worktree root.
+func readExcludesFile(filename string) []gitignore.Pattern {
+	if filename == "" {
+		return nil
+	}
+	content, err := os.ReadFile(longPath(filename))
+	if err != nil {
+		return nil
+	}
+
+	var patterns []gitignore.Pattern
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, gitignore.ParsePattern(line, nil))
+	}
+	return patterns
+}
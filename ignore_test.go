@@ -0,0 +1,129 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/shu-go/git-cx/internal/testutil"
+)
+
+func TestIgnoreMatcher(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	r := testutil.NewRepo(t)
+	r.WriteFile(".gitignore", "build/\n*.log\nlogs/*\n!logs/keep.log\n")
+	r.WriteFile("pkg/.gitignore", "generated.go\n")
+	r.WriteFile(".git/info/exclude", "*.local\n")
+	excludesFile := filepath.Join(t.TempDir(), "ignore")
+	if err := os.WriteFile(excludesFile, []byte("# editors\n*.swp\n\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r.SetConfig("core", "excludesFile", excludesFile)
+
+	wt, err := r.Repository.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := newIgnoreMatcher(r.Repository, wt)
+
+	tests := []struct {
+		file string
+		want bool
+	}{
+		{file: "a.txt", want: false},
+		{file: "build/a.txt", want: true},
+		{file: "src/build/a.txt", want: true},
+		{file: "a.log", want: true},
+		{file: "logs/other.txt", want: true},
+		{file: "logs/keep.log", want: false},
+		{file: "pkg/generated.go", want: true},
+		{file: "generated.go", want: false},
+		{file: "settings.local", want: true},
+		{file: "pkg/.a.go.swp", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			if got := m.ignored(tt.file); got != tt.want {
+				t.Errorf("ignored(%q) = %v, want %v", tt.file, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExcludesFileDefault(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+
+	r := testutil.NewRepo(t)
+	if got, want := excludesFilePath(r.Repository), filepath.Join(xdg, "git", "ignore"); got != want {
+		t.Errorf("excludesFilePath() = %q, want %q", got, want)
+	}
+
+	r.WriteFile("x.tmp", "x")
+	if err := os.MkdirAll(filepath.Join(xdg, "git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(xdg, "git", "ignore"), []byte("*.tmp\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	wt, err := r.Repository.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !newIgnoreMatcher(r.Repository, wt).ignored("x.tmp") {
+		t.Error("x.tmp is not ignored by $XDG_CONFIG_HOME/git/ignore")
+	}
+}
+
+// Tracked files in ignored directories are staged by --all, as git does,
+// and ignored files are not staged even as the new side of renames.
+func TestAllStagingPlanIgnored(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	r := testutil.NewRepo(t)
+	r.WriteFile(".gitignore", "build/\n*.log\nlogs/*\n!logs/keep.log\n")
+	r.WriteFile("build/keep.txt", "k")
+	r.WriteFile("build/gone.txt", "g")
+	r.WriteFile("logs/keep.log", "k")
+	r.WriteFile("a.txt", "a")
+	r.WriteFile("c.txt", "c")
+	r.Stage(".gitignore", "a.txt", "c.txt", "build/keep.txt", "build/gone.txt", "logs/keep.log")
+	r.Commit("chore: init")
+
+	excludesFile := filepath.Join(t.TempDir(), "ignore")
+	if err := os.WriteFile(excludesFile, []byte("*.tmp\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r.SetConfig("core", "excludesFile", excludesFile)
+
+	r.WriteFile("build/keep.txt", "k2")
+	r.Remove("build/gone.txt")
+	r.WriteFile("build/new.txt", "n")
+	r.WriteFile("logs/keep.log", "k2")
+	r.WriteFile("logs/other.log", "o")
+	r.WriteFile("x.log", "l")
+	r.Remove("a.txt")
+	r.WriteFile("a.tmp", "a")
+	r.Remove("c.txt")
+	r.WriteFile("d.txt", "c")
+
+	wt, err := r.Repository.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	st, err := wt.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := newIgnoreMatcher(r.Repository, wt)
+	got := allStagingPlan(st, detectRenames(r.Repository, wt, st, m), m)
+	want := []string{"a.txt", "build/gone.txt", "build/keep.txt", "c.txt", "d.txt", "logs/keep.log"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("allStagingPlan() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+)
+
+const (
+	indexLockFileName = "index.lock"
+
+	indexLockFirstBackoff = 50 * time.Millisecond
+	indexLockMaxBackoff   = 400 * time.Millisecond
+)
+
+// waitIndexUnlock waits, up to --lock-wait, until another git process (an IDE, mostly) releases index.lock.
+//
+// go-git writes the index without taking the lock, and git fails if it is taken,
+// so this is called before staging and committing.
+func (c globalCmd) waitIndexUnlock(ctx context.Context) error {
+	lock := indexLockPath(c.repository)
+	if lock == "" {
+		return nil
+	}
+
+	deadline := time.Now().Add(c.LockWait)
+	backoff := indexLockFirstBackoff
+	for {
+		if _, err := os.Stat(longPath(lock)); errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		if !time.Now().Before(deadline) {
+			return errors.New(tr(msgIndexLocked, lock))
+		}
+
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case <-time.After(min(backoff, time.Until(deadline))):
+		}
+		backoff = min(backoff*2, indexLockMaxBackoff)
+	}
+}
+
+// indexLockPath returns the path of index.lock in the git directory, or "" if not on a filesystem.
+func indexLockPath(repos *git.Repository) string {
+	if repos == nil {
+		return ""
+	}
+	fs, ok := repos.Storer.(*filesystem.Storage)
+	if !ok {
+		return ""
+	}
+	return filepath.Join(fs.Filesystem().Root(), indexLockFileName)
+}
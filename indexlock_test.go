@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shu-go/git-cx/internal/testutil"
+)
+
+func TestWaitIndexUnlock(t *testing.T) {
+	tests := []struct {
+		name      string
+		locked    bool
+		releaseIn time.Duration
+		interrupt bool
+		wantErr   func(lock string) error
+	}{
+		{name: "not locked"},
+		{name: "released while waiting", locked: true, releaseIn: 100 * time.Millisecond},
+		{
+			name:    "still locked",
+			locked:  true,
+			wantErr: func(lock string) error { return errors.New(tr(msgIndexLocked, lock)) },
+		},
+		{
+			name:      "interrupted",
+			locked:    true,
+			interrupt: true,
+			wantErr:   func(string) error { return ErrInterrupted },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := testutil.NewRepo(t)
+			lock := filepath.Join(r.Dir, ".git", indexLockFileName)
+			if tt.locked {
+				if err := os.WriteFile(lock, nil, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.releaseIn > 0 {
+				time.AfterFunc(tt.releaseIn, func() { os.Remove(lock) })
+			}
+
+			ctx, cancel := context.WithCancelCause(context.Background())
+			defer cancel(nil)
+			lockWait := 300 * time.Millisecond
+			if tt.interrupt {
+				lockWait = time.Minute
+				time.AfterFunc(100*time.Millisecond, func() { cancel(errInterrupted()) })
+			}
+
+			c := globalCmd{LockWait: lockWait, repository: r.Repository}
+			start := time.Now()
+			err := c.waitIndexUnlock(ctx)
+			switch {
+			case tt.wantErr == nil:
+				if err != nil {
+					t.Errorf("waitIndexUnlock() = %v", err)
+				}
+			case tt.interrupt:
+				if !errors.Is(err, ErrInterrupted) {
+					t.Errorf("waitIndexUnlock() = %v, want %v", err, ErrInterrupted)
+				}
+				if d := time.Since(start); d > 10*time.Second {
+					t.Errorf("waited %v after Ctrl+C", d)
+				}
+			default:
+				if want := tt.wantErr(lock); err == nil || err.Error() != want.Error() {
+					t.Errorf("waitIndexUnlock() = %v, want %v", err, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package testutil
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	prompt "github.com/elk-language/go-prompt"
+)
+
+// Prompter replays scripted answers as the prompter of git-cx, in the order they are asked.
+//
+// Each prompt takes an answer, and so does each line of the body, which ends with two empty lines:
+//
+//	p := testutil.NewPrompter(t,
+//		"feat", "api", "add retry flag", // type, scope and description
+//		"the body", "", "", // body
+//	)
+//
+// Running out of answers fails the test and results in io.EOF, as Ctrl+D does.
+type Prompter struct {
+	TB testing.TB
+
+	answers []string
+	asked   int
+}
+
+// NewPrompter returns a Prompter replaying answers.
+func NewPrompter(tb testing.TB, answers ...string) *Prompter {
+	return &Prompter{TB: tb, answers: answers}
+}
+
+// Input returns the next answer, ignoring the prompt.
+func (p *Prompter) Input(...prompt.Option) (string, error) {
+	return p.next()
+}
+
+// ReadLine returns the next answer as a line of the body.
+func (p *Prompter) ReadLine(ctx context.Context) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", context.Cause(ctx)
+	}
+	return p.next()
+}
+
+// Asked returns how many answers have been taken.
+func (p *Prompter) Asked() int {
+	return p.asked
+}
+
+// Remaining returns the answers not taken yet.
+func (p *Prompter) Remaining() []string {
+	return p.answers[p.asked:]
+}
+
+func (p *Prompter) next() (string, error) {
+	if p.asked >= len(p.answers) {
+		p.TB.Errorf("asked more than the %d answers scripted", len(p.answers))
+		return "", io.EOF
+	}
+	p.asked++
+	return p.answers[p.asked-1], nil
+}
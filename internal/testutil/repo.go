@@ -0,0 +1,341 @@
+// Package testutil builds fixture repositories for tests of git-cx,
+// and reads back what has been committed.
+//
+// All helpers fail the test on an error, so that tests read as a scenario:
+//
+//	r := testutil.NewRepo(t)
+//	r.CommitFile("api/a.go", "package api", "feat(api): add a")
+//	r.Tag("v1.0.0")
+//	r.WriteFile("api/b.go", "package api")
+//	r.Stage("api/b.go")
+//	r.Chdir()
+//	// run the flow
+//	if got := r.HeadMessage(); got != "fix(api): add b\n" { ... }
+//
+// NewRepoWithSubmodule and Repo.NewWorktree build the variants needing git,
+// and Prompter answers the prompts of the flow.
+package testutil
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Author is the author and committer of fixture commits, also set as user.name and user.email of the repository.
+var Author = object.Signature{Name: "Fixture", Email: "fixture@example.com"}
+
+// baseTime is the time of the first fixture commit. Each commit is a minute later than the previous one,
+// so that the order by time is the order of commits.
+var baseTime = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Repo is a repository in a temporary directory.
+type Repo struct {
+	TB         testing.TB
+	Dir        string
+	Repository *git.Repository
+
+	commits int
+}
+
+// NewRepo initializes a repository with the branch main in a temporary directory removed after the test.
+func NewRepo(tb testing.TB) *Repo {
+	tb.Helper()
+
+	dir := tb.TempDir()
+	repos, err := git.PlainInitWithOptions(dir, &git.PlainInitOptions{
+		InitOptions: git.InitOptions{DefaultBranch: plumbing.NewBranchReferenceName("main")},
+	})
+	if err != nil {
+		tb.Fatalf("init %s: %v", dir, err)
+	}
+
+	r := &Repo{TB: tb, Dir: dir, Repository: repos}
+	r.SetConfig("user", "name", Author.Name)
+	r.SetConfig("user", "email", Author.Email)
+	return r
+}
+
+// NewRepoWithSubmodule initializes a repository like NewRepo with a commit adding a submodule at path,
+// and returns it and the submodule, checked out in it.
+// It needs git, and skips the test without it.
+func NewRepoWithSubmodule(tb testing.TB, path string) (r, sub *Repo) {
+	tb.Helper()
+
+	origin := NewRepo(tb)
+	origin.CommitFile("README.md", "submodule\n", "chore: init")
+
+	r = NewRepo(tb)
+	r.CommitFile("README.md", "superproject\n", "chore: init")
+	r.git("-c", "protocol.file.allow=always", "submodule", "add", "--quiet", origin.Dir, path)
+	r.Commit("chore: add " + path)
+
+	return r, r.open(filepath.Join(r.Dir, filepath.FromSlash(path)))
+}
+
+// NewWorktree adds a linked worktree with a new branch at HEAD in a temporary directory, like `git worktree add -b branch`.
+// It needs git, and skips the test without it.
+func (r *Repo) NewWorktree(branch string) *Repo {
+	r.TB.Helper()
+
+	dir := filepath.Join(r.TB.TempDir(), branch)
+	r.git("worktree", "add", "--quiet", "-b", branch, dir)
+	return r.open(dir)
+}
+
+// open opens the repository at dir, which shares the commits with r.
+func (r *Repo) open(dir string) *Repo {
+	r.TB.Helper()
+
+	repos, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{EnableDotGitCommonDir: true})
+	if err != nil {
+		r.TB.Fatalf("open %s: %v", dir, err)
+	}
+	return &Repo{TB: r.TB, Dir: dir, Repository: repos, commits: r.commits}
+}
+
+// git runs the git command in the worktree, for what go-git can not do.
+func (r *Repo) git(args ...string) {
+	r.TB.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		r.TB.Skip("git is not found")
+	}
+	cmd := exec.Command("git", args...)
+	cmd.Dir = r.Dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		r.TB.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+// SetConfig sets the option key of section in the repository config, like `git config section.key value`.
+func (r *Repo) SetConfig(section, key, value string) {
+	r.TB.Helper()
+
+	cfg, err := r.Repository.Config()
+	if err != nil {
+		r.TB.Fatalf("config: %v", err)
+	}
+	cfg.Raw.Section(section).SetOption(key, value)
+	if err := r.Repository.SetConfig(cfg); err != nil {
+		r.TB.Fatalf("config: %v", err)
+	}
+}
+
+// WriteFile writes content to name, a slash-separated path relative to the worktree, creating the directories.
+func (r *Repo) WriteFile(name, content string) {
+	r.TB.Helper()
+
+	path := filepath.Join(r.Dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		r.TB.Fatalf("write %s: %v", name, err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		r.TB.Fatalf("write %s: %v", name, err)
+	}
+}
+
+// Remove removes name from the worktree, not from the index.
+func (r *Repo) Remove(name string) {
+	r.TB.Helper()
+
+	if err := os.Remove(filepath.Join(r.Dir, filepath.FromSlash(name))); err != nil {
+		r.TB.Fatalf("remove %s: %v", name, err)
+	}
+}
+
+// Stage stages names, including removals, like `git add -A names...`.
+func (r *Repo) Stage(names ...string) {
+	r.TB.Helper()
+
+	wt := r.worktree()
+	for _, name := range names {
+		if _, err := wt.Add(name); err != nil {
+			if _, rmErr := wt.Remove(name); rmErr != nil {
+				r.TB.Fatalf("stage %s: %v", name, err)
+			}
+		}
+	}
+}
+
+// Commit commits what is staged with msg and returns the hash.
+func (r *Repo) Commit(msg string) plumbing.Hash {
+	r.TB.Helper()
+
+	sig := Author
+	sig.When = baseTime.Add(time.Duration(r.commits) * time.Minute)
+	r.commits++
+
+	hash, err := r.worktree().Commit(msg, &git.CommitOptions{
+		Author:            &sig,
+		Committer:         &sig,
+		AllowEmptyCommits: true,
+	})
+	if err != nil {
+		r.TB.Fatalf("commit %q: %v", msg, err)
+	}
+	return hash
+}
+
+// CommitFile writes, stages and commits a file with msg.
+func (r *Repo) CommitFile(name, content, msg string) plumbing.Hash {
+	r.TB.Helper()
+
+	r.WriteFile(name, content)
+	r.Stage(name)
+	return r.Commit(msg)
+}
+
+// Commits commits each of msgs, changing a file named history.txt, and returns the hashes in order.
+func (r *Repo) Commits(msgs ...string) []plumbing.Hash {
+	r.TB.Helper()
+
+	var hashes []plumbing.Hash
+	for i, msg := range msgs {
+		hashes = append(hashes, r.CommitFile("history.txt", strconv.Itoa(r.commits+i), msg))
+	}
+	return hashes
+}
+
+// Tag creates a lightweight tag at HEAD.
+func (r *Repo) Tag(name string) {
+	r.TB.Helper()
+
+	if _, err := r.Repository.CreateTag(name, r.head(), nil); err != nil {
+		r.TB.Fatalf("tag %s: %v", name, err)
+	}
+}
+
+// Branch creates a branch at HEAD and checks it out, keeping the worktree and the index.
+func (r *Repo) Branch(name string) {
+	r.TB.Helper()
+
+	err := r.worktree().Checkout(&git.CheckoutOptions{
+		Branch: plumbing.NewBranchReferenceName(name),
+		Create: true,
+		Keep:   true,
+	})
+	if err != nil {
+		r.TB.Fatalf("branch %s: %v", name, err)
+	}
+}
+
+// Chdir changes the working directory to the worktree, or to sub under it, until the test ends.
+func (r *Repo) Chdir(sub ...string) {
+	r.TB.Helper()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		r.TB.Fatalf("chdir: %v", err)
+	}
+	dir := filepath.Join(append([]string{r.Dir}, sub...)...)
+	if err := os.Chdir(dir); err != nil {
+		r.TB.Fatalf("chdir %s: %v", dir, err)
+	}
+	r.TB.Cleanup(func() { _ = os.Chdir(wd) })
+}
+
+// HeadMessage returns the message of HEAD.
+func (r *Repo) HeadMessage() string {
+	r.TB.Helper()
+
+	return r.commit(r.head()).Message
+}
+
+// Messages returns the messages of the latest n commits from HEAD, newest first.
+func (r *Repo) Messages(n int) []string {
+	r.TB.Helper()
+
+	var msgs []string
+	c := r.commit(r.head())
+	for len(msgs) < n {
+		msgs = append(msgs, c.Message)
+		if c.NumParents() == 0 {
+			break
+		}
+		parent, err := c.Parent(0)
+		if err != nil {
+			r.TB.Fatalf("parent of %s: %v", c.Hash, err)
+		}
+		c = parent
+	}
+	return msgs
+}
+
+// CommitCount returns the number of commits reachable from HEAD, 0 if there is none.
+func (r *Repo) CommitCount() int {
+	r.TB.Helper()
+
+	ref, err := r.Repository.Head()
+	if err == plumbing.ErrReferenceNotFound {
+		return 0
+	}
+	if err != nil {
+		r.TB.Fatalf("HEAD: %v", err)
+	}
+
+	iter, err := r.Repository.Log(&git.LogOptions{From: ref.Hash()})
+	if err != nil {
+		r.TB.Fatalf("log: %v", err)
+	}
+	n := 0
+	_ = iter.ForEach(func(*object.Commit) error {
+		n++
+		return nil
+	})
+	return n
+}
+
+// StagedFiles returns the paths of the files staged, in no particular order.
+func (r *Repo) StagedFiles() []string {
+	r.TB.Helper()
+
+	st, err := r.worktree().Status()
+	if err != nil {
+		r.TB.Fatalf("status: %v", err)
+	}
+	var files []string
+	for name, s := range st {
+		if s.Staging != git.Unmodified && s.Staging != git.Untracked {
+			files = append(files, name)
+		}
+	}
+	return files
+}
+
+func (r *Repo) worktree() *git.Worktree {
+	r.TB.Helper()
+
+	wt, err := r.Repository.Worktree()
+	if err != nil {
+		r.TB.Fatalf("worktree: %v", err)
+	}
+	return wt
+}
+
+func (r *Repo) head() plumbing.Hash {
+	r.TB.Helper()
+
+	ref, err := r.Repository.Head()
+	if err != nil {
+		r.TB.Fatalf("HEAD: %v", err)
+	}
+	return ref.Hash()
+}
+
+func (r *Repo) commit(hash plumbing.Hash) *object.Commit {
+	r.TB.Helper()
+
+	c, err := r.Repository.CommitObject(hash)
+	if err != nil {
+		r.TB.Fatalf("commit %s: %v", hash, err)
+	}
+	return c
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// git-cx runs as `git cx`, git finding git-cx on PATH, or standalone as `git-cx`, with the same arguments:
+// git passes those after `cx`, including -a, to git-cx as they are.
+// git runs git-cx in the current directory, but an alias of a shell command (like `cx = !git-cx`)
+// at the top of the worktree, with GIT_PREFIX telling the directory the user ran it from.
+
+// invokedByGit reports whether git runs git-cx, which sets GIT_EXEC_PATH for the commands it runs.
+func invokedByGit() bool {
+	return os.Getenv("GIT_EXEC_PATH") != ""
+}
+
+// commandName returns how the user runs git-cx, for the help.
+func commandName() string {
+	if invokedByGit() {
+		return "git cx"
+	}
+	return "git-cx"
+}
+
+// enterGitPrefix changes to the directory in GIT_PREFIX, where the user ran the alias from,
+// so that relative paths (gen, --rule, --config, lint files) and the search of the rule file start there.
+// GIT_PREFIX is unset not to move again in git-cx run by git commands git-cx runs, such as in a hook.
+func enterGitPrefix() error {
+	prefix := os.Getenv("GIT_PREFIX")
+	if prefix == "" {
+		return nil
+	}
+	os.Unsetenv("GIT_PREFIX")
+
+	if err := os.Chdir(longPath(prefix)); err != nil {
+		return fmt.Errorf("GIT_PREFIX %s: %w", prefix, err)
+	}
+	return nil
+}
+
+// enterWorktree changes to dir for --worktree (-C), as git -C does, relative to the directory after GIT_PREFIX.
+// Every subcommand opens the repository and resolves relative paths from the working directory,
+// so moving before them is enough. dir must be in a worktree, even for the subcommands that run outside one.
+func enterWorktree(dir string) error {
+	if s, err := os.Stat(longPath(dir)); err != nil || !s.IsDir() {
+		return withMessage(ErrNoRepository, tr(msgWorktreeNotFound, dir))
+	}
+	if err := os.Chdir(longPath(dir)); err != nil {
+		return fmt.Errorf("--worktree %s: %w", dir, err)
+	}
+
+	repos, err := plainOpen(".")
+	if err != nil {
+		return withMessage(ErrNoRepository, tr(msgWorktreeNotRepository, dir))
+	}
+	if _, err := repos.Worktree(); err != nil {
+		// bare
+		return withMessage(ErrNoRepository, tr(msgWorktreeNotRepository, dir))
+	}
+	return nil
+}
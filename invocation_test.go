@@ -0,0 +1,220 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	git "github.com/go-git/go-git/v5"
+
+	"github.com/shu-go/git-cx/internal/testutil"
+)
+
+func TestCommandName(t *testing.T) {
+	t.Setenv("GIT_EXEC_PATH", "")
+	if got := commandName(); got != "git-cx" {
+		t.Errorf("commandName() = %q standalone, want git-cx", got)
+	}
+
+	t.Setenv("GIT_EXEC_PATH", "/usr/lib/git-core")
+	if got := commandName(); got != "git cx" {
+		t.Errorf("commandName() = %q by git, want git cx", got)
+	}
+}
+
+func TestEnterGitPrefix(t *testing.T) {
+	r := testutil.NewRepo(t)
+	r.WriteFile("pkg/sub/a.txt", "a")
+
+	tests := []struct {
+		name    string
+		prefix  string
+		want    string
+		wantErr bool
+	}{
+		{name: "top", prefix: "", want: r.Dir},
+		{name: "subdirectory", prefix: "pkg/sub/", want: filepath.Join(r.Dir, "pkg", "sub")},
+		{name: "missing", prefix: "none/", want: r.Dir, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// as a shell alias runs at the top of the worktree
+			r.Chdir()
+			t.Setenv("GIT_PREFIX", tt.prefix)
+
+			err := enterGitPrefix()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("enterGitPrefix() = %v, want an error: %v", err, tt.wantErr)
+			}
+			if got := realPath(mustGetwd(t)); got != realPath(tt.want) {
+				t.Errorf("working directory = %q, want %q", got, tt.want)
+			}
+			if p, found := os.LookupEnv("GIT_PREFIX"); found && p != "" {
+				t.Errorf("GIT_PREFIX = %q, want unset not to move again in hooks", p)
+			}
+		})
+	}
+}
+
+func mustGetwd(t *testing.T) string {
+	t.Helper()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return wd
+}
+
+func TestEnterWorktree(t *testing.T) {
+	r := testutil.NewRepo(t)
+	r.WriteFile("pkg/a.txt", "a")
+	outside := t.TempDir()
+	bare := t.TempDir()
+	if _, err := git.PlainInit(bare, true); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		from    string
+		dir     string
+		want    string
+		wantErr error
+	}{
+		{name: "absolute", from: outside, dir: r.Dir, want: r.Dir},
+		{name: "subdirectory", from: outside, dir: filepath.Join(r.Dir, "pkg"), want: filepath.Join(r.Dir, "pkg")},
+		{name: "relative", from: r.Dir, dir: "pkg", want: filepath.Join(r.Dir, "pkg")},
+		{name: "relative upward", from: filepath.Join(r.Dir, "pkg"), dir: "..", want: r.Dir},
+		{name: "missing", from: r.Dir, dir: "none", wantErr: ErrNoRepository},
+		{name: "file", from: r.Dir, dir: filepath.Join("pkg", "a.txt"), wantErr: ErrNoRepository},
+		{name: "not a repository", from: r.Dir, dir: outside, wantErr: ErrNoRepository},
+		{name: "bare", from: r.Dir, dir: bare, wantErr: ErrNoRepository},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r.Chdir()
+			if err := os.Chdir(tt.from); err != nil {
+				t.Fatal(err)
+			}
+
+			err := enterWorktree(tt.dir)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("enterWorktree(%q) = %v, want %v", tt.dir, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := realPath(mustGetwd(t)); got != realPath(tt.want) {
+				t.Errorf("working directory = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// buildBinary builds git-cx into a temporary directory and returns its path.
+// It skips the test in -short mode, or without go or git.
+func buildBinary(t *testing.T) string {
+	t.Helper()
+
+	if testing.Short() {
+		t.Skip("building the binary in -short mode")
+	}
+	for _, name := range []string{"go", "git"} {
+		if _, err := exec.LookPath(name); err != nil {
+			t.Skipf("%s is not found", name)
+		}
+	}
+
+	bin := filepath.Join(t.TempDir(), "git-cx")
+	if runtime.GOOS == "windows" {
+		bin += ".exe"
+	}
+	if out, err := exec.Command("go", "build", "-o", bin, ".").CombinedOutput(); err != nil {
+		t.Fatalf("go build: %v\n%s", err, out)
+	}
+	return bin
+}
+
+// The built binary runs the same as `git cx`, found by git on PATH, and as `git-cx`.
+func TestBinaryInvocation(t *testing.T) {
+	bin := buildBinary(t)
+	// git finds git-cx on PATH; the global config is left out not to depend on the user's
+	env := append(os.Environ(),
+		"PATH="+filepath.Dir(bin)+string(os.PathListSeparator)+os.Getenv("PATH"),
+		"HOME="+t.TempDir(),
+		"XDG_CONFIG_HOME="+t.TempDir(),
+		"GIT_CONFIG_NOSYSTEM=1",
+	)
+
+	tests := []struct {
+		name string
+		cmd  []string
+	}{
+		{name: "git cx", cmd: []string{"git", "cx"}},
+		{name: "git-cx", cmd: []string{bin}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run := func(dir string, args ...string) string {
+				t.Helper()
+
+				cmd := exec.Command(tt.cmd[0], append(tt.cmd[1:], args...)...)
+				cmd.Dir, cmd.Env = dir, env
+				out, err := cmd.CombinedOutput()
+				if err != nil {
+					t.Fatalf("%s %v: %v\n%s", tt.name, args, err, out)
+				}
+				return string(out)
+			}
+
+			r := testutil.NewRepo(t)
+			r.CommitFile("README.md", "retry\n", "chore: init")
+			r.WriteFile("README.md", "retry with a backoff\n")
+
+			// git passes -a to git-cx as it is
+			run(r.Dir, "-a", "-q", "--native", "--type", "feat", "--scope", "api", "-m", "add retry flag")
+			if got, want := strings.TrimRight(r.HeadMessage(), "\n"), "feat(api): add retry flag"; got != want {
+				t.Errorf("HEAD = %q, want %q", got, want)
+			}
+			if got := r.StagedFiles(); len(got) != 0 {
+				t.Errorf("left staged = %v", got)
+			}
+			if r.CommitCount() != 2 {
+				t.Errorf("commits = %d, want 2", r.CommitCount())
+			}
+
+			// the help names the command as it is run
+			if out := run(r.Dir, "help"); !strings.HasPrefix(out, tt.name+" - ") {
+				t.Errorf("help = %q, want it of %q", firstLine(out), tt.name)
+			}
+		})
+	}
+
+	// an alias of a shell command runs at the top of the worktree, and git-cx moves back to GIT_PREFIX
+	t.Run("alias", func(t *testing.T) {
+		r := testutil.NewRepo(t)
+		r.WriteFile("pkg/a.txt", "a")
+
+		cmd := exec.Command("git", "-c", "alias.x=!git-cx", "x", "gen")
+		cmd.Dir, cmd.Env = filepath.Join(r.Dir, "pkg"), env
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git x gen: %v\n%s", err, out)
+		}
+		if _, err := os.Stat(filepath.Join(r.Dir, "pkg", defaultRuleFileName+".yaml")); err != nil {
+			t.Errorf("the rule file is not generated where the alias is run: %v", err)
+		}
+	})
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Violations that `lint --fix` corrects. Others are left to people.
+//
+//   - no space after the colon of the header
+//   - type in upper case (unless the rule defines it so)
+//   - trailing period of the description
+//   - no blank line before the footers
+//   - BREAKING-CHANGE spelled with a hyphen
+
+// headerNoSpacePattern is headerPattern without the space after the colon, like feat(api):add.
+var headerNoSpacePattern = regexp.MustCompile(`^([^\s():!]+)(?:\(([^()]*)\))?(!)?:(\S.*)$`)
+
+// fixableViolations returns the violations that fixMessage corrects.
+func fixableViolations(rule *Rule, msg string) []string {
+	_, changes := fixMessage(rule, msg)
+	return changes
+}
+
+// fixMessage corrects the fixable violations in msg, and returns the descriptions of the corrections.
+// Other parts of msg are kept as they are.
+func fixMessage(rule *Rule, msg string) (string, []string) {
+	var changes []string
+
+	msg = strings.ReplaceAll(msg, "\r\n", "\n")
+	header, rest, found := strings.Cut(msg, "\n")
+
+	// header
+
+	if loc := headerNoSpacePattern.FindStringSubmatchIndex(header); loc != nil {
+		header = header[:loc[8]] + " " + header[loc[8]:]
+		changes = append(changes, tr(msgLintHeaderSpace))
+	}
+	if loc := headerPattern.FindStringSubmatchIndex(header); loc != nil {
+		typ := header[loc[2]:loc[3]]
+		_, defined := rule.Types.Get(typ)
+		if lower := strings.ToLower(typ); lower != typ && !defined {
+			header = header[:loc[2]] + lower + header[loc[3]:]
+			changes = append(changes, tr(msgLintTypeCase, typ, lower))
+		}
+
+		desc := strings.TrimRight(header, " \t")
+		if strings.HasSuffix(desc, ".") && !strings.HasSuffix(desc, "..") {
+			header = strings.TrimSuffix(desc, ".")
+			changes = append(changes, tr(msgLintTrailingPeriod))
+		}
+	}
+
+	if !found {
+		return header, changes
+	}
+
+	// footers
+
+	lines := strings.Split(rest, "\n")
+	first, last := 0, len(lines)-1
+	for first <= last && lines[first] == "" {
+		first++
+	}
+	for last >= first && lines[last] == "" {
+		last--
+	}
+	content := append([]string(nil), lines[first:last+1]...)
+
+	start := footerStart(content)
+	for i := start; i < len(content); i++ {
+		if after, ok := strings.CutPrefix(content[i], breakingChangeTokenAlias); ok && footerPattern.MatchString(content[i]) {
+			content[i] = breakingChangeToken + after
+			changes = append(changes, tr(msgLintBreakingSpelling))
+		}
+	}
+	if start > 0 && start < len(content) && content[start-1] != "" {
+		content = append(content[:start], append([]string{""}, content[start:]...)...)
+		changes = append(changes, tr(msgLintFooterBlankLine))
+	}
+
+	lines = append(append(append([]string(nil), lines[:first]...), content...), lines[last+1:]...)
+	return header + "\n" + strings.Join(lines, "\n"), changes
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFixMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		msg     string
+		want    string
+		changes []string
+	}{
+		{
+			name: "nothing to fix",
+			msg:  "feat: add retry flag\n",
+			want: "feat: add retry flag\n",
+		},
+		{
+			name:    "type case",
+			msg:     "Feat(api): add retry flag\n",
+			want:    "feat(api): add retry flag\n",
+			changes: []string{tr(msgLintTypeCase, "Feat", "feat")},
+		},
+		{
+			name:    "trailing period",
+			msg:     "feat: add retry flag.\n",
+			want:    "feat: add retry flag\n",
+			changes: []string{tr(msgLintTrailingPeriod)},
+		},
+		{
+			name:    "no space after the colon",
+			msg:     "feat(api)!:add retry flag\n",
+			want:    "feat(api)!: add retry flag\n",
+			changes: []string{tr(msgLintHeaderSpace)},
+		},
+		{
+			name:    "no space after the colon of a header only",
+			msg:     "fix:handle nil",
+			want:    "fix: handle nil",
+			changes: []string{tr(msgLintHeaderSpace)},
+		},
+		{
+			name: "not a header",
+			msg:  "Add retry flag: see #1\n",
+			want: "Add retry flag: see #1\n",
+		},
+		{
+			name: "a footer-like line in the body is kept",
+			msg:  "feat: add retry flag\n\nthe body\nRefs: #1\n",
+			want: "feat: add retry flag\n\nthe body\nRefs: #1\n",
+		},
+		{
+			name: "ellipsis is kept",
+			msg:  "feat: add retry flag...\n",
+			want: "feat: add retry flag...\n",
+		},
+		{
+			name:    "blank line before footers",
+			msg:     "feat: drop v1\n\nthe body\nBREAKING CHANGE: v1 is gone\nRefs: #1\n",
+			want:    "feat: drop v1\n\nthe body\n\nBREAKING CHANGE: v1 is gone\nRefs: #1\n",
+			changes: []string{tr(msgLintFooterBlankLine)},
+		},
+		{
+			name:    "BREAKING-CHANGE spelling",
+			msg:     "feat: drop v1\n\nBREAKING-CHANGE: v1 is gone\n",
+			want:    "feat: drop v1\n\nBREAKING CHANGE: v1 is gone\n",
+			changes: []string{tr(msgLintBreakingSpelling)},
+		},
+		{
+			name:    "all at once",
+			msg:     "FIX:retry.\n\nthe body\nBREAKING-CHANGE: retries are counted\n",
+			want:    "fix: retry\n\nthe body\n\nBREAKING CHANGE: retries are counted\n",
+			changes: []string{tr(msgLintHeaderSpace), tr(msgLintTypeCase, "FIX", "fix"), tr(msgLintTrailingPeriod), tr(msgLintBreakingSpelling), tr(msgLintFooterBlankLine)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := defaultRule(false)
+			got, changes := fixMessage(&rule, tt.msg)
+			if got != tt.want {
+				t.Errorf("fixMessage() = %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(changes, tt.changes) {
+				t.Errorf("changes = %q, want %q", changes, tt.changes)
+			}
+		})
+	}
+}
+
+func TestFixMessageKeepsDefinedType(t *testing.T) {
+	rule := defaultRule(false)
+	rule.Types.Set("WIP", CommitType{Desc: "work in progress"})
+
+	msg := "WIP: retry\n"
+	if got, changes := fixMessage(&rule, msg); got != msg || len(changes) != 0 {
+		t.Errorf("fixMessage() = %q, %q, want %q unchanged", got, changes, msg)
+	}
+}
@@ -1,17 +1,17 @@
 package main
 
 import (
-	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"sort"
+	"strconv"
 	"strings"
-	"text/template"
 	"time"
 
 	prompt "github.com/elk-language/go-prompt"
@@ -27,6 +27,9 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
+// defaultHeaderFormat is the header format of the default rule, also used when the rule's fails.
+const defaultHeaderFormat = "{{.type}}{{.scope_with_parens}}{{.bang}}: {{.emoji}}{{.description}}"
+
 const (
 	userConfigFolder = "git-cx"
 
@@ -36,120 +39,613 @@ const (
 	configSection      = "cx"
 	configRule         = "rule"
 	configScopeHistory = "scopes"
+	scopeHistoryNone   = "none"
 )
 
 type globalCmd struct {
 	repository *git.Repository
 
-	rule *Rule
+	rule     *Rule
+	rulePath string
 
 	scopesFileName string
 	scopes         Scopes
 
+	// promptHistories are the answers of Rule.Prompts used, kept in the scope history file
+	promptHistories PromptHistories
+
+	// answers are the answers of Rule.Prompts, for the templates
+	answers map[string]string
+
+	bodyStateFileName string
+
+	descHistoryFileName string
+	descHistory         []descEntry
+
+	status git.Status
+
+	// renames are the staged renames (new path -> old path)
+	renames map[string]string
+
+	// renderedHeader is the header as GitHub renders it, if it differs
+	renderedHeader string
+
+	// prefill is the initial text of the prompts
+	prefill ConventionalCommit
+
+	// given are the answers on the command line, whose prompts are skipped
+	given ConventionalCommit
+
+	readOnlyPaths []string
+
+	profile *profiler
+
+	// commits are the history shared by the features reading it
+	commits *commitLog
+
+	// composing is true in compose, which only prints the message
+	composing bool
+
 	All bool `cli:"all,a" help:"commit all changed files"`
 
-	Debug bool `cli:"debug" default:"false" help:"do not commit, do output to stdout"`
+	Debug bool `cli:"debug" default:"false" help:"do not commit, output the message to stdout and a summary to stderr"`
+
+	Edit bool `cli:"edit,e" help:"edit the message in the editor (core.editor, $GIT_EDITOR or $EDITOR) before committing"`
+
+	DryRun bool `cli:"dry-run" help:"do not commit, output the message and fail (exit code 3) if it violates the rule"`
+
+	IgnoreSubmodules bool `cli:"ignore-submodules" help:"ignore changes of submodules"`
+
+	Lang langFlag `cli:"lang=LANG" help:"language of messages (en, ja)"`
+
+	Amend bool `cli:"amend" help:"amend HEAD, pre-filling the prompts with its message"`
+
+	Type     string `cli:"type=TYPE" help:"type, without the prompt"`
+	Scope    string `cli:"scope=SCOPE" help:"scope, without the prompt"`
+	Message  string `cli:"message,m=DESCRIPTION" help:"description, without the prompt (with --type, no prompts are shown)"`
+	Body     string `cli:"body=BODY" help:"body, without the prompt"`
+	Breaking string `cli:"breaking=DESCRIPTION" help:"BREAKING CHANGE, without the prompt"`
+
+	Like      string `cli:"like=COMMITISH" help:"pre-fill the prompts with the commit"`
+	KeepScope bool   `cli:"keep-scope" help:"keep the scope of --like"`
+
+	Signoff  bool `cli:"signoff,s" help:"add a Signed-off-by trailer like git commit -s (default: gitconfig cx.signoff)"`
+	NoVerify bool `cli:"no-verify,n" help:"bypass the pre-commit and commit-msg hooks like git commit --no-verify"`
+
+	NoStagePrompt bool `cli:"no-stage-prompt" help:"fail with no changes when nothing is staged, instead of asking to stage the changes"`
+
+	Native bool `cli:"native" help:"commit by go-git without git, unless hooks or signing need git (default: gitconfig cx.native-commit)"`
+
+	Verbose bool `cli:"verbose" help:"write warnings as they occur, instead of before the next prompt"`
+
+	Quiet bool `cli:"quiet,q" help:"do not show the staged files before the prompts"`
+
+	RuleFile string `cli:"rule=FILE" help:"rule file (or https URL) to use, instead of searching"`
+
+	Profile bool `cli:"profile" help:"print how long each phase takes to stderr"`
+
+	NoCache bool `cli:"no-cache" help:"do not use the cache of parsed commits in .git/cx-cache"`
+
+	Worktree string `cli:"worktree,C=PATH" help:"run in PATH instead of the current directory, as git -C"`
+
+	LockWait time.Duration `cli:"lock-wait" default:"2s" help:"how long to wait for index.lock held by another git process"`
+
+	Timeout time.Duration `cli:"timeout" default:"0" help:"time limit of each operation that may stall, such as git status and git commit (0: none)"`
+
+	Gen        genCmd        `cli:"generate,gen" help:"generate rule file"`
+	Parse      parseCmd      `cli:"parse" help:"print a commit message as JSON" usage:"git cx parse [MSGFILE] (stdin if omitted)"`
+	Lint       lintCmd       `cli:"lint" help:"validate commit messages against the rule" usage:"git cx lint MSGFILE\ngit cx lint --batch [--json] DIR|FILE..."`
+	RewordLast rewordLastCmd `cli:"reword-last" help:"amend type, scope or description of the last commit without prompts" usage:"git cx reword-last [--type TYPE] [--scope SCOPE|--no-scope] [--desc DESCRIPTION]"`
+	Wip        wipCmd        `cli:"wip" help:"commit the staged files as work in progress without prompts"`
+	Check      checkCmd      `cli:"check" help:"check the commits of the current branch, such as outstanding wip commits and forbidden types" usage:"git cx check [--branch BRANCH] [A..B]"`
+	EditRule   ruleCmd       `cli:"rule" help:"edit the rule file"`
+	Scopes     scopesCmd     `cli:"scopes" help:"list and edit the scope history" usage:"git cx scopes [list|add SCOPE|rm SCOPE|rename OLD NEW|prune]"`
+	Compose    composeCmd    `cli:"compose" help:"ask the components and print the message without committing" usage:"git cx compose [--no-repo] [--config FILE] [TYPE] [DESCRIPTION...]"`
+	Changelog  changelogCmd  `cli:"changelog" help:"write release notes of the commits in Markdown" usage:"git cx changelog [--group-by type|scope] [--order alpha|count] [A..B]"`
+}
 
-	Gen genCmd `cli:"generate,gen" help:"generate rule file"`
+// Before applies the flags for all the subcommands.
+func (c globalCmd) Before() error {
+	warnings.setImmediate(c.Verbose)
+	if c.Worktree != "" {
+		return enterWorktree(c.Worktree)
+	}
+	return nil
 }
 
-func (c globalCmd) Run() error {
-	repos, err := git.PlainOpenWithOptions(".", &git.PlainOpenOptions{DetectDotGit: true})
+func (c globalCmd) Run(args []string) error {
+	ctx, cancel := newRootContext()
+	defer cancel()
+
+	c.profile = newProfiler(c.Profile)
+	defer c.profile.print(os.Stderr)
+
+	return newCommitPipeline().run(&c, &commitFlow{ctx: ctx, args: args})
+}
+
+func (c *globalCmd) openFlowRepository(f *commitFlow) error {
+	done := c.profile.measure("open repository")
+	repos, err := c.openRepository()
+	done()
 	if err != nil {
 		return err
 	}
 	c.repository = repos
 
-	wt, err := repos.Worktree()
+	f.wt, err = repos.Worktree()
+	return err
+}
+
+func (c *globalCmd) prepareFlow(f *commitFlow) error {
+	if err := c.prepare(c.repository); err != nil {
+		return err
+	}
+	if c.readOnly() {
+		warn(tr(msgReadOnly, strings.Join(c.readOnlyPaths, ", ")))
+	} else {
+		f.onEnd(c.commits.save)
+	}
+	return nil
+}
+
+func (c *globalCmd) flowAnswers(f *commitFlow) error {
+	var err error
+	if c.given, err = answersFromArgs(c.rule, f.args); err != nil {
+		return err
+	}
+	return c.answersFromFlags()
+}
+
+// stageAll stages the changes for --all.
+func (c *globalCmd) stageAll(f *commitFlow) error {
+	if c.Debug || c.DryRun || !c.All {
+		return nil
+	}
+
+	st, err := c.worktreeStatus(f.ctx, f.wt)
 	if err != nil {
 		return err
 	}
+	if err := c.waitIndexUnlock(f.ctx); err != nil {
+		return err
+	}
+	ign := newIgnoreMatcher(c.repository, f.wt)
+	return addFiles(f.wt, allStagingPlan(st, detectRenames(c.repository, f.wt, st, ign), ign))
+}
 
-	wd, err := os.Getwd()
+func (c *globalCmd) flowStatus(f *commitFlow) error {
+	done := c.profile.measure("status")
+	st, err := c.worktreeStatus(f.ctx, f.wt)
+	done()
 	if err != nil {
 		return err
 	}
+	f.st = st
+	return nil
+}
 
-	if c.Debug {
-		fmt.Fprintln(os.Stderr, wd)
+// offerPartiallyStaged asks whether to stage the rest of the partially staged files.
+func (c *globalCmd) offerPartiallyStaged(f *commitFlow) error {
+	if !c.rule.WarnPartiallyStaged || c.promptless() {
+		return nil
+	}
+	partial := partiallyStagedFiles(f.st)
+	if len(partial) == 0 {
+		return nil
 	}
 
-	if !c.Debug && c.All {
-		st, err := wt.Status()
-		if err != nil {
+	fmt.Fprintln(os.Stderr, tr(msgPartiallyStaged))
+	for _, file := range partial {
+		fmt.Fprintln(os.Stderr, "  "+file)
+	}
+
+	answer, err := promptInput(prompt.WithPrefix(tr(msgPartiallyStagedAsk)))
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "c", "continue":
+		//nop
+	case "a", "add":
+		if err := c.waitIndexUnlock(f.ctx); err != nil {
 			return err
 		}
-		for f, s := range st {
-			//println(f, s.Worktree, s.Staging)
-			switch s.Worktree {
-			case git.Modified, git.Added, git.Deleted, git.Renamed, git.Copied, git.UpdatedButUnmerged:
-				if _, err := wt.Add(f); err != nil {
-					return fmt.Errorf("try git gc: adding %s: %w", f, err)
-				}
-			default:
-				//nop
-			}
+		if err := addFiles(f.wt, partial); err != nil {
+			return err
+		}
+		if f.st, err = c.worktreeStatus(f.ctx, f.wt); err != nil {
+			return err
 		}
+	default:
+		return withMessage(ErrUserAborted, tr(msgAborted))
+	}
+	return nil
+}
+
+// offerRelatedUntracked asks whether to stage the untracked files next to the staged ones.
+func (c *globalCmd) offerRelatedUntracked(f *commitFlow) error {
+	if !c.rule.offersUntracked() || c.promptless() {
+		return nil
+	}
+	untracked := relatedUntrackedFiles(f.st, newIgnoreMatcher(c.repository, f.wt))
+	if len(untracked) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(os.Stderr, tr(msgRelatedUntracked))
+	for _, file := range untracked {
+		fmt.Fprintln(os.Stderr, "  "+file)
 	}
 
-	st, err := wt.Status()
+	var files []string
+	answer, err := promptInput(prompt.WithPrefix(tr(msgRelatedUntrackedAsk)))
 	if err != nil {
 		return err
 	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		files = untracked
+	case "s", "select":
+		if files, err = pickFiles(untracked); err != nil {
+			return err
+		}
+	default:
+		//nop
+	}
+
+	if len(files) == 0 {
+		return nil
+	}
+	if err := c.waitIndexUnlock(f.ctx); err != nil {
+		return err
+	}
+	if err := addFiles(f.wt, files); err != nil {
+		return err
+	}
+	f.st, err = c.worktreeStatus(f.ctx, f.wt)
+	return err
+}
+
+// offerUnstaged lists the changes in the worktree when nothing is staged, and asks to stage all of them or some.
+func (c *globalCmd) offerUnstaged(f *commitFlow) error {
+	if c.Amend || c.Debug || c.DryRun || c.NoStagePrompt || !c.rule.promptsStaging() || c.promptless() {
+		return nil
+	}
+	if len(stagedFiles(f.st)) > 0 {
+		return nil
+	}
+	files := unstagedFiles(f.st, newIgnoreMatcher(c.repository, f.wt))
+	if len(files) == 0 {
+		return nil
+	}
+
+	labels := make([]string, 0, len(files))
+	for _, file := range files {
+		labels = append(labels, fmt.Sprintf("%c %s", f.st[file].Worktree, file))
+	}
+	fmt.Fprintln(os.Stderr, tr(msgUnstaged))
+	for i, label := range labels {
+		if i == stagedSummaryMaxFiles {
+			fmt.Fprintln(os.Stderr, "  "+tr(msgStagedMore, len(labels)-i))
+			break
+		}
+		fmt.Fprintln(os.Stderr, "  "+label)
+	}
+
+	answer, err := promptInput(prompt.WithPrefix(tr(msgUnstagedAsk)))
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "a", "add":
+		//nop
+	case "i", "select":
+		if files, err = selectFiles(files, labels); err != nil {
+			return err
+		}
+	default:
+		// fails as nothing is staged
+		return nil
+	}
+
+	if len(files) == 0 {
+		return nil
+	}
+	if err := c.waitIndexUnlock(f.ctx); err != nil {
+		return err
+	}
+	if err := addFiles(f.wt, files); err != nil {
+		return err
+	}
+	f.st, err = c.worktreeStatus(f.ctx, f.wt)
+	return err
+}
+
+// checkStaged fails if nothing is staged, unless the commit is not made or amended.
+func (c *globalCmd) checkStaged(f *commitFlow) error {
+	c.status = f.st
+	c.renames = stagedRenames(c.repository, f.st)
 	staged := false
-	for _, s := range st {
+	for _, s := range f.st {
 		staged = staged || (s.Staging != git.Unmodified && s.Staging != git.Untracked)
 	}
 	if !staged {
-		fmt.Fprintln(os.Stderr, "no changes")
-
-		if !c.Debug {
-			return nil
+		if !c.Debug && !c.DryRun && !c.Amend {
+			return withMessage(ErrNothingStaged, tr(msgNoChanges))
 		}
+		if !c.Amend {
+			fmt.Fprintln(os.Stderr, tr(msgNoChanges))
+		}
+	}
+	return nil
+}
+
+// checkFlowSigning asks whether to commit without signing if signing would fail.
+func (c *globalCmd) checkFlowSigning(f *commitFlow) error {
+	if c.Debug || c.DryRun || !c.rule.checksSigning() {
+		return nil
 	}
 
-	if err := c.prepare(repos); err != nil {
+	done := c.profile.measure("signing check")
+	problem := signingProblem(f.ctx, c.repository)
+	done()
+	if problem == "" {
+		return nil
+	}
+	fmt.Fprintln(os.Stderr, problem)
+	if c.promptless() {
+		return nil
+	}
+	answer, err := promptInput(prompt.WithPrefix(tr(msgSigningAsk)))
+	if err != nil {
 		return err
 	}
+	if !in(strings.ToLower(strings.TrimSpace(answer)), "y", "yes") {
+		return withMessage(ErrUserAborted, tr(msgAborted))
+	}
+	f.noGPGSign = true
+	return nil
+}
 
-	msg := c.buildupCommitMessage()
+func (c *globalCmd) flowStagedSummary(f *commitFlow) error {
+	if c.rule.showsStagedSummary() && !c.Quiet && !c.promptless() {
+		c.printStagedSummary(os.Stderr)
+	}
+	return nil
+}
+
+func (c *globalCmd) collectMessage(f *commitFlow) error {
+	done := c.profile.measure("prompts")
+	msg, answered, rendered, err := c.buildupCommitMessage(f.ctx)
+	done()
+	if err != nil {
+		return err
+	}
+	f.msg, f.answered, f.rendered = msg, answered, rendered
+	c.renderedHeader = rendered
+	return nil
+}
 
-	if c.Debug {
-		fmt.Println("----------")
-		fmt.Println(msg)
+// editFlowMessage lets the user edit the message for --edit.
+func (c *globalCmd) editFlowMessage(f *commitFlow) error {
+	if !c.Edit {
 		return nil
 	}
 
-	f, err := os.CreateTemp("", "")
+	edited, err := c.editMessage(f.ctx, f.msg)
 	if err != nil {
 		return err
 	}
-	_, err = f.WriteString(msg)
+	if header, _, _ := strings.Cut(f.msg, "\n"); !strings.HasPrefix(edited, header+"\n") && edited != header {
+		c.renderedHeader = ""
+	}
+	f.msg = edited
+	return nil
+}
+
+// flowSignoff adds the Signed-off-by trailer where git commit does not, before commit-msg sees the message.
+func (c *globalCmd) flowSignoff(f *commitFlow) error {
+	if f.runsHooks && c.signsOff() {
+		f.msg = withSignoff(f.msg, signoffTrailer(c.repository))
+	}
+	return nil
+}
+
+// printFlowDebug prints the message and the summary for --debug, instead of committing.
+func (c *globalCmd) printFlowDebug(f *commitFlow) error {
+	if !c.Debug {
+		return nil
+	}
+
+	c.printDebugSummary(os.Stderr)
+	fmt.Println(f.msg)
+	f.stop()
+	return nil
+}
+
+// printFlowDryRun prints the message for --dry-run, instead of committing, and fails if it violates the rule.
+func (c *globalCmd) printFlowDryRun(f *commitFlow) error {
+	if !c.DryRun {
+		return nil
+	}
+
+	if f.rendered != "" {
+		fmt.Fprintln(os.Stderr, tr(msgRenderedHeader, f.rendered))
+	}
+	fmt.Println(f.msg)
+	if violations := validateMessage(c.rule, f.msg); len(violations) > 0 {
+		for _, v := range violations {
+			fmt.Fprintln(os.Stderr, "  "+v)
+		}
+		return withMessage(ErrInvalidMessage, tr(msgDryRunInvalid, len(violations)))
+	}
+	f.stop()
+	return nil
+}
+
+// commitFlowMessage commits by git, or by go-git if asked and possible.
+func (c *globalCmd) commitFlowMessage(f *commitFlow) error {
+	var commitArgs []string
+	if c.Amend {
+		commitArgs = append(commitArgs, "--amend")
+	}
+	if f.noGPGSign {
+		commitArgs = append(commitArgs, "--no-gpg-sign")
+	}
+	if c.signsOff() {
+		commitArgs = append(commitArgs, "--signoff")
+	}
+	if c.NoVerify {
+		commitArgs = append(commitArgs, "--no-verify")
+	}
+
+	var err error
+	done := c.profile.measure("git commit")
+	if f.native {
+		if err = c.waitIndexUnlock(f.ctx); err == nil {
+			err = c.nativeCommit(f.msg)
+		}
+	} else {
+		err = c.gitCommit(f.ctx, f.msg, commitArgs...)
+	}
+	done()
 	if err != nil {
-		f.Close()
 		return err
 	}
-	f.Close()
+	printCommitted(c.repository)
+	return nil
+}
 
-	cmd := exec.Command("git", "commit", "-F", f.Name())
-	err = cmd.Run()
-	os.Remove(f.Name())
+// persistFlow records the answers in the histories and clears the autosaved body, once committed.
+// Nothing is recorded if the commit is aborted or fails.
+func (c *globalCmd) persistFlow(f *commitFlow) error {
+	c.recordHistories(f.answered)
+	if !c.readOnly() {
+		clearBodyState(c.bodyStateFileName)
+		c.recordScopeCommit(f.answered.scope)
+	}
+	return nil
+}
+
+// normalizeMessage removes a BOM, converts CRLF and CR into LF and ends msg with exactly one newline,
+// since pasted text may have them and git -F commits them verbatim.
+func normalizeMessage(msg string) string {
+	msg = strings.TrimPrefix(msg, "\ufeff")
+	msg = strings.ReplaceAll(msg, "\r\n", "\n")
+	msg = strings.ReplaceAll(msg, "\r", "\n")
+	return strings.TrimRight(msg, "\n") + "\n"
+}
+
+// gitCommit runs git commit with msg and extra args.
+func (c globalCmd) gitCommit(ctx context.Context, msg string, args ...string) error {
+	if err := c.waitIndexUnlock(ctx); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(longPath(os.TempDir()), "")
+	if err != nil {
+		return err
+	}
+	_, err = f.WriteString(normalizeMessage(msg))
 	if err != nil {
+		f.Close()
 		return err
 	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	cmdctx, cmdcancel := c.opContext(ctx)
+	defer cmdcancel()
+	args = append(append([]string{"commit"}, args...), "-F", f.Name())
+	cmd := exec.CommandContext(cmdctx, "git", args...)
+	stderr := bytes.Buffer{}
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	if err == nil {
+		return nil
+	}
+	if ctx.Err() != nil {
+		// git commit is killed, or has got Ctrl+C as well
+		return context.Cause(ctx)
+	}
 
-	return nil
+	var exitErr *exec.ExitError
+	if hooks := commitHooks(c.repository); errors.As(err, &exitErr) && len(hooks) > 0 {
+		return &HookRejectedError{Hook: strings.Join(hooks, ", "), Output: stderr.String()}
+	}
+	if out := strings.TrimSpace(stderr.String()); out != "" {
+		return fmt.Errorf("git commit: %w\n%s", err, out)
+	}
+	return fmt.Errorf("git commit: %w", err)
 }
 
 func (c *globalCmd) prepare(repos *git.Repository) error {
-	c.rule, _ = readRuleFile(repos)
+	var err error
+	done := c.profile.measure("rule load")
+	c.rule, c.rulePath, err = c.readRule(repos)
+	done()
+	if err != nil {
+		return err
+	}
+	if err := checkMinVersion(c.rule, c.rulePath); err != nil {
+		return err
+	}
+	if err := checkExtraTrailers(c.rule, c.rulePath); err != nil {
+		return err
+	}
+	if err := checkEmojiOverrides(c.rule, c.rulePath); err != nil {
+		return err
+	}
+	warnNearDuplicateTypes(c.rule)
+	checkPrompts(c.rule, c.rulePath)
+	checkHeaderFormat(c.rule, c.rulePath)
+	checkMessageFormats(c.rule, c.rulePath)
+
+	c.commits = newCommitLog(repos, c.NoCache)
 
 	// scope history
 
-	c.scopes, c.scopesFileName = readScopesFile(repos)
+	if c.rule.recordsScopes() {
+		done = c.profile.measure("scopes load")
+		c.scopes, c.scopesFileName, err = readScopesFile(repos)
+		done()
+		if err != nil {
+			return err
+		}
+	}
 	if c.scopes == nil {
 		c.scopes = make(Scopes)
 	}
+	c.promptHistories = readPromptHistories(c.scopesFileName, configFileLimit(repos))
+
+	c.bodyStateFileName = bodyStatePath(repos)
+
+	c.descHistoryFileName = statePath(repos, descHistoryFileName)
+	c.descHistory = readDescHistory(c.descHistoryFileName)
+
+	c.readOnlyPaths = unwritablePaths(c.scopesFileName, c.descHistoryFileName, c.bodyStateFileName)
+
+	switch {
+	case c.Amend:
+		cc, err := c.prefillFrom(repos, "HEAD")
+		if err != nil {
+			return err
+		}
+		c.prefill = cc
+	case c.Like != "":
+		cc, err := c.prefillFrom(repos, c.Like)
+		if err != nil {
+			return err
+		}
+		if !c.KeepScope {
+			cc.Scope = ""
+		}
+		c.prefill = cc
+	default:
+		done := c.profile.measure("default scope")
+		c.prefill.Scope = c.defaultScope(repos)
+		done()
+	}
 
 	return nil
 }
@@ -160,8 +656,9 @@ func defaultRule(emoji bool) Rule {
 		DenyEmptyType:     false,
 		DenyAdlibType:     false,
 		UseBreakingChange: false,
-		HeaderFormat:      "{{.type}}{{.scope_with_parens}}{{.bang}}: {{.emoji_unicode}}{{.description}}",
-		HeaderFormatHint:  ".type, .scope, .scope_with_parens, .bang(if BREAKING CHANGE), .emoji, .emoji_unicode, .description",
+		HeaderFormat:      defaultHeaderFormat,
+		HeaderFormatHint:  ".type, .scope, .scope_with_parens, .bang(if BREAKING CHANGE), .emoji(see emojiRendering), .emoji_unicode, .emoji_shortcode, .description, .staged_dirs, .staged_files_count; functions: upper, lower, title, trunc N, default X, trimPrefix X, replace OLD NEW (like {{.description | trunc 50}})",
+		EmojiRendering:    emojiRenderingUnicode,
 	}
 }
 
@@ -222,21 +719,167 @@ func defaultCommitTypes(emoji bool) *orderedmap.OrderedMap[string, CommitType] {
 	return ct
 }
 
-func readRuleFile(repos *git.Repository) (*Rule, string) {
-	var rootDir string
-	if wt, err := repos.Worktree(); err == nil {
-		rootDir = wt.Filesystem.Root()
+// printDebugSummary writes what would be committed, in a stable format apart from the message.
+// The labels are not translated so that the output can be compared.
+func (c globalCmd) printDebugSummary(w io.Writer) {
+	rulePath := c.rulePath
+	if isRuleURL(rulePath) {
+		rulePath += " (" + remoteRuleStatus(rulePath) + ")"
+	} else if s, err := os.Stat(longPath(rulePath)); err != nil || s.IsDir() {
+		rulePath = "(default)"
+	}
+
+	fmt.Fprintln(w, "[debug]")
+	fmt.Fprintf(w, "rule: %s\n", rulePath)
+	scopesFileName := c.scopesFileName
+	if scopesFileName == "" {
+		scopesFileName = "(disabled)"
+	}
+	fmt.Fprintf(w, "scopes: %s\n", scopesFileName)
+	fmt.Fprintf(w, "staged files: %d\n", len(stagedFiles(c.status)))
+	for _, line := range stagedSummary(c.status, c.renames) {
+		fmt.Fprintf(w, "  %s\n", line)
+	}
+	if c.renderedHeader != "" {
+		fmt.Fprintf(w, "rendered header: %s\n", c.renderedHeader)
+	}
+	fmt.Fprintf(w, "flags: all=%v ignore-submodules=%v lang=%s timeout=%v\n", c.All, c.IgnoreSubmodules, currentLang, c.Timeout)
+	fmt.Fprintln(w, "[/debug]")
+}
+
+// nestedRuleFinder returns the finder of the rule file in the directories below the worktree root, nearest first,
+// or nil if there is none. It wins over gitconfig cx.rule, being more specific.
+func nestedRuleFinder(repos *git.Repository) *findcfg.Finder {
+	dirs := nestedRuleDirs(worktreeRoot(repos))
+	if len(dirs) == 0 {
+		return nil
+	}
+
+	opts := []findcfg.FinderOption{findcfg.Name(defaultRuleFileName), findcfg.YAML(), findcfg.JSON()}
+	for _, dir := range dirs {
+		opts = append(opts, findcfg.Dir(dir))
+	}
+	if nested := findcfg.New(opts...); nested.Find() != nil {
+		return nested
+	}
+	return nil
+}
+
+// worktreeRoot returns the root directory of the worktree of repos with symlinks resolved, or "".
+func worktreeRoot(repos *git.Repository) string {
+	if repos == nil {
+		return ""
+	}
+	wt, err := repos.Worktree()
+	if err != nil {
+		return ""
+	}
+	return realPath(wt.Filesystem.Root())
+}
+
+// nestedRuleDirs returns the directories from the current directory up to the worktree root rootDir, excluding the root,
+// or nil if the current directory is not below the root.
+func nestedRuleDirs(rootDir string) []string {
+	if rootDir == "" {
+		return nil
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil
+	}
+	cwd = realPath(cwd)
+
+	rel, err := filepath.Rel(rootDir, cwd)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil
+	}
+
+	var dirs []string
+	for dir := cwd; dir != rootDir; dir = filepath.Dir(dir) {
+		dirs = append(dirs, dir)
+		if filepath.Dir(dir) == dir {
+			return nil
+		}
+	}
+	return dirs
+}
+
+// readRule reads the rule file given by --rule, or else searches it by readRuleFile.
+func (c globalCmd) readRule(repos *git.Repository) (*Rule, string, error) {
+	if c.RuleFile == "" {
+		return readRuleFile(repos)
+	}
+
+	if isRuleURL(c.RuleFile) {
+		r, err := readRemoteRule(c.RuleFile, configFileLimit(repos))
+		if err != nil {
+			return nil, c.RuleFile, &RuleInvalidError{Path: c.RuleFile, Cause: err}
+		}
+		return r, c.RuleFile, nil
+	}
+
+	path, err := filepath.Abs(c.RuleFile)
+	if err != nil {
+		return nil, c.RuleFile, err
+	}
+	r, err := tryReadRuleFile(path, configFileLimit(repos))
+	if err != nil {
+		return nil, path, &RuleInvalidError{Path: path, Cause: err}
+	}
+	if r == nil {
+		return nil, path, fmt.Errorf("%s: %w", path, os.ErrNotExist)
+	}
+	return r, path, nil
+}
+
+// readRuleFile reads the rule file found first, or returns the default rule if none.
+// A broken file is warned about and ignored, but one larger than the limit is an error, since it is likely misconfigured.
+func readRuleFile(repos *git.Repository) (*Rule, string, error) {
+	if rawURL := ruleURL(repos); rawURL != "" {
+		r, err := readRemoteRule(rawURL, configFileLimit(repos))
+		if err == nil {
+			return r, rawURL, nil
+		}
+		warn(tr(msgRuleDefaultWarning, &RuleInvalidError{Path: rawURL, Cause: err}))
+		d := defaultRule(false)
+		return &d, rawURL, nil
+	}
+
+	finder := ruleFinder(repos)
+	found := finder.Find()
+	if found != nil {
+		r, err := tryReadRuleFile(found.Path, configFileLimit(repos))
+		if err == nil {
+			return r, actualCase(found.Path), nil
+		}
+		if errors.As(err, new(*fileTooLargeError)) {
+			return nil, found.Path, &RuleInvalidError{Path: found.Path, Cause: err}
+		}
+		warn(tr(msgRuleDefaultWarning, &RuleInvalidError{Path: found.Path, Cause: err}))
+	}
+
+	r := defaultRule(false)
+	return &r, finder.FallbackPath(), nil
+}
+
+// ruleFinder returns the finder of the rule file: the nearest one below the root of the worktree (see nestedRuleFinder),
+// or else gitconfig cx.rule, the root of the worktree, the user config directory and the executable directory, in this order.
+func ruleFinder(repos *git.Repository) *findcfg.Finder {
+	if nested := nestedRuleFinder(repos); nested != nil {
+		return nested
 	}
 
+	rootDir := worktreeRoot(repos)
+
 	var exactPath string
 	if rootDir != "" {
 		// config
-		if cfg := getGitConfig(repos, configRule); cfg != nil {
+		if cfg := getGitConfig(repos, configRule); cfg != nil && !isRuleURL(*cfg) {
 			exactPath = filepath.Join(rootDir, *cfg)
 		}
 	}
 
-	finder := findcfg.New(
+	return findcfg.New(
 		findcfg.Name(defaultRuleFileName),
 		findcfg.ExactPath(exactPath),
 		findcfg.YAML(),
@@ -245,15 +888,17 @@ func readRuleFile(repos *git.Repository) (*Rule, string) {
 		findcfg.UserConfigDir(userConfigFolder),
 		findcfg.ExecutableDir(),
 	)
-	found := finder.Find()
-	if found != nil {
-		if r, err := tryReadRuleFile(found.Path); err == nil {
-			return r, found.Path
-		}
-	}
+}
 
-	r := defaultRule(false)
-	return &r, finder.FallbackPath()
+// ruleURL returns gitconfig cx.rule if it is a URL, or "". A rule file below the root of the worktree wins over it.
+func ruleURL(repos *git.Repository) string {
+	if repos == nil || nestedRuleFinder(repos) != nil {
+		return ""
+	}
+	if cfg := getGitConfig(repos, configRule); cfg != nil && isRuleURL(*cfg) {
+		return strings.TrimSpace(*cfg)
+	}
+	return ""
 }
 
 func commitTypeAsOM(desc string, emoji string) CommitType {
@@ -263,51 +908,119 @@ func commitTypeAsOM(desc string, emoji string) CommitType {
 	}
 }
 
-func tryReadRuleFile(filename string) (*Rule, error) {
-	if s, err := os.Stat(filename); err != nil || s.IsDir() {
+// tryReadRuleFile reads the rule file filename with the rule files it extends, or returns nil if not found.
+func tryReadRuleFile(filename string, limit int64) (*Rule, error) {
+	content, err := readConfigFile(filename, limit)
+	if err != nil || content == nil {
 		return nil, err
 	}
-
-	file, err := os.Open(filename)
+	r, err := parseRule(filename, content)
 	if err != nil {
 		return nil, err
 	}
-	defer file.Close()
-
-	content, err := io.ReadAll(file)
-	if err != nil {
+	if r, err = extendRule(r, filename, content, limit, []string{absRuleRef(filename)}); err != nil {
 		return nil, err
 	}
+	if err := normalizeRule(r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
 
-	r := Rule{
-		Types: orderedmap.New[string, CommitType](),
+// normalizeRule fills what a rule file may leave out but a message can not be built without,
+// after the rule files it extends are merged.
+//
+// A rule without the types key (or with types: null) has the default types, as if there were no rule file,
+// rather than no types, which would leave only adlib types and none at all with DenyAdlibType.
+// An empty mapping (types: {}) is kept as it is written on purpose.
+// An empty HeaderFormat is defaultHeaderFormat, rather than an empty header.
+// An empty EmojiRendering is shortcode, what .emoji was before the option, while gen writes unicode.
+// An EmojiRendering other than unicode, shortcode or none is an error, since a typo would be taken as shortcode silently.
+func normalizeRule(r *Rule) error {
+	if r.Types == nil {
+		r.Types = defaultCommitTypes(false)
+	}
+	if r.HeaderFormat == "" {
+		r.HeaderFormat = defaultHeaderFormat
+	}
+	switch r.EmojiRendering {
+	case "":
+		r.EmojiRendering = emojiRenderingShortcode
+	case emojiRenderingUnicode, emojiRenderingShortcode, emojiRenderingNone:
+	default:
+		return errors.New(tr(msgInvalidEmojiRendering, r.EmojiRendering))
 	}
+	return nil
+}
 
-	if in(filepath.Ext(filename), ".yaml", ".yml") {
-		if err := yaml.Unmarshal(content, &r); err != nil {
-			return nil, err
+// parseRule parses content of a rule file named filename, in the format of the extension or else of the content.
+func parseRule(filename string, content []byte) (*Rule, error) {
+	return decodeRule(filename, content, Rule{})
+}
+
+// decodeRule decodes content onto base: the options in content overwrite those of base
+// and the others are left as they are, except the types that are of content only.
+func decodeRule(filename string, content []byte, base Rule) (*Rule, error) {
+	// by the extension, only the format; by the content, the other one too
+	formats := []string{formatYAML, formatJSON}
+	if isJSONContent(filename, content) {
+		formats = []string{formatJSON, formatYAML}
+	}
+	if in(fileExt(filename), ".json", ".yaml", ".yml") {
+		formats = formats[:1]
+	}
+
+	ferr := &FormatError{}
+	for _, format := range formats {
+		r := base
+		// nil if content has no types, see normalizeRule
+		r.Types = nil
+		var err error
+		if format == formatJSON {
+			err = withJSONPosition(content, json.Unmarshal(content, &r))
+		} else {
+			err = yaml.Unmarshal(content, &r)
 		}
-		return &r, nil
-	}
-	if in(filepath.Ext(filename), ".json") {
-		if err := json.Unmarshal(content, &r); err != nil {
-			return nil, err
+		if err == nil {
+			// not a syntax error; another format would not help
+			if err := checkTypeBreaking(&r); err != nil {
+				return nil, err
+			}
+			return &r, nil
 		}
-		return &r, nil
+		ferr.Attempts = append(ferr.Attempts, FormatAttempt{Format: format, Err: err})
 	}
-	if err := yaml.Unmarshal(content, &r); err != nil {
-		if err := json.Unmarshal(content, &r); err != nil {
-			return nil, err
+	return nil, ferr
+}
+
+// checkTypeBreaking fails if the breaking of a type is not empty, never, allowed or ask,
+// since a typo would be taken as the default silently.
+func checkTypeBreaking(r *Rule) error {
+	if r.Types == nil {
+		return nil
+	}
+	for _, k := range r.typeNames() {
+		ct, _ := r.Types.Get(k)
+		switch ct.Breaking {
+		case "", breakingNever, breakingAllowed, breakingAsk:
+		default:
+			return errors.New(tr(msgInvalidTypeBreaking, k, ct.Breaking))
 		}
-		return &r, nil
 	}
-	return &r, nil
+	return nil
 }
 
-func readScopesFile(repos *git.Repository) (scopes Scopes, fileName string) {
+// readScopesFile reads the scope history file found first.
+// Like readRuleFile, only a file larger than the limit is an error.
+// fileName is "" if gitconfig cx.scopes is none.
+func readScopesFile(repos *git.Repository) (scopes Scopes, fileName string, err error) {
+	if cfg := getGitConfig(repos, configScopeHistory); cfg != nil && strings.EqualFold(*cfg, scopeHistoryNone) {
+		return nil, "", nil
+	}
+
 	var rootDir string
 	if wt, err := repos.Worktree(); err == nil {
-		rootDir = wt.Filesystem.Root()
+		rootDir = realPath(wt.Filesystem.Root())
 	}
 
 	var exactPath string
@@ -329,50 +1042,35 @@ func readScopesFile(repos *git.Repository) (scopes Scopes, fileName string) {
 	)
 	found := finder.Find()
 	if found != nil {
-		if sc, err := tryReadScopesFile(found.Path); err == nil {
-			return sc, exactPath
+		sc, err := tryReadScopesFile(found.Path, configFileLimit(repos))
+		if err == nil {
+			return sc, actualCase(found.Path), nil
 		}
-		return nil, finder.FallbackPath()
+		if errors.As(err, new(*fileTooLargeError)) {
+			return nil, found.Path, err
+		}
+		return nil, finder.FallbackPath(), nil
 	}
 
-	return nil, finder.FallbackPath()
+	return nil, finder.FallbackPath(), nil
 }
 
-func tryReadScopesFile(filename string) (Scopes, error) {
-	if s, err := os.Stat(filename); err != nil || s.IsDir() {
-		return nil, err
-	}
-
-	file, err := os.Open(filename)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
-
-	content, err := io.ReadAll(file)
-	if err != nil {
+func tryReadScopesFile(filename string, limit int64) (Scopes, error) {
+	content, err := readConfigFile(filename, limit)
+	if err != nil || content == nil {
 		return nil, err
 	}
 
 	sc := make(Scopes)
 
-	if in(filepath.Ext(filename), ".yaml", ".yml") {
-		if err = yaml.Unmarshal(content, &sc); err != nil {
-			return nil, err
-		}
-		return sc, nil
-	}
-	if in(filepath.Ext(filename), ".json") {
+	if isJSONContent(filename, content) {
 		if err = json.Unmarshal(content, &sc); err != nil {
 			return nil, err
 		}
 		return sc, nil
 	}
 	if err = yaml.Unmarshal(content, &sc); err != nil {
-		if err = json.Unmarshal(content, &sc); err != nil {
-			return nil, err
-		}
-		return sc, nil
+		return nil, err
 	}
 	return sc, nil
 }
@@ -401,105 +1099,263 @@ func getGitConfig(repos *git.Repository, key string) *string {
 	return nil
 }
 
-func (c globalCmd) buildupCommitMessage() string {
-	typ := c.promptType()
-	scope := c.promptScope()
-	desc := c.promptDesc()
-	body := c.promptBody()
-	breakingChange := c.promptBreakingChange()
-
-	// write back scope history
-
-	if scope != "" && c.scopesFileName != "" {
-		c.scopes[scope] = time.Now()
-
-		type tmpscope struct {
-			scope string
-			ts    time.Time
-		}
-		sclist := []tmpscope{}
-		for k, v := range c.scopes {
-			sclist = append(sclist, tmpscope{
-				scope: k,
-				ts:    v,
-			})
+// buildupCommitMessage asks the components and returns the message, the answers to record in the histories
+// and the header as GitHub renders it (see renderHeaders) if it differs.
+func (c globalCmd) buildupCommitMessage(ctx context.Context) (msg string, answered messageAnswers, rendered string, err error) {
+	typ, err := c.promptType(ctx)
+	if err != nil {
+		return "", messageAnswers{}, "", err
+	}
+	if branch := currentBranch(c.repository); branch != "" {
+		if f := forbiddenOn(c.rule, branch, ConventionalCommit{Type: typ}, ""); f != "" {
+			warn(tr(msgForbiddenTypeWarning, f, branch))
 		}
-		sort.Slice(sclist, func(i, j int) bool {
-			return sclist[i].ts.After(sclist[j].ts)
-		})
+	}
+	scope, err := c.promptScope(ctx)
+	if err != nil {
+		return "", messageAnswers{}, "", err
+	}
+	desc, err := c.promptDesc(typ, scope)
+	if err != nil {
+		return "", messageAnswers{}, "", err
+	}
+	body, err := c.promptBody(ctx)
+	if err != nil {
+		return "", messageAnswers{}, "", err
+	}
+	body = wrapBody(body, c.rule.bodyWidth())
+	ruleFooters, err := c.promptFooters()
+	if err != nil {
+		return "", messageAnswers{}, "", err
+	}
+	breakingChanges, err := c.promptBreakingChanges(typ)
+	if err != nil {
+		return "", messageAnswers{}, "", err
+	}
+	if c.answers, err = c.promptCustom(); err != nil {
+		return "", messageAnswers{}, "", err
+	}
 
-		outscope := orderedmap.New[string, time.Time]()
-		for _, s := range sclist {
-			outscope.Set(s.scope, s.ts)
-		}
+	desc, err = c.fitHeader(typ, scope, desc, len(breakingChanges) > 0)
+	if err != nil {
+		return "", messageAnswers{}, "", err
+	}
 
-		var content []byte
-		if in(filepath.Ext(c.scopesFileName), ".json") {
-			content, _ = json.MarshalIndent(outscope, "", "  ")
-		} else {
-			content, _ = yaml.Marshal(outscope)
+	header, rendered, formatErr := c.renderHeaders(typ, scope, desc, len(breakingChanges) > 0, stagedFiles(c.status))
+	if formatErr != nil {
+		if err := c.acknowledgeHeaderFallback(formatErr, header, !c.promptless()); err != nil {
+			return "", messageAnswers{}, "", err
 		}
+	}
+	if rendered == header {
+		rendered = ""
+	}
 
-		if file, err := os.Create(c.scopesFileName); err == nil {
-			_, err = file.WriteString(string(content))
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "WARNING: write scopes: %v\n", err)
+	var footers []string
+	for _, bc := range breakingChanges {
+		footers = append(footers, breakingChangeToken+": "+bc)
+	}
+	footers = append(footers, ruleFooters...)
+	if c.Amend {
+		// the footers not asked, such as Signed-off-by
+		for _, f := range c.prefill.Footers {
+			if !f.IsBreakingChange() && !isCxToken(f.Token) && !isRuleFooter(c.rule, f.Token) && !isExtraTrailer(c.rule, f.Token) {
+				footers = append(footers, f.String())
 			}
-			file.Close()
 		}
 	}
+	footers = append(footers, extraTrailers(c.rule)...)
+	if c.rule.MachineTrailers {
+		footers = append(footers, machineTrailers(typ, scope, len(breakingChanges) > 0))
+	}
+
+	data := messageFormatData(c.rule, typ, scope, desc, body, breakingChanges, footers)
+	addPromptAnswers(data, c.rule, c.answers)
+	body = renderSection("bodyFormat", c.rule.BodyFormat, data, body)
+	footer := renderSection("footerFormat", c.rule.FooterFormat, data, strings.Join(footers, "\n"))
+
+	msg = header
+	if body != "" {
+		msg += "\n\n" + body
+	}
+	// footers are separated from the body by a blank line
+	if footer != "" {
+		msg += "\n\n" + footer
+	}
 
-	//---
+	return msg, messageAnswers{typ: typ, scope: scope, desc: desc, custom: c.answers}, rendered, nil
+}
 
-	var header string
-	{
-		emoji := c.emojiOf(typ, false)
-		emojiUnicode := c.emojiOf(typ, true)
+// messageAnswers are the answers of a message, recorded in the histories once it is committed.
+type messageAnswers struct {
+	typ, scope, desc string
+	// custom are the answers of Rule.Prompts
+	custom map[string]string
+}
 
-		var scopeWithParens string
-		if scope != "" {
-			scopeWithParens = "(" + scope + ")"
+// recordHistories writes a into the scope history, the prompt histories and the description history.
+func (c globalCmd) recordHistories(a messageAnswers) {
+	if !c.persists() {
+		return
+	}
+	if c.scopesFileName != "" {
+		changed := c.recordPromptHistories(a.custom, time.Now())
+		if a.scope != "" {
+			entry := ScopeEntry{LastUsed: time.Now()}
+			if c.rule.ScopeFilter == scopeFilterStagedPaths {
+				entry.Paths = stagedDirs(c.scopeFiles(), c.rule.StagedDirsDepth)
+			}
+			c.scopes[a.scope] = entry
+			c.pruneScopeHistory()
+			changed = true
 		}
 
-		var bang string
-		if breakingChange != "" {
-			bang = "!"
+		if changed {
+			if err := writeScopesAndHistories(c.scopesFileName, c.scopes, c.promptHistories, c.rule.ScopeTimestampFormat); err != nil {
+				warn(tr(msgWriteScopesWarning, err))
+			}
 		}
+	}
 
-		templ := template.Must(template.New("").Parse(c.rule.HeaderFormat))
-		buf := bytes.Buffer{}
-		err := templ.Execute(&buf, map[string]string{
-			"type":              typ,
-			"scope":             scope,
-			"scope_with_parens": scopeWithParens,
-			"bang":              bang,
-			"emoji":             emoji,
-			"emoji_unicode":     emojiUnicode,
-			"description":       desc,
+	if a.desc != "" {
+		c.descHistory = addDescHistory(c.descHistory, descEntry{
+			Description: a.desc,
+			Type:        a.typ,
+			Scope:       a.scope,
+			LastUsed:    time.Now(),
 		})
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "%v: %q\n", err, c.rule.HeaderFormat)
-			buf.WriteString(typ)
-			buf.WriteString(scopeWithParens)
-			buf.WriteString(bang)
-			buf.WriteString(": ")
-			buf.WriteString(desc)
+		if err := writeDescHistory(c.descHistoryFileName, c.descHistory); err != nil {
+			warn(tr(msgWriteDescHistoryWarning, err))
 		}
-		header = buf.String()
 	}
-	msg := header
+}
 
-	if body != "" {
-		msg += "\n\n" + body
+// recordScopeCommit writes the hash of HEAD into the history entry of scope.
+// The entry is left as it is if HEAD can not be resolved.
+func (c globalCmd) recordScopeCommit(scope string) {
+	if scope == "" || c.scopesFileName == "" {
+		return
+	}
+	entry, found := c.scopes[scope]
+	if !found {
+		return
+	}
+
+	head, err := c.repository.Head()
+	if err != nil {
+		return
+	}
+	entry.Commit = head.Hash().String()
+	c.scopes[scope] = entry
+
+	if err := writeScopesFile(c.scopesFileName, c.scopes, c.rule.ScopeTimestampFormat); err != nil {
+		warn(tr(msgWriteScopesWarning, err))
+	}
+}
+
+// headerVariables are the variables of HeaderFormat.
+var headerVariables = []string{
+	"type", "scope", "scope_with_parens", "bang", "emoji", "emoji_unicode", "emoji_shortcode",
+	"description", "staged_dirs", "staged_files_count",
+}
+
+// checkHeaderFormat replaces the HeaderFormat of rule by defaultHeaderFormat with a warning
+// if it does not parse or uses an unknown variable, rather than failing at every commit.
+func checkHeaderFormat(rule *Rule, rulePath string) {
+	keys := append(append([]string{}, headerVariables...), rule.promptVariables()...)
+	if err := checkTemplate(rule.HeaderFormat, keys); err != nil {
+		warn(tr(msgHeaderFormatInvalid, rulePath, err, strings.Join(keys, ", ")))
+		rule.HeaderFormat = defaultHeaderFormat
+	}
+}
+
+// renderHeader renders the header by the rule's HeaderFormat, or by defaultHeaderFormat if it fails.
+// staged are the files for .staged_dirs and .staged_files_count.
+func (c globalCmd) renderHeader(typ, scope, desc string, breaking bool, staged []string) string {
+	header, _, _ := c.renderHeaders(typ, scope, desc, breaking, staged)
+	return header
+}
+
+// renderHeaders is renderHeader, also returning the header as GitHub renders it:
+// the emoji variables are in unicode, since GitHub shows shortcodes as emojis.
+// Shortcodes typed by the user are left as they are.
+// If the rule's HeaderFormat fails, the headers are rendered by defaultHeaderFormat
+// and its error is returned, to be acknowledged by acknowledgeHeaderFallback.
+func (c globalCmd) renderHeaders(typ, scope, desc string, breaking bool, staged []string) (header, rendered string, formatErr error) {
+	emoji, emojiUnicode, emojiShortcode, glyph := c.emojiVariables(typ, scope)
+
+	var scopeWithParens string
+	if scope != "" {
+		scopeWithParens = "(" + scope + ")"
+	}
+
+	var bang string
+	if breaking {
+		bang = "!"
+	}
+
+	var stagedCount string
+	if len(staged) > 0 {
+		stagedCount = strconv.Itoa(len(staged))
+	}
+
+	data := map[string]string{
+		"type":               typ,
+		"scope":              scope,
+		"scope_with_parens":  scopeWithParens,
+		"bang":               bang,
+		"emoji":              emoji,
+		"emoji_unicode":      emojiUnicode,
+		"emoji_shortcode":    emojiShortcode,
+		"description":        desc,
+		"staged_dirs":        strings.Join(stagedDirs(staged, c.rule.StagedDirsDepth), ", "),
+		"staged_files_count": stagedCount,
+	}
+	addPromptAnswers(data, c.rule, c.answers)
+	format := c.rule.HeaderFormat
+	header, formatErr = renderTemplate(format, data)
+	if formatErr != nil {
+		format = defaultHeaderFormat
+		header, _ = renderTemplate(format, data)
 	}
-	if breakingChange != "" {
-		msg += "\nBREAKING CHANGE: " + breakingChange
+
+	header = stripEmojiMarker(header)
+
+	data["emoji"] = glyph
+	data["emoji_unicode"] = glyph
+	data["emoji_shortcode"] = glyph
+	rendered, err := renderTemplate(format, data)
+	if err != nil {
+		return header, header, formatErr
 	}
+	return header, stripEmojiMarker(rendered), formatErr
+}
 
-	return msg
+// acknowledgeHeaderFallback tells that the rule's HeaderFormat failed with formatErr and header was rendered
+// by defaultHeaderFormat, and asks whether to go on with it.
+// If not interactive, it fails instead, not to commit a header of another shape silently.
+func (c globalCmd) acknowledgeHeaderFallback(formatErr error, header string, interactive bool) error {
+	fmt.Fprintln(os.Stderr, tr(msgHeaderFormatFailed, c.rulePath, formatErr))
+	fmt.Fprintln(os.Stderr, "  "+header)
+
+	if !interactive {
+		return &RuleInvalidError{Path: c.rulePath, Cause: fmt.Errorf("headerFormat: %w", formatErr)}
+	}
+	ok, err := confirm(tr(msgHeaderFormatFailedAsk), false)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return withMessage(ErrUserAborted, tr(msgAborted))
+	}
+	return nil
 }
 
-func (c globalCmd) promptType() string {
+func (c globalCmd) promptType(ctx context.Context) (string, error) {
+	if c.given.Type != "" {
+		return c.given.Type, nil
+	}
+
 	var typ string
 
 	items := make([]prompt.Suggest, 0, len(c.rule.Types.Keys()))
@@ -514,107 +1370,222 @@ func (c globalCmd) promptType() string {
 			continue
 		}
 
+		// the emoji of the type, since the scope is not known yet
 		item := prompt.Suggest{
 			Text:        k,
-			Description: c.emojiOf(k, true) + " " + typ.Desc,
+			Description: strings.TrimSpace(emoji.Emojize(typ.Emoji)) + " " + typ.Desc,
+		}
+		if len(typ.Aliases) > 0 {
+			item.Description += " (" + strings.Join(typ.Aliases, ", ") + ")"
 		}
 		items = append(items, item)
 	}
 
+	c.sortTypeSuggestions(ctx, items)
+
+	filter := c.rule.completionFilter(completionMatchContains, true)
 	typeCompleter := func(in prompt.Document) ([]prompt.Suggest, pstrings.RuneNumber, pstrings.RuneNumber) {
 		endIndex := in.CurrentRuneIndex()
 		w := in.GetWordBeforeCursor()
 		startIndex := endIndex - pstrings.RuneCountInString(w)
 
-		return prompt.FilterHasPrefix(items, w, true), startIndex, endIndex
+		return filter(items, w), startIndex, endIndex
+	}
+
+	var entries []typeListEntry
+	if c.rule.TypeSelect == typeSelectList {
+		entries = c.typeListEntries(items)
 	}
 
 	for typ == "" {
-		typ = prompt.Input(prompt.WithPrefix("Type: "), prompt.WithCompleter(typeCompleter), prompt.WithShowCompletionAtStart())
+		var err error
+		if entries != nil {
+			typ, err = chooseType(entries, c.prefill.Type)
+		} else {
+			typ, err = promptInput(
+				prompt.WithPrefix(tr(msgPromptType)),
+				prompt.WithInitialText(c.prefill.Type),
+				prompt.WithCompleter(typeCompleter),
+				prompt.WithShowCompletionAtStart(),
+			)
+		}
+		if err != nil {
+			return "", err
+		}
+		typ = c.rule.canonicalType(typ)
 		if typ == "" && c.rule.DenyEmptyType {
-			fmt.Fprintln(os.Stderr, "type is required")
+			fmt.Fprintln(os.Stderr, tr(msgTypeRequired))
 		}
 		if typ != "" && c.rule.DenyAdlibType {
 			_, found := c.rule.Types.Get(typ)
 			if !found {
-				fmt.Fprintln(os.Stderr, "ad-lib type is not allowed")
+				fmt.Fprintln(os.Stderr, tr(msgAdlibTypeNotAllowed))
 				typ = ""
 			}
 		}
 	}
 
-	return typ
+	return typ, nil
 }
 
-func (c globalCmd) promptScope() string {
-	var scope string
+func (c globalCmd) promptScope(ctx context.Context) (string, error) {
+	if c.given.Scope != "" || c.promptless() {
+		return c.given.Scope, nil
+	}
 
 	items := make([]prompt.Suggest, 0, 8)
 
-	for s, t := range c.scopes {
-		item := prompt.Suggest{
-			Text:        s,
-			Description: t.Local().Format(time.RFC3339),
+	listed := make(map[string]bool)
+
+	done := c.profile.measure("co-changed scopes")
+	for _, sc := range c.cochangedScopes(ctx) {
+		items = append(items, prompt.Suggest{Text: sc.Scope, Description: tr(msgCochangedScope, sc.Count)})
+		listed[sc.Scope] = true
+	}
+	done()
+
+	done = c.profile.measure("scope ranking")
+	ranked := c.rankedScopes(ctx)
+	done()
+	for _, s := range ranked {
+		if !listed[s] {
+			items = append(items, prompt.Suggest{Text: s})
+			listed[s] = true
 		}
-		items = append(items, item)
 	}
-	sort.Slice(items, func(i, j int) bool {
-		return items[i].Description > items[j].Description
-	})
-	// timestamps are not shown
-	for i := range items {
-		items[i].Description = ""
+
+	if c.rule.suggestsScopesFromPaths() {
+		for _, s := range scopesFromPaths(c.scopeFiles()) {
+			if !listed[s] {
+				items = append(items, prompt.Suggest{Text: s, Description: tr(msgScopeFromPaths)})
+				listed[s] = true
+			}
+		}
 	}
+	// the descriptions of scopes tell where they come from
+	filter := c.rule.completionFilter(completionMatchPrefix, false)
 	scopeCompleter := func(in prompt.Document) ([]prompt.Suggest, pstrings.RuneNumber, pstrings.RuneNumber) {
 		endIndex := in.CurrentRuneIndex()
 		w := in.GetWordBeforeCursor()
 		startIndex := endIndex - pstrings.RuneCountInString(w)
 
-		return prompt.FilterHasPrefix(items, w, true), startIndex, endIndex
+		return filter(items, w), startIndex, endIndex
 	}
-	scope = prompt.Input(
-		prompt.WithPrefix("Scope: "),
+	return promptInput(
+		prompt.WithPrefix(tr(msgPromptScope)),
+		prompt.WithInitialText(c.prefill.Scope),
 		prompt.WithCompleter(scopeCompleter),
 		prompt.WithShowCompletionAtStart(),
 	)
-
-	return scope
 }
 
-func (c globalCmd) promptDesc() string {
-	var desc string
+// promptDesc asks the description, completing the history of descriptions used with typ and scope first.
+func (c globalCmd) promptDesc(typ, scope string) (string, error) {
+	if c.given.Description != "" {
+		return c.given.Description, nil
+	}
+
+	var items []prompt.Suggest
+	for _, d := range rankDescHistory(c.descHistory, typ, scope) {
+		items = append(items, prompt.Suggest{Text: d})
+	}
+	for _, s := range c.descriptionSnippets() {
+		items = append(items, prompt.Suggest{Text: s, Description: tr(msgSnippet)})
+	}
 
+	var jumper placeholderJumper
+	reader := prompt.NewStdinReader()
+	preview := c.headerPreview(typ, scope, reader)
 	descCompleter := func(in prompt.Document) ([]prompt.Suggest, pstrings.RuneNumber, pstrings.RuneNumber) {
 		endIndex := in.CurrentRuneIndex()
-		w := in.GetWordBeforeCursor()
-		startIndex := endIndex - pstrings.RuneCountInString(w)
+		if preview != nil {
+			preview.update(in)
+		}
+		// Tab jumps to the placeholders of the snippet chosen, instead of completing
+		jumper.jumping.Store(placeholderPattern.MatchString(in.Text))
+		if jumper.jumping.Load() {
+			return nil, endIndex, endIndex
+		}
 
-		return prompt.FilterHasPrefix(nil, w, true), startIndex, endIndex
+		// the whole text, since descriptions are sentences
+		w := in.TextBeforeCursor()
+		if w == "" {
+			return nil, endIndex, endIndex
+		}
+
+		return prompt.FilterHasPrefix(items, w, true), 0, endIndex
 	}
 
-	desc = prompt.Input(prompt.WithPrefix("Description: "), prompt.WithCompleter(descCompleter))
-	desc = strings.TrimSpace(desc)
+	opts := []prompt.Option{prompt.WithPrefix(tr(msgPromptDesc)), prompt.WithInitialText(c.prefill.Description), prompt.WithCompleter(descCompleter)}
+	opts = append(opts, jumper.options(reader)...)
+	if preview != nil {
+		opts = append(opts, preview.options()...)
+	}
+	desc, err := promptInput(opts...)
+	if err != nil {
+		return "", err
+	}
+	desc = strings.TrimSpace(jumper.finish(desc))
 	if desc == "" {
-		fmt.Fprintln(os.Stderr, "description required")
+		fmt.Fprintln(os.Stderr, tr(msgDescRequired))
 	}
 
-	return desc
+	return desc, nil
 }
 
-func (c globalCmd) promptBody() string {
+// promptBody reads the body from stdin until two empty lines.
+// EOF (Ctrl+D) cancels the flow with ErrInterrupted, as the other prompts do.
+func (c globalCmd) promptBody(ctx context.Context) (string, error) {
+	if c.given.Body != "" || c.promptless() {
+		return c.given.Body, nil
+	}
+
 	var body string
 
-	fmt.Println("Body: (Enter 2 empty lines to finish)")
+	if saved := readBodyState(c.bodyStateFileName); strings.TrimSpace(saved) != "" {
+		fmt.Println(tr(msgAutosavedBody))
+		fmt.Println(saved)
+		answer, err := promptInput(prompt.WithPrefix(tr(msgRestoreBody)))
+		if err != nil {
+			return "", err
+		}
+		if in(strings.TrimSpace(answer), "n", "no") {
+			if !c.readOnly() {
+				clearBodyState(c.bodyStateFileName)
+			}
+		} else {
+			body = strings.TrimRight(saved, "\n")
+		}
+	}
+	if body == "" {
+		body = c.prefill.Body
+	}
+
+	autosaveFileName := c.bodyStateFileName
+	if c.readOnly() {
+		autosaveFileName = ""
+	}
+	saver := startAutosave(autosaveFileName, autosaveBodyPeriod)
+	defer saver.Stop()
+	saver.Update(body)
+
+	fmt.Println(tr(msgPromptBody))
+	if body != "" {
+		fmt.Println(body)
+	}
 
 	prevEmpty := false
-	buf := bufio.NewReader(os.Stdin)
 	for {
-		linebyte, _, err := buf.ReadLine()
+		line, err := prompter.ReadLine(ctx)
+		if errors.Is(err, io.EOF) {
+			// the autosaved body is left to be restored
+			return "", errInterrupted()
+		}
 		if err != nil {
-			break
+			return "", err
 		}
 
-		line := strings.TrimSpace(string(linebyte))
+		line = strings.TrimSpace(line)
 
 		if line == "" {
 			if prevEmpty {
@@ -630,9 +1601,10 @@ func (c globalCmd) promptBody() string {
 			body += "\n"
 		}
 		body += line
+		saver.Update(body)
 	}
 
-	return body
+	return body, nil
 }
 
 // copied from github.com/c-bata/go-prompt/filter.go
@@ -657,37 +1629,92 @@ func fuzzyMatch(s, sub string) bool {
 	return true
 }
 
-func (c globalCmd) promptBreakingChange() string {
-	var breakingChange string
+// promptBreakingChanges asks BREAKING CHANGEs until an empty one, since a commit may have several.
+func (c globalCmd) promptBreakingChanges(typ string) ([]string, error) {
+	var breakingChanges []string
+
+	if len(c.given.BreakingChanges) > 0 || c.promptless() {
+		return c.given.BreakingChanges, nil
+	}
+	if !c.rule.askBreakingChange(typ) {
+		return nil, nil
+	}
+
+	bcCompleter := func(in prompt.Document) ([]prompt.Suggest, pstrings.RuneNumber, pstrings.RuneNumber) {
+		endIndex := in.CurrentRuneIndex()
+		w := in.GetWordBeforeCursor()
+		startIndex := endIndex - pstrings.RuneCountInString(w)
+
+		return prompt.FilterHasPrefix(nil, w, true), startIndex, endIndex
+	}
+
+	for {
+		prefix := tr(msgPromptBreaking)
+		if len(breakingChanges) > 0 {
+			prefix = tr(msgPromptBreakingMore)
+		}
 
-	if c.rule.UseBreakingChange {
-		bcCompleter := func(in prompt.Document) ([]prompt.Suggest, pstrings.RuneNumber, pstrings.RuneNumber) {
-			endIndex := in.CurrentRuneIndex()
-			w := in.GetWordBeforeCursor()
-			startIndex := endIndex - pstrings.RuneCountInString(w)
+		var initial string
+		if i := len(breakingChanges); i < len(c.prefill.BreakingChanges) {
+			initial = c.prefill.BreakingChanges[i]
+		}
 
-			return prompt.FilterHasPrefix(nil, w, true), startIndex, endIndex
+		bc, err := promptInput(prompt.WithPrefix(prefix), prompt.WithInitialText(initial), prompt.WithCompleter(bcCompleter))
+		if err != nil {
+			return nil, err
+		}
+		bc = strings.TrimSpace(bc)
+		if bc == "" {
+			break
 		}
-		breakingChange = prompt.Input(prompt.WithPrefix("BREAKING CHANGE: "), prompt.WithCompleter(bcCompleter))
-		breakingChange = strings.TrimSpace(breakingChange)
+		breakingChanges = append(breakingChanges, bc)
 	}
 
-	return breakingChange
+	return breakingChanges, nil
 }
 
-func (c globalCmd) emojiOf(typ string, emojize bool) string {
-	if ct, found := c.rule.Types.Get(typ); found {
-		e := ct.Emoji
-		if emojize {
-			e = strings.TrimSpace(emoji.Emojize(e))
+// askBreakingChange reports whether the BREAKING CHANGE prompt is shown for typ.
+func (r Rule) askBreakingChange(typ string) bool {
+	if ct, found := r.Types.Get(typ); found {
+		switch ct.Breaking {
+		case breakingNever:
+			return false
+		case breakingAsk:
+			return true
 		}
-		return e
 	}
 
-	return ""
+	return r.UseBreakingChange
+}
+
+// completionFilter returns the filter of the completions by Rule.CompletionMatch, or by def if it is not set.
+// The descriptions of the suggestions are searched too if descriptions, except by prefix.
+func (r Rule) completionFilter(def string, descriptions bool) func(suggestions []prompt.Suggest, sub string) []prompt.Suggest {
+	match := r.CompletionMatch
+	if !in(match, completionMatchPrefix, completionMatchContains, completionMatchFuzzy) {
+		match = def
+	}
+
+	switch match {
+	case completionMatchContains:
+		return func(suggestions []prompt.Suggest, sub string) []prompt.Suggest {
+			return filterSuggestions(suggestions, sub, true, descriptions, strings.Contains)
+		}
+	case completionMatchFuzzy:
+		return func(suggestions []prompt.Suggest, sub string) []prompt.Suggest {
+			return filterSuggestions(suggestions, sub, true, descriptions, fuzzyMatch)
+		}
+	default:
+		return func(suggestions []prompt.Suggest, sub string) []prompt.Suggest {
+			return prompt.FilterHasPrefix(suggestions, sub, true)
+		}
+	}
 }
 
-func filterSuggestions(suggestions []prompt.Suggest, sub string, ignoreCase bool, function func(string, string) bool) []prompt.Suggest {
+// filterSuggestions returns suggestions whose Text (or Description if descriptions) matches sub.
+// Texts starting with sub come first, then Texts containing sub, then other Text matches
+// (scattered by fuzzyMatch), then Description matches.
+func filterSuggestions(suggestions []prompt.Suggest, sub string, ignoreCase, descriptions bool, function func(string, string) bool) []prompt.Suggest {
 	if sub == "" {
 		return suggestions
 	}
@@ -695,7 +1722,7 @@ func filterSuggestions(suggestions []prompt.Suggest, sub string, ignoreCase bool
 		sub = strings.ToUpper(sub)
 	}
 
-	ret := make([]prompt.Suggest, 0, len(suggestions))
+	var prefixed, contained, texts, descs []prompt.Suggest
 	for i := range suggestions {
 		c := suggestions[i].Text
 		d := suggestions[i].Description
@@ -703,10 +1730,27 @@ func filterSuggestions(suggestions []prompt.Suggest, sub string, ignoreCase bool
 			c = strings.ToUpper(c)
 			d = strings.ToUpper(d)
 		}
-		if function(c, sub) || function(d, sub) {
-			ret = append(ret, suggestions[i])
+		if !function(c, sub) {
+			if descriptions && function(d, sub) {
+				descs = append(descs, suggestions[i])
+			}
+			continue
+		}
+		switch {
+		case strings.HasPrefix(c, sub):
+			prefixed = append(prefixed, suggestions[i])
+		case strings.Contains(c, sub):
+			contained = append(contained, suggestions[i])
+		default:
+			texts = append(texts, suggestions[i])
 		}
 	}
+
+	ret := make([]prompt.Suggest, 0, len(prefixed)+len(contained)+len(texts)+len(descs))
+	ret = append(ret, prefixed...)
+	ret = append(ret, contained...)
+	ret = append(ret, texts...)
+	ret = append(ret, descs...)
 	return ret
 }
 
@@ -714,16 +1758,24 @@ func filterSuggestions(suggestions []prompt.Suggest, sub string, ignoreCase bool
 var Version string
 
 func main() {
-	rule, scope := getPathToHelp()
+	if err := enterGitPrefix(); err != nil {
+		fmt.Println(err)
+		os.Exit(exitEnvironment)
+	}
+
+	rule, scope, disabled, _ := getPathToHelp()
 	if rule != "" {
 		rule = "\nrule: " + rule + "\n"
 	}
 	if scope != "" {
 		scope = "scope: " + scope + "\n"
 	}
+	if disabled != "" {
+		scope += "disabled by: " + disabled + "\n"
+	}
 
 	app := gli.NewWith(&globalCmd{})
-	app.Name = "git-cx"
+	app.Name = commandName()
 	app.Desc = "A conventional commits tool"
 	app.Version = Version
 	app.Usage = `
@@ -740,25 +1792,51 @@ git cx
 ` + rule + scope + `
 
 # record and complete scope history
-(gitconfig: [cx] scopes=.scopes.yaml)`
+(gitconfig: [cx] scopes=.scopes.yaml)
+
+# lint commit messages
+git cx lint .git/COMMIT_EDITMSG
+git cx lint --batch dir_of_messages`
 	app.Copyright = "(C) 2024 Shuhei Kubota"
 	app.SuppressErrorOutput = true
-	if err := app.Run(os.Args); err != nil {
+	err := app.Run(os.Args)
+	flushWarnings()
+	if err != nil {
 		fmt.Println(err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
 }
 
-func getPathToHelp() (rule string, scope string) {
-	repos, err := git.PlainOpenWithOptions(".", &git.PlainOpenOptions{DetectDotGit: true})
+// getPathToHelp returns the paths of the rule and scopes files and what disables git-cx if any,
+// or the error why they are unknown.
+func getPathToHelp() (rule, scope, disabled string, err error) {
+	repos, err := plainOpen(".")
 	if err != nil {
-		return "", ""
+		return "", "", "", err
+	}
+
+	// the path only, not to warn about a broken file twice
+	finder := ruleFinder(repos)
+	rule = finder.FallbackPath()
+	if found := finder.Find(); found != nil {
+		rule = actualCase(found.Path)
 	}
+	if rawURL := ruleURL(repos); rawURL != "" {
+		rule = rawURL
+	}
+	_, scope, err = readScopesFile(repos)
 
-	_, rule = readRuleFile(repos)
-	_, scope = readScopesFile(repos)
+	disabled = disabledBy(repos, nil, "")
+	if disabled == "" && !isRuleURL(rule) {
+		// the file only, not its base that may be remote; errors are told by the commands
+		if content, _ := readConfigFile(rule, configFileLimit(repos)); content != nil {
+			if r, _ := parseRule(rule, content); r != nil {
+				disabled = disabledBy(nil, r, rule)
+			}
+		}
+	}
 
-	return rule, scope
+	return rule, scope, disabled, err
 }
 
 func in(s string, choices ...string) bool {
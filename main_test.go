@@ -0,0 +1,632 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	prompt "github.com/elk-language/go-prompt"
+	git "github.com/go-git/go-git/v5"
+
+	"github.com/shu-go/git-cx/internal/testutil"
+)
+
+func TestNormalizeRule(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		header  string
+		wantErr bool
+	}{
+		{
+			name:    "no options",
+			content: "{}",
+			header:  "feat: add retry flag",
+		},
+		{
+			name:    "emoji types without emojirendering",
+			content: "types:\n  feat:\n    desc: A new feature\n    emoji: ':sparkles:'\n",
+			header:  "feat: :sparkles:add retry flag",
+		},
+		{
+			name:    "emojirendering unicode",
+			content: "emojirendering: unicode\ntypes:\n  feat:\n    desc: A new feature\n    emoji: ':sparkles:'\n",
+			header:  "feat: ✨add retry flag",
+		},
+		{
+			name:    "emojirendering none",
+			content: "emojirendering: none\ntypes:\n  feat:\n    desc: A new feature\n    emoji: ':sparkles:'\n",
+			header:  "feat: add retry flag",
+		},
+		{
+			name:    "unknown emojirendering",
+			content: "emojirendering: unicod\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := parseRule(".cx.yaml", []byte(tt.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := normalizeRule(rule); (err != nil) != tt.wantErr {
+				t.Fatalf("normalizeRule() = %v, want an error: %v", err, tt.wantErr)
+			} else if err != nil {
+				if want := tr(msgInvalidEmojiRendering, "unicod"); err.Error() != want {
+					t.Errorf("normalizeRule() = %v, want %q", err, want)
+				}
+				return
+			}
+
+			c := globalCmd{rule: rule}
+			if got := c.renderHeader("feat", "", "add retry flag", false, nil); got != tt.header {
+				t.Errorf("header = %q, want %q", got, tt.header)
+			}
+		})
+	}
+}
+
+// A rule file leaving out the options has the header format of a generated rule,
+// but .emoji stays a shortcode as before emojiRendering while a generated rule writes unicode.
+func TestNormalizeRuleIsDefaultRule(t *testing.T) {
+	rule, err := parseRule(".cx.yaml", []byte("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := normalizeRule(rule); err != nil {
+		t.Fatal(err)
+	}
+	def := defaultRule(true)
+	rule.Types = def.Types
+
+	tests := []struct {
+		name string
+		rule *Rule
+		want string
+	}{
+		{name: "omitted", rule: rule, want: "feat(api): :sparkles:add retry flag"},
+		{name: "generated", rule: &def, want: "feat(api): \u2728add retry flag"},
+	}
+	for _, tt := range tests {
+		c := globalCmd{rule: tt.rule}
+		if got := c.renderHeader("feat", "api", "add retry flag", false, nil); got != tt.want {
+			t.Errorf("%s: header = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+	if rule.HeaderFormat != def.HeaderFormat {
+		t.Errorf("normalized = %q, default = %q", rule.HeaderFormat, def.HeaderFormat)
+	}
+	if rule.EmojiRendering != emojiRenderingShortcode || def.EmojiRendering != emojiRenderingUnicode {
+		t.Errorf("emojiRendering normalized = %q, default = %q", rule.EmojiRendering, def.EmojiRendering)
+	}
+}
+
+func TestFilterSuggestions(t *testing.T) {
+	suggestions := []prompt.Suggest{
+		{Text: "feat", Description: "A new feature"},
+		{Text: "fix", Description: "A bug fix"},
+		{Text: "perf", Description: "A code change that improves performance"},
+		{Text: "refactor", Description: "A code change that neither fixes a bug nor adds a feature"},
+	}
+
+	tests := []struct {
+		name         string
+		sub          string
+		descriptions bool
+		want         []string
+	}{
+		{name: "empty", sub: "", want: []string{"feat", "fix", "perf", "refactor"}},
+		{name: "key", sub: "fe", want: []string{"feat"}},
+		{name: "description", sub: "performance", descriptions: true, want: []string{"perf"}},
+		{name: "description not searched", sub: "performance"},
+		{name: "key before description", sub: "fix", descriptions: true, want: []string{"fix", "refactor"}},
+		{name: "prefix before contained", sub: "f", want: []string{"feat", "fix", "perf", "refactor"}},
+		{name: "contained", sub: "act", want: []string{"refactor"}},
+		{name: "ignore case", sub: "PERF", want: []string{"perf"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := filterSuggestions(suggestions, tt.sub, true, tt.descriptions, strings.Contains)
+			if texts := suggestionTexts(got); !reflect.DeepEqual(texts, tt.want) {
+				t.Errorf("filterSuggestions(%q) = %v, want %v", tt.sub, texts, tt.want)
+			}
+		})
+	}
+}
+
+func suggestionTexts(suggestions []prompt.Suggest) []string {
+	var texts []string
+	for _, s := range suggestions {
+		texts = append(texts, s.Text)
+	}
+	return texts
+}
+
+// The rule file is found whether the worktree is reached through a symlink or not.
+func TestReadRuleFileThroughSymlink(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	r := testutil.NewRepo(t)
+	r.WriteFile(".cx.yaml", "headerformat: 'root: {{.description}}'\n")
+	r.WriteFile("sub/.cx.yaml", "headerformat: 'sub: {{.description}}'\n")
+	r.WriteFile("other/a.txt", "")
+	root := realPath(r.Dir)
+
+	link := filepath.Join(t.TempDir(), "link")
+	if err := os.Symlink(r.Dir, link); err != nil {
+		t.Skip(err)
+	}
+
+	tests := []struct {
+		dir  string
+		want string
+	}{
+		{dir: "", want: filepath.Join(root, ".cx.yaml")},
+		{dir: "sub", want: filepath.Join(root, "sub", ".cx.yaml")},
+		{dir: "other", want: filepath.Join(root, ".cx.yaml")},
+	}
+	for _, tt := range tests {
+		for _, base := range []string{r.Dir, link} {
+			t.Run(filepath.Join(filepath.Base(base), tt.dir), func(t *testing.T) {
+				dir := filepath.Join(base, tt.dir)
+				wd, err := os.Getwd()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := os.Chdir(dir); err != nil {
+					t.Fatal(err)
+				}
+				defer os.Chdir(wd)
+
+				repos, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true})
+				if err != nil {
+					t.Fatal(err)
+				}
+				_, path, err := readRuleFile(repos)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if path != tt.want {
+					t.Errorf("rule file = %q, want %q", path, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestNormalizeMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  string
+		want string
+	}{
+		{name: "as is", msg: "feat: add\n", want: "feat: add\n"},
+		{name: "no trailing newline", msg: "feat: add", want: "feat: add\n"},
+		{name: "trailing newlines", msg: "feat: add\n\n\n", want: "feat: add\n"},
+		{name: "BOM", msg: "\ufefffeat: add\n", want: "feat: add\n"},
+		{name: "CRLF", msg: "feat: add\r\n\r\nbody\r\n", want: "feat: add\n\nbody\n"},
+		{name: "CR", msg: "feat: add\r\rbody\r", want: "feat: add\n\nbody\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeMessage(tt.msg); got != tt.want {
+				t.Errorf("normalizeMessage(%q) = %q, want %q", tt.msg, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGitCommitMessageRoundTrip(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not found")
+	}
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	tests := []struct {
+		name string
+		msg  string
+		want string
+	}{
+		{name: "CJK", msg: "feat(画面): 一覧を追加する\n\n本文です。", want: "feat(画面): 一覧を追加する\n\n本文です。\n"},
+		{name: "emoji", msg: "feat: ✨ add 🎉 party", want: "feat: ✨ add 🎉 party\n"},
+		{name: "pasted CRLF and BOM", msg: "\ufefffix: handle nil\r\n\r\nline 1\r\nline 2\r\n", want: "fix: handle nil\n\nline 1\nline 2\n"},
+		{name: "quotes and shell characters", msg: "fix: \"$HOME\" `pwd` 'a' & b > c", want: "fix: \"$HOME\" `pwd` 'a' & b > c\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := testutil.NewRepo(t)
+			r.WriteFile("a.txt", tt.name)
+			r.Stage("a.txt")
+
+			c := globalCmd{repository: r.Repository}
+			r.Chdir()
+			if err := c.gitCommit(context.Background(), tt.msg); err != nil {
+				t.Fatal(err)
+			}
+
+			cmd := exec.Command("git", "log", "-1", "--format=%B")
+			cmd.Dir = r.Dir
+			out, err := cmd.Output()
+			if err != nil {
+				t.Fatal(err)
+			}
+			// %B is followed by a newline
+			if got := strings.TrimSuffix(string(out), "\n"); got != tt.want {
+				t.Errorf("message = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeRuleFormatError(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		content  string
+		formats  []string
+		contains []string
+	}{
+		{
+			name:     "broken JSON by the extension",
+			filename: "rule.json",
+			content:  "{\n  \"types\": {\n    \"feat\": {}\n  },\n}",
+			formats:  []string{formatJSON},
+			contains: []string{"JSON", "line 5, column 1"},
+		},
+		{
+			name:     "broken YAML by the extension",
+			filename: "rule.yaml",
+			content:  "types:\n  feat:\n    desc: [unclosed\n",
+			formats:  []string{formatYAML},
+			contains: []string{"YAML", "line 2"},
+		},
+		{
+			name:     "broken JSON by the content",
+			filename: ".cx",
+			content:  "{\"types\": {\"feat\": {}}",
+			formats:  []string{formatJSON, formatYAML},
+			contains: []string{"JSON", "YAML", "line 1, column 22"},
+		},
+		{
+			name:     "broken YAML by the content",
+			filename: ".cx",
+			content:  "types:\n\tfeat: {}\n",
+			formats:  []string{formatYAML, formatJSON},
+			contains: []string{"YAML", "JSON", "line 2"},
+		},
+		{
+			name:     "wrong type",
+			filename: "rule.json",
+			content:  "{\n  \"denyAdlibType\": \"yes\"\n}",
+			formats:  []string{formatJSON},
+			contains: []string{"line 2", "denyAdlibType"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseRule(tt.filename, []byte(tt.content))
+			var ferr *FormatError
+			if !errors.As(err, &ferr) {
+				t.Fatalf("parseRule() = %v, want a FormatError", err)
+			}
+			var formats []string
+			for _, a := range ferr.Attempts {
+				formats = append(formats, a.Format)
+			}
+			if !reflect.DeepEqual(formats, tt.formats) {
+				t.Errorf("formats = %v, want %v", formats, tt.formats)
+			}
+			for _, s := range tt.contains {
+				if !strings.Contains(err.Error(), s) {
+					t.Errorf("%q does not contain %q", err, s)
+				}
+			}
+		})
+	}
+}
+
+// A broken rule file is warned about with its name, and the default rule is used.
+func TestReadRuleFileWarnsBrokenFile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	buf := &bytes.Buffer{}
+	defer func(w *warningCollector) { warnings = w }(warnings)
+	warnings = &warningCollector{w: buf, immediate: true, seen: make(map[string]bool)}
+
+	r := testutil.NewRepo(t)
+	r.WriteFile(".cx.json", "{\"headerFormat\": }")
+	r.Chdir()
+
+	rule, _, err := readRuleFile(r.Repository)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rule.HeaderFormat != defaultHeaderFormat {
+		t.Errorf("HeaderFormat = %q, want the default", rule.HeaderFormat)
+	}
+	for _, s := range []string{filepath.Join(realPath(r.Dir), ".cx.json"), "JSON", "line 1"} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("warning %q does not contain %q", buf.String(), s)
+		}
+	}
+}
+
+// The rule file of a linked worktree or a submodule is its own, not of the repository it belongs to.
+func TestReadRuleFileOfWorktrees(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	r, sub := testutil.NewRepoWithSubmodule(t, "lib")
+	r.CommitFile(".cx.yaml", "headerformat: 'main: {{.description}}'\n", "chore: add the rule")
+	sub.WriteFile(".cx.yaml", "headerformat: 'lib: {{.description}}'\n")
+	linked := r.NewWorktree("topic")
+	linked.WriteFile(".cx.yaml", "headerformat: 'topic: {{.description}}'\n")
+
+	tests := []struct {
+		name string
+		repo *testutil.Repo
+		want string
+	}{
+		{name: "main", repo: r, want: "main: {{.description}}"},
+		{name: "submodule", repo: sub, want: "lib: {{.description}}"},
+		{name: "linked worktree", repo: linked, want: "topic: {{.description}}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.repo.Chdir()
+			repos, err := plainOpen(".")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rule, path, err := readRuleFile(repos)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rule.HeaderFormat != tt.want {
+				t.Errorf("HeaderFormat = %q, want %q", rule.HeaderFormat, tt.want)
+			}
+			if want := filepath.Join(realPath(tt.repo.Dir), ".cx.yaml"); path != want {
+				t.Errorf("rule file = %q, want %q", path, want)
+			}
+		})
+	}
+}
+
+func TestRenderHeadersFallback(t *testing.T) {
+	tests := []struct {
+		name         string
+		format       string
+		want         string
+		wantRendered string
+		wantErr      bool
+	}{
+		{name: "valid", format: "[{{.type}}] {{.description}}", want: "[feat] add", wantRendered: "[feat] add"},
+		{name: "parse error", format: "{{.type", want: "feat(ui)!: ✨add", wantRendered: "feat(ui)!: ✨add", wantErr: true},
+		{name: "execution error", format: "{{template \"x\"}}", want: "feat(ui)!: ✨add", wantRendered: "feat(ui)!: ✨add", wantErr: true},
+		{name: "call of a string", format: "{{call .type}}", want: "feat(ui)!: ✨add", wantRendered: "feat(ui)!: ✨add", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := defaultRule(true)
+			rule.HeaderFormat = tt.format
+			c := globalCmd{rule: &rule}
+
+			header, rendered, err := c.renderHeaders("feat", "ui", "add", true, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want an error: %v", err, tt.wantErr)
+			}
+			if header != tt.want || rendered != tt.wantRendered {
+				t.Errorf("headers = %q, %q, want %q, %q", header, rendered, tt.want, tt.wantRendered)
+			}
+		})
+	}
+}
+
+// The prompts ask again for an answer the rule does not accept.
+func TestBuildupCommitMessagePrompts(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    func(r *Rule)
+		answers []string
+		want    string
+	}{
+		{
+			name:    "all the components",
+			answers: []string{"feat", "api", "add retry flag", "retries failed requests", "", ""},
+			want:    "feat(api): add retry flag\n\nretries failed requests\n",
+		},
+		{
+			name:    "alias",
+			rule:    func(r *Rule) { r.Types.Set("feat", CommitType{Desc: "A new feature", Aliases: []string{"feature"}}) },
+			answers: []string{"feature", "", "add retry flag", "", ""},
+			want:    "feat: add retry flag",
+		},
+		{
+			name:    "adlib type denied",
+			rule:    func(r *Rule) { r.DenyAdlibType = true },
+			answers: []string{"feet", "feat", "", "add retry flag", "", ""},
+			want:    "feat: add retry flag",
+		},
+		{
+			name:    "empty type denied",
+			rule:    func(r *Rule) { r.DenyEmptyType = true },
+			answers: []string{"", "fix", "", "retry once", "", ""},
+			want:    "fix: retry once",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+			stderr := os.Stderr
+			devnull, err := os.Open(os.DevNull)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer devnull.Close()
+			os.Stderr = devnull
+			defer func() { os.Stderr = stderr }()
+
+			r := testutil.NewRepo(t)
+			r.CommitFile("README.md", "retry\n", "chore: init")
+			rule := defaultRule(false)
+			if tt.rule != nil {
+				tt.rule(&rule)
+			}
+			p := scriptPrompts(t, tt.answers...)
+			c := globalCmd{rule: &rule, repository: r.Repository, scopes: make(Scopes), commits: newCommitLog(r.Repository, true)}
+
+			var msg string
+			captureStdout(t, func() {
+				msg, _, _, err = c.buildupCommitMessage(context.Background())
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if msg != tt.want {
+				t.Errorf("message = %q, want %q", msg, tt.want)
+			}
+			if rest := p.Remaining(); len(rest) != 0 {
+				t.Errorf("answers not asked: %q", rest)
+			}
+		})
+	}
+}
+
+func TestBuildupCommitMessageHeaderFallback(t *testing.T) {
+	rule := defaultRule(false)
+	rule.HeaderFormat = "{{.type"
+	c := globalCmd{rule: &rule, rulePath: ".cx.yaml", given: ConventionalCommit{Type: "feat", Description: "add"}}
+	c.Type, c.Message = "feat", "add"
+
+	stderr := os.Stderr
+	devnull, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devnull.Close()
+	os.Stderr = devnull
+	defer func() { os.Stderr = stderr }()
+
+	_, _, _, err = c.buildupCommitMessage(context.Background())
+	var rerr *RuleInvalidError
+	if !errors.As(err, &rerr) || rerr.Path != ".cx.yaml" {
+		t.Errorf("error = %v, want the rule file to be invalid, not to commit by the default format silently", err)
+	}
+}
+
+func TestCheckHeaderFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		valid  bool
+	}{
+		{name: "default", format: defaultHeaderFormat, valid: true},
+		{name: "functions", format: "{{.type | upper}}{{.scope_with_parens}}: {{.description | trunc 50}}", valid: true},
+		{name: "staged", format: "{{.type}}: {{.description}} ({{.staged_files_count}} in {{.staged_dirs}})", valid: true},
+		{name: "unknown variable", format: "{{.type}}: {{.summary}}"},
+		{name: "parse error", format: "{{.type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			defer func(w *warningCollector) { warnings = w }(warnings)
+			warnings = &warningCollector{w: buf, immediate: true, seen: make(map[string]bool)}
+
+			rule := defaultRule(false)
+			rule.HeaderFormat = tt.format
+			checkHeaderFormat(&rule, ".cx.yaml")
+
+			if tt.valid {
+				if rule.HeaderFormat != tt.format || buf.Len() != 0 {
+					t.Errorf("headerFormat = %q, warnings %q, want it as it is", rule.HeaderFormat, buf.String())
+				}
+				return
+			}
+			if rule.HeaderFormat != defaultHeaderFormat || !strings.Contains(buf.String(), ".cx.yaml") {
+				t.Errorf("headerFormat = %q, warnings %q, want the default with a warning", rule.HeaderFormat, buf.String())
+			}
+		})
+	}
+}
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		s, sub string
+		want   bool
+	}{
+		{s: "refactor", sub: "", want: true},
+		{s: "refactor", sub: "rf", want: true},
+		{s: "refactor", sub: "ft", want: true},
+		{s: "refactor", sub: "tf", want: false},
+		{s: "feat", sub: "feat", want: true},
+		{s: "feat", sub: "feats", want: false},
+		{s: "ドキュメント", sub: "ドメ", want: true},
+	}
+	for _, tt := range tests {
+		if got := fuzzyMatch(tt.s, tt.sub); got != tt.want {
+			t.Errorf("fuzzyMatch(%q, %q) = %v, want %v", tt.s, tt.sub, got, tt.want)
+		}
+	}
+}
+
+func TestCompletionFilter(t *testing.T) {
+	suggestions := []prompt.Suggest{
+		{Text: "feat", Description: "A new feature"},
+		{Text: "fix", Description: "A bug fix"},
+		{Text: "refactor", Description: "A code change"},
+		{Text: "perf", Description: "A code change that improves performance"},
+		{Text: "ci", Description: "CI configuration"},
+	}
+
+	tests := []struct {
+		name         string
+		match        string
+		def          string
+		descriptions bool
+		sub          string
+		want         []string
+	}{
+		{name: "fuzzy", match: completionMatchFuzzy, def: completionMatchPrefix, sub: "ft", want: []string{"feat", "refactor"}},
+		{name: "fuzzy, containing first", match: completionMatchFuzzy, def: completionMatchPrefix, sub: "fa", want: []string{"refactor", "feat"}},
+		{name: "fuzzy descriptions", match: completionMatchFuzzy, def: completionMatchPrefix, descriptions: true, sub: "bug", want: []string{"fix"}},
+		{name: "fuzzy without descriptions", match: completionMatchFuzzy, def: completionMatchPrefix, sub: "bug"},
+		{name: "contains", match: completionMatchContains, def: completionMatchPrefix, sub: "F", want: []string{"feat", "fix", "refactor", "perf"}},
+		{name: "prefix ignores descriptions", match: completionMatchPrefix, def: completionMatchContains, descriptions: true, sub: "bug"},
+		{name: "default", def: completionMatchPrefix, sub: "FE", want: []string{"feat"}},
+		{name: "unknown is default", match: "bogus", def: completionMatchContains, sub: "fac", want: []string{"refactor"}},
+		{name: "empty", match: completionMatchFuzzy, def: completionMatchPrefix, sub: "", want: []string{"feat", "fix", "refactor", "perf", "ci"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := Rule{CompletionMatch: tt.match}
+			got := suggestionTexts(rule.completionFilter(tt.def, tt.descriptions)(suggestions, tt.sub))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("completions of %q = %v, want %v", tt.sub, got, tt.want)
+			}
+		})
+	}
+}
+
+// scriptPrompts replaces the prompter by answers until the test ends.
+func scriptPrompts(t *testing.T, answers ...string) *testutil.Prompter {
+	t.Helper()
+
+	p := testutil.NewPrompter(t, answers...)
+	saved := prompter
+	prompter = p
+	t.Cleanup(func() { prompter = saved })
+	return p
+}
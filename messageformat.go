@@ -0,0 +1,98 @@
+package main
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// BodyFormat and FooterFormat render the body and the footers of the message, like HeaderFormat does the header.
+// Without them, the body is the answer as it is and the footers are the lines of the footers, as before.
+
+// messageVariables are the variables of BodyFormat and FooterFormat, besides the footers of Rule.Footers
+// and the answers of Rule.Prompts.
+var messageVariables = []string{"type", "scope", "description", "body", "breaking_change", "footers"}
+
+// footerVariable returns the variable of a footer of Rule.Footers, like refs for Refs and reviewed_by for Reviewed-by.
+func footerVariable(key string) string {
+	return strings.ReplaceAll(strings.ToLower(key), "-", "_")
+}
+
+// messageFormatKeys returns the variables of BodyFormat and FooterFormat of rule.
+func messageFormatKeys(rule *Rule) []string {
+	keys := append([]string{}, messageVariables...)
+	for _, def := range rule.Footers {
+		if def.Key != "" {
+			keys = append(keys, footerVariable(def.Key))
+		}
+	}
+	keys = append(keys, rule.promptVariables()...)
+	sort.Strings(keys)
+	return keys
+}
+
+// checkMessageFormats drops BodyFormat and FooterFormat of rule with a warning if they do not parse
+// or use an unknown variable, so that the body and the footers are written as without them.
+func checkMessageFormats(rule *Rule, rulePath string) {
+	keys := messageFormatKeys(rule)
+	if err := checkTemplate(rule.BodyFormat, keys); err != nil {
+		warn(tr(msgMessageFormatInvalid, "bodyFormat", rulePath, err, strings.Join(keys, ", ")))
+		rule.BodyFormat = ""
+	}
+	if err := checkTemplate(rule.FooterFormat, keys); err != nil {
+		warn(tr(msgMessageFormatInvalid, "footerFormat", rulePath, err, strings.Join(keys, ", ")))
+		rule.FooterFormat = ""
+	}
+}
+
+// messageFormatData returns the variables of BodyFormat and FooterFormat.
+// footers are the lines of the footers; those of Rule.Footers are also variables by their keys.
+func messageFormatData(rule *Rule, typ, scope, desc, body string, breakingChanges, footers []string) map[string]string {
+	data := map[string]string{
+		"type":            typ,
+		"scope":           scope,
+		"description":     desc,
+		"body":            body,
+		"breaking_change": strings.Join(breakingChanges, "\n"),
+		"footers":         strings.Join(footers, "\n"),
+	}
+	for _, def := range rule.Footers {
+		if def.Key != "" {
+			data[footerVariable(def.Key)] = ""
+		}
+	}
+	for _, f := range footers {
+		token, value, found := strings.Cut(f, ": ")
+		if !found || !isRuleFooter(rule, token) {
+			continue
+		}
+		data[footerVariable(token)] = value
+	}
+	return data
+}
+
+// renderSection renders a section of the message by format, or returns def if format is empty or fails.
+func renderSection(name, format string, data map[string]string, def string) string {
+	if format == "" {
+		return def
+	}
+	section, err := renderTemplate(format, data)
+	if err != nil {
+		warn(tr(msgMessageFormatFailed, name, err))
+		return def
+	}
+	return tidySection(section)
+}
+
+var blankLinesPattern = regexp.MustCompile(`\n{3,}`)
+
+// tidySection removes what empty variables leave in a rendered section:
+// trailing spaces, blank lines at both ends, and more than one blank line in a row.
+func tidySection(s string) string {
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimRight(l, " \t")
+	}
+	s = blankLinesPattern.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return strings.Trim(s, "\n")
+}
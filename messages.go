@@ -0,0 +1,568 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	git "github.com/go-git/go-git/v5"
+)
+
+// User-facing messages are looked up by tr so that they can be translated.
+
+const (
+	langEnglish  = "en"
+	langJapanese = "ja"
+
+	configLang = "lang"
+)
+
+const (
+	msgNoChanges               = "no_changes"
+	msgTypeRequired            = "type_required"
+	msgAdlibTypeNotAllowed     = "adlib_type_not_allowed"
+	msgDescRequired            = "desc_required"
+	msgPromptType              = "prompt_type"
+	msgPromptScope             = "prompt_scope"
+	msgPromptDesc              = "prompt_desc"
+	msgPromptBody              = "prompt_body"
+	msgPromptBreaking          = "prompt_breaking"
+	msgAutosavedBody           = "autosaved_body"
+	msgRestoreBody             = "restore_body"
+	msgPartiallyStaged         = "partially_staged"
+	msgPartiallyStagedAsk      = "partially_staged_ask"
+	msgAborted                 = "aborted"
+	msgWriteScopesWarning      = "write_scopes_warning"
+	msgDevBuildMinVersion      = "dev_build_min_version"
+	msgUpgradeRequired         = "upgrade_required"
+	msgLintFilesRequired       = "lint_files_required"
+	msgLintUseBatch            = "lint_use_batch"
+	msgLintSummary             = "lint_summary"
+	msgLintFailed              = "lint_failed"
+	msgLintEmptyMessage        = "lint_empty_message"
+	msgLintInvalidHeader       = "lint_invalid_header"
+	msgLintUndefinedType       = "lint_undefined_type"
+	msgLintEmptyDesc           = "lint_empty_desc"
+	msgLintBreakingDenied      = "lint_breaking_denied"
+	msgRewordNothing           = "reword_nothing"
+	msgRewordNotConventional   = "reword_not_conventional"
+	msgWipProtectedBranch      = "wip_protected_branch"
+	msgCheckWipCommits         = "check_wip_commits"
+	msgCheckForbidden          = "check_forbidden"
+	msgCheckFailed             = "check_failed"
+	msgCheckInvalidRange       = "check_invalid_range"
+	msgForbiddenTypeWarning    = "forbidden_type_warning"
+	msgRuleArgs                = "rule_args"
+	msgRuleTypeExists          = "rule_type_exists"
+	msgRuleTypeNotFound        = "rule_type_not_found"
+	msgRuleUnknownField        = "rule_unknown_field"
+	msgRuleCreate              = "rule_create"
+	msgRuleUnchanged           = "rule_unchanged"
+	msgRuleConfirm             = "rule_confirm"
+	msgIndexLocked             = "index_locked"
+	msgWriteDescHistoryWarning = "write_desc_history_warning"
+	msgFileTooLarge            = "file_too_large"
+	msgLintTypeCase            = "lint_type_case"
+	msgLintTrailingPeriod      = "lint_trailing_period"
+	msgLintHeaderSpace         = "lint_header_space"
+	msgLintFooterBlankLine     = "lint_footer_blank_line"
+	msgLintBreakingSpelling    = "lint_breaking_spelling"
+	msgLintFixed               = "lint_fixed"
+	msgPromptBreakingMore      = "prompt_breaking_more"
+	msgNotRepository           = "not_repository"
+	msgScopeLastUsedIn         = "scope_last_used_in"
+	msgScopeLastUsed           = "scope_last_used"
+	msgAgoJustNow              = "ago_just_now"
+	msgAgoMinutes              = "ago_minutes"
+	msgAgoHours                = "ago_hours"
+	msgAgoDays                 = "ago_days"
+	msgUnknownValue            = "unknown_value"
+	msgReadOnly                = "read_only"
+	msgRuleInvalid             = "rule_invalid"
+	msgHookRejected            = "hook_rejected"
+	msgRelatedUntracked        = "related_untracked"
+	msgRelatedUntrackedAsk     = "related_untracked_ask"
+	msgPickFiles               = "pick_files"
+	msgUnknownTypeArg          = "unknown_type_arg"
+	msgDryRunInvalid           = "dry_run_invalid"
+	msgRenderedHeader          = "rendered_header"
+	msgEditHelp                = "edit_help"
+	msgEditStaged              = "edit_staged"
+	msgEditEmpty               = "edit_empty"
+	msgCochangedScope          = "cochanged_scope"
+	msgLintFixNeedsFiles       = "lint_fix_needs_files"
+	msgHeaderTooLong           = "header_too_long"
+	msgHeaderTooLongAsk        = "header_too_long_ask"
+	msgSigningNoKey            = "signing_no_key"
+	msgSigningKeyMissing       = "signing_key_missing"
+	msgSigningAsk              = "signing_ask"
+	msgFooterRequired          = "footer_required"
+	msgTypeOrderUnknown        = "type_order_unknown"
+	msgVersionHint             = "version_hint"
+	msgScopeFromPaths          = "scope_from_paths"
+	msgFormatAttempt           = "format_attempt"
+	msgRuleDefaultWarning      = "rule_default_warning"
+	msgScopePruneWarning       = "scope_prune_warning"
+	msgScopeHistoryDisabled    = "scope_history_disabled"
+	msgScopePruneNothing       = "scope_prune_nothing"
+	msgScopePruned             = "scope_pruned"
+	msgScopeNotFound           = "scope_not_found"
+	msgInvalidTrailerToken     = "invalid_trailer_token"
+	msgTrailerWarning          = "trailer_warning"
+	msgRuleURLNotHTTPS         = "rule_url_not_https"
+	msgRuleURLCached           = "rule_url_cached"
+	msgRuleURLReadOnly         = "rule_url_read_only"
+	msgStagedHeader            = "staged_header"
+	msgStagedModified          = "staged_modified"
+	msgStagedAdded             = "staged_added"
+	msgStagedDeleted           = "staged_deleted"
+	msgStagedRenamed           = "staged_renamed"
+	msgStagedOther             = "staged_other"
+	msgStagedMore              = "staged_more"
+	msgWarning                 = "warning"
+	msgInterrupted             = "interrupted"
+	msgInvalidEmojiShortcode   = "invalid_emoji_shortcode"
+	msgFunFacts                = "fun_facts"
+	msgPromptTypeList          = "prompt_type_list"
+	msgPromptTypeListPaged     = "prompt_type_list_paged"
+	msgTypeNumberOutOfRange    = "type_number_out_of_range"
+	msgHeaderFormatFailed      = "header_format_failed"
+	msgHeaderFormatFailedAsk   = "header_format_failed_ask"
+	msgExtendsCycle            = "extends_cycle"
+	msgRuleExtendsEdit         = "rule_extends_edit"
+	msgNativeFallback          = "native_fallback"
+	msgNativeHooks             = "native_hooks"
+	msgNativeSigning           = "native_signing"
+	msgNativeAmend             = "native_amend"
+	msgNativeNoIdentity        = "native_no_identity"
+	msgCommitted               = "committed"
+	msgTypeNearDuplicates      = "type_near_duplicates"
+	msgNoNearDuplicates        = "no_near_duplicates"
+	msgDedupeGroup             = "dedupe_group"
+	msgDedupeAsk               = "dedupe_ask"
+	msgDedupeInvalid           = "dedupe_invalid"
+	msgHeaderFormatInvalid     = "header_format_invalid"
+	msgMessageFormatInvalid    = "message_format_invalid"
+	msgMessageFormatFailed     = "message_format_failed"
+	msgSnippet                 = "snippet"
+	msgSnippetsFileWarning     = "snippets_file_warning"
+	msgSnippetsFileRelative    = "snippets_file_relative"
+	msgPromptNameInvalid       = "prompt_name_invalid"
+	msgPromptRequired          = "prompt_required"
+	msgDiffFailed              = "diff_failed"
+	msgDiffHint                = "diff_hint"
+	msgGenOverwrite            = "gen_overwrite"
+	msgGenAskEmoji             = "gen_ask_emoji"
+	msgGenAskDenyAdlib         = "gen_ask_deny_adlib"
+	msgGenAskBreaking          = "gen_ask_breaking"
+	msgGenAskMaxHeader         = "gen_ask_max_header"
+	msgGenInvalidLength        = "gen_invalid_length"
+	msgDisabled                = "disabled"
+	msgGenOutsideWorktree      = "gen_outside_worktree"
+	msgGenConfigSet            = "gen_config_set"
+	msgGenConfigReplaced       = "gen_config_replaced"
+	msgGenConfigKept           = "gen_config_kept"
+	msgGenConfigFile           = "gen_config_file"
+	msgGenNoHooksDir           = "gen_no_hooks_dir"
+	msgGenHookExists           = "gen_hook_exists"
+	msgGenHookInstalled        = "gen_hook_installed"
+	msgGenKeepRule             = "gen_keep_rule"
+	msgHeaderPreview           = "header_preview"
+	msgWorktreeNotFound        = "worktree_not_found"
+	msgWorktreeNotRepository   = "worktree_not_repository"
+	msgScopeBackupNoDir        = "scope_backup_no_dir"
+	msgScopeNoBackup           = "scope_no_backup"
+	msgScopeUndoModified       = "scope_undo_modified"
+	msgScopeUndoAsk            = "scope_undo_ask"
+	msgScopeUndone             = "scope_undone"
+	msgScopeClearAsk           = "scope_clear_ask"
+	msgUnstaged                = "unstaged"
+	msgUnstagedAsk             = "unstaged_ask"
+	msgSelectFiles             = "select_files"
+	msgInvalidTypeBreaking     = "invalid_type_breaking"
+	msgInvalidEmojiRendering   = "invalid_emoji_rendering"
+)
+
+var catalog = map[string]map[string]string{
+	langEnglish: {
+		msgNoChanges:               "no changes",
+		msgTypeRequired:            "type is required",
+		msgAdlibTypeNotAllowed:     "ad-lib type is not allowed",
+		msgDescRequired:            "description required",
+		msgPromptType:              "Type: ",
+		msgPromptScope:             "Scope: ",
+		msgPromptDesc:              "Description: ",
+		msgPromptBody:              "Body: (a blank line between paragraphs, 2 blank lines or Ctrl+D to finish)",
+		msgPromptBreaking:          "BREAKING CHANGE: ",
+		msgAutosavedBody:           "Autosaved body:",
+		msgRestoreBody:             "Restore it? [Y/n]: ",
+		msgPartiallyStaged:         "these files have unstaged changes on top of staged ones:",
+		msgPartiallyStagedAsk:      "[c]ontinue, [a]dd them too, a[b]ort: ",
+		msgAborted:                 "aborted",
+		msgWriteScopesWarning:      "write scopes: %v",
+		msgDevBuildMinVersion:      "development build; the rule requires git-cx %s or later",
+		msgUpgradeRequired:         "%s requires git-cx %s or later (this is %s); please upgrade git-cx",
+		msgLintFilesRequired:       "message files are required",
+		msgLintUseBatch:            "use --batch to lint multiple files",
+		msgLintSummary:             "%d files, %d failed",
+		msgLintFailed:              "%d of %d messages failed",
+		msgLintEmptyMessage:        "empty message",
+		msgLintInvalidHeader:       "header %q is not `type(scope)!: description`",
+		msgLintUndefinedType:       "type %q is not defined in the rule",
+		msgLintEmptyDesc:           "description is empty",
+		msgLintBreakingDenied:      "breaking change is not allowed for type %q",
+		msgRewordNothing:           "specify --type, --scope, --no-scope or --desc",
+		msgRewordNotConventional:   "the header of the last commit is not `type(scope)!: description`; specify --type",
+		msgWipProtectedBranch:      "refusing to commit work in progress on the protected branch %s",
+		msgCheckWipCommits:         "%d wip commits on this branch; squash them before merging",
+		msgCheckForbidden:          "%s is forbidden on %s; rebase with --autosquash before merging",
+		msgCheckFailed:             "%d commits are forbidden on the branch",
+		msgCheckInvalidRange:       "invalid range %q; use A..B",
+		msgForbiddenTypeWarning:    "%s is forbidden on %s; rebase with --autosquash before merging",
+		msgRuleArgs:                "arguments required: %s",
+		msgRuleTypeExists:          "type %q already exists",
+		msgRuleTypeNotFound:        "type %q is not found",
+		msgRuleUnknownField:        "unknown field %q",
+		msgRuleCreate:              "No rule file. Create %s from the default? [Y/n]: ",
+		msgRuleUnchanged:           "no changes to the rule file",
+		msgRuleConfirm:             "Write these changes to %s? [y/N]: ",
+		msgIndexLocked:             "%s exists: another git process, most likely an IDE or editor integration, is using the index. Try again when it finishes, or remove the file if no git process is running",
+		msgWriteDescHistoryWarning: "write description history: %v",
+		msgFileTooLarge:            "%s is too large (%d bytes, the limit is %d bytes; see gitconfig cx.maxFileSize)",
+		msgLintTypeCase:            "type %q should be lowercase %q",
+		msgLintTrailingPeriod:      "description ends with a period",
+		msgLintHeaderSpace:         "no space after the colon of the header",
+		msgLintFooterBlankLine:     "footers must be preceded by a blank line",
+		msgLintBreakingSpelling:    "BREAKING-CHANGE should be spelled BREAKING CHANGE",
+		msgLintFixed:               "fixed: %s",
+		msgPromptBreakingMore:      "BREAKING CHANGE (empty to finish): ",
+		msgNotRepository:           "not a git repository (searched from %s upward); run git cx inside a repository, or git init first",
+		msgScopeLastUsedIn:         "%s — last used in %s (%s)",
+		msgScopeLastUsed:           "%s — last used %s",
+		msgAgoJustNow:              "just now",
+		msgAgoMinutes:              "%d minutes ago",
+		msgAgoHours:                "%d hours ago",
+		msgAgoDays:                 "%d days ago",
+		msgUnknownValue:            "unknown %s '%s', valid: %s",
+		msgReadOnly:                "read-only mode: %s not writable; the scope and description history and the body autosave are skipped",
+		msgRuleInvalid:             "invalid rule file %s: %v",
+		msgHookRejected:            "git commit was rejected; check the output of the hook (%s)",
+		msgRelatedUntracked:        "these untracked files are next to the staged ones:",
+		msgRelatedUntrackedAsk:     "Also stage these untracked files? [y/N/select]: ",
+		msgPickFiles:               "Numbers to stage (like 1 3 or 2-4, empty for none): ",
+		msgUnknownTypeArg:          "type %q is not defined in the rule, and ad-lib types are denied",
+		msgDryRunInvalid:           "the message violates the rule (%d violations)",
+		msgRenderedHeader:          "(rendered on GitHub: %s)",
+		msgEditHelp:                "Please enter the commit message for your changes. Lines starting\nwith '#' will be ignored, and an empty message aborts the commit.",
+		msgEditStaged:              "Changes to be committed:",
+		msgEditEmpty:               "aborting commit due to empty commit message",
+		msgCochangedScope:          "used for these files in %d recent commits",
+		msgLintFixNeedsFiles:       "--fix rewrites message files only, not stdin or commits",
+		msgHeaderTooLong:           "the header is %d characters, longer than the limit %d",
+		msgHeaderTooLongAsk:        "Commit anyway? (y: yes, otherwise edit the description): ",
+		msgSigningNoKey:            "commit.gpgsign is true, but no signing key is found (user.signingkey)",
+		msgSigningKeyMissing:       "commit.gpgsign is true, but the signing key %s is not available",
+		msgSigningAsk:              "Commit without signing? (y: --no-gpg-sign, otherwise abort): ",
+		msgFooterRequired:          "footer %s is required",
+		msgTypeOrderUnknown:        "typeorder has types not defined in the rule: %v",
+		msgVersionHint:             "this commit will trigger a %s version bump (current: %s → next: %s)",
+		msgScopeFromPaths:          "(from staged files)",
+		msgFormatAttempt:           "as %s, %v",
+		msgRuleDefaultWarning:      "%v; the default rule is used",
+		msgScopePruneWarning:       "scope history is not pruned: %v",
+		msgScopeHistoryDisabled:    "the scope history is disabled",
+		msgScopePruneNothing:       "nothing to prune",
+		msgScopePruned:             "removed: %s",
+		msgScopeNotFound:           "scope %s is not in the history",
+		msgInvalidTrailerToken:     "%q is not a trailer token (letters, digits and hyphens, or BREAKING CHANGE)",
+		msgTrailerWarning:          "trailer %s is left out: %v",
+		msgRuleURLNotHTTPS:         "only https is allowed for a rule URL, not %s",
+		msgRuleURLCached:           "%s can not be fetched, the copy cached %s is used: %v",
+		msgRuleURLReadOnly:         "%s is a URL and can not be edited",
+		msgStagedHeader:            "staged: %s",
+		msgStagedModified:          "%d modified",
+		msgStagedAdded:             "%d added",
+		msgStagedDeleted:           "%d deleted",
+		msgStagedRenamed:           "%d renamed",
+		msgStagedOther:             "%d other",
+		msgStagedMore:              "...and %d more",
+		msgWarning:                 "cx: warning: %s",
+		msgInterrupted:             "interrupted; nothing is committed",
+		msgInvalidEmojiShortcode:   "%q is not a known emoji shortcode",
+		msgFunFacts:                "🎉 %s commit today, %d this week on this repo",
+		msgPromptTypeList:          "Type (number or name): ",
+		msgPromptTypeListPaged:     "Type (number or name, n/p: page %d/%d): ",
+		msgTypeNumberOutOfRange:    "no type numbered %d",
+		msgHeaderFormatFailed:      "headerFormat of %s can not render the header: %v; rendered by the default format instead:",
+		msgHeaderFormatFailedAsk:   "Commit with this header? [y/N]: ",
+		msgExtendsCycle:            "circular extends: %s",
+		msgRuleExtendsEdit:         "%s extends %s and can not be edited, since the whole rule would be written into it",
+		msgNativeFallback:          "committing by git instead of go-git: %s",
+		msgNativeHooks:             "the hook %s is installed",
+		msgNativeSigning:           "commit.gpgsign is on",
+		msgNativeAmend:             "amending",
+		msgNativeNoIdentity:        "user.name or user.email is not set",
+		msgCommitted:               "[%s %s] %s",
+		msgTypeNearDuplicates:      "types %s differ only by case or punctuation; git cx rule dedupe merges them",
+		msgNoNearDuplicates:        "no types differ only by case or punctuation",
+		msgDedupeGroup:             "these types differ only by case or punctuation:",
+		msgDedupeAsk:               "type to keep, the others becoming its aliases (1-%d, empty to skip): ",
+		msgDedupeInvalid:           "answer a number from 1 to %d, or nothing to skip",
+		msgHeaderFormatInvalid:     "headerFormat of %s is not valid: %v (variables: %s); the default format is used instead",
+		msgMessageFormatInvalid:    "%s of %s is not valid: %v (variables: %s); it is not used",
+		msgMessageFormatFailed:     "%s can not render: %v; written without it",
+		msgSnippet:                 "snippet",
+		msgSnippetsFileWarning:     "can not read the description snippets %s: %v",
+		msgSnippetsFileRelative:    "descriptionSnippetsFile %s must be absolute in a remote rule file",
+		msgPromptNameInvalid:       "the prompt %q in %s is ignored: its name must be letters, digits and _ other than the standard variables, and unique",
+		msgPromptRequired:          "%s is required",
+		msgDiffFailed:              "can not show the staged diff: %v",
+		msgDiffHint:                "(F2 or ?diff: show the diff)",
+		msgGenOverwrite:            "%s exists. Overwrite it? [y/N]: ",
+		msgGenAskEmoji:             "Give the types emojis?",
+		msgGenAskDenyAdlib:         "Deny types not in the rule file?",
+		msgGenAskBreaking:          "Ask BREAKING CHANGE?",
+		msgGenAskMaxHeader:         "Maximum length of the header (0: unlimited) [%d]: ",
+		msgGenInvalidLength:        "Enter a number of 0 or more.",
+		msgDisabled:                "git-cx is disabled by %s; running git commit",
+		msgGenOutsideWorktree:      "%s is outside the worktree %s; gitconfig cx.rule must be relative to it",
+		msgGenConfigSet:            "set %s.%s = %s",
+		msgGenConfigReplaced:       "set %s.%s = %s (was %s)",
+		msgGenConfigKept:           "kept %s.%s = %s",
+		msgGenConfigFile:           "written: %s (undo by git config --unset)",
+		msgGenNoHooksDir:           "the hooks directory of the repository is not found",
+		msgGenHookExists:           "%s exists; overwrite it by --force",
+		msgGenHookInstalled:        "installed: %s (undo by removing it)",
+		msgGenKeepRule:             "kept %s",
+		msgHeaderPreview:           "preview: %s (%v)",
+		msgWorktreeNotFound:        "--worktree %s: not a directory",
+		msgWorktreeNotRepository:   "--worktree %s is not inside the worktree of a git repository",
+		msgScopeBackupNoDir:        "the user config directory to back up the scope history is not found",
+		msgScopeNoBackup:           "no backup of %s to restore",
+		msgScopeUndoModified:       "%s is changed since git-cx wrote it last; not restored not to lose the change",
+		msgScopeUndoAsk:            "Restore %s as it was %s? [y/N]: ",
+		msgScopeUndone:             "restored %s",
+		msgScopeClearAsk:           "Remove all the %d scopes from %s? [y/N]: ",
+		msgUnstaged:                "nothing is staged, but these files are changed:",
+		msgUnstagedAsk:             "[a]dd all, [i]nteractively select, [q]uit: ",
+		msgSelectFiles:             "%d/%d chosen (space: toggle, a: all, enter: stage, q: quit)",
+		msgInvalidTypeBreaking:     "types: %s: breaking must be never, allowed or ask, not %q",
+		msgInvalidEmojiRendering:   "emojiRendering must be unicode, shortcode or none, not %q",
+	},
+	langJapanese: {
+		msgNoChanges:               "変更がありません",
+		msgTypeRequired:            "type は必須です",
+		msgAdlibTypeNotAllowed:     "ルールにない type は使えません",
+		msgDescRequired:            "description は必須です",
+		msgPromptType:              "Type: ",
+		msgPromptScope:             "Scope: ",
+		msgPromptDesc:              "Description: ",
+		msgPromptBody:              "Body: (段落の間は空行1つ、空行2つか Ctrl+D で終了)",
+		msgPromptBreaking:          "BREAKING CHANGE: ",
+		msgAutosavedBody:           "自動保存された Body:",
+		msgRestoreBody:             "復元しますか? [Y/n]: ",
+		msgPartiallyStaged:         "次のファイルはステージ後にさらに変更されています:",
+		msgPartiallyStagedAsk:      "[c]続行, [a]これらも追加, [b]中止: ",
+		msgAborted:                 "中止しました",
+		msgWriteScopesWarning:      "scope 履歴を書き込めません: %v",
+		msgDevBuildMinVersion:      "開発版です。ルールは git-cx %s 以降を要求しています",
+		msgUpgradeRequired:         "%s は git-cx %s 以降を要求しています (このバージョンは %s)。git-cx を更新してください",
+		msgLintFilesRequired:       "メッセージファイルを指定してください",
+		msgLintUseBatch:            "複数のファイルを検査するには --batch を指定してください",
+		msgLintSummary:             "%d ファイル中 %d 件が不合格",
+		msgLintFailed:              "%d / %d 件のメッセージが不合格です",
+		msgLintEmptyMessage:        "メッセージが空です",
+		msgLintInvalidHeader:       "ヘッダ %q が `type(scope)!: description` の形式ではありません",
+		msgLintUndefinedType:       "type %q はルールに定義されていません",
+		msgLintEmptyDesc:           "description が空です",
+		msgLintBreakingDenied:      "type %q では破壊的変更は許可されていません",
+		msgRewordNothing:           "--type, --scope, --no-scope, --desc のいずれかを指定してください",
+		msgRewordNotConventional:   "直前のコミットのヘッダが `type(scope)!: description` の形式ではありません。--type を指定してください",
+		msgWipProtectedBranch:      "保護されたブランチ %s には作業中のコミットはできません",
+		msgCheckWipCommits:         "このブランチに wip コミットが %d 件あります。マージ前に squash してください",
+		msgCheckForbidden:          "%s は %s では禁止されています。マージ前に --autosquash で rebase してください",
+		msgCheckFailed:             "%d 件のコミットがこのブランチでは禁止されています",
+		msgCheckInvalidRange:       "範囲 %q が不正です。A..B の形式で指定してください",
+		msgForbiddenTypeWarning:    "%s は %s では禁止されています。マージ前に --autosquash で rebase してください",
+		msgRuleArgs:                "引数が必要です: %s",
+		msgRuleTypeExists:          "type %q はすでに存在します",
+		msgRuleTypeNotFound:        "type %q が見つかりません",
+		msgRuleUnknownField:        "不明なフィールド %q",
+		msgRuleCreate:              "ルールファイルがありません。既定のルールから %s を作成しますか? [Y/n]: ",
+		msgRuleUnchanged:           "ルールファイルに変更はありません",
+		msgRuleConfirm:             "%s に書き込みますか? [y/N]: ",
+		msgIndexLocked:             "%s があります: 別の git プロセス (おそらく IDE やエディタの git 連携) が index を使用中です。終了してから再実行するか、git プロセスが動いていなければこのファイルを削除してください",
+		msgWriteDescHistoryWarning: "description 履歴を書き込めません: %v",
+		msgFileTooLarge:            "%s が大きすぎます (%d バイト、上限は %d バイト。gitconfig cx.maxFileSize を参照)",
+		msgLintTypeCase:            "type %q は小文字 %q にしてください",
+		msgLintTrailingPeriod:      "description の末尾にピリオドがあります",
+		msgLintHeaderSpace:         "header のコロンの後に空白がありません",
+		msgLintFooterBlankLine:     "フッタの前には空行が必要です",
+		msgLintBreakingSpelling:    "BREAKING-CHANGE は BREAKING CHANGE と書いてください",
+		msgLintFixed:               "修正: %s",
+		msgPromptBreakingMore:      "BREAKING CHANGE (空で終了): ",
+		msgNotRepository:           "git リポジトリではありません (%s から上位を探しました)。リポジトリ内で git cx を実行するか、先に git init してください",
+		msgScopeLastUsedIn:         "%s — %s で最後に使用 (%s)",
+		msgScopeLastUsed:           "%s — 最終使用 %s",
+		msgAgoJustNow:              "たった今",
+		msgAgoMinutes:              "%d 分前",
+		msgAgoHours:                "%d 時間前",
+		msgAgoDays:                 "%d 日前",
+		msgUnknownValue:            "不明な %s '%s' です。有効な値: %s",
+		msgReadOnly:                "読み取り専用モード: %s に書き込めません。scope と description の履歴、Body の自動保存は行いません",
+		msgRuleInvalid:             "ルールファイル %s が不正です: %v",
+		msgHookRejected:            "git commit が拒否されました。フック (%s) の出力を確認してください",
+		msgRelatedUntracked:        "次の未追跡ファイルはステージしたファイルの近くにあります:",
+		msgRelatedUntrackedAsk:     "これらの未追跡ファイルもステージしますか? [y/N/select]: ",
+		msgPickFiles:               "ステージする番号 (1 3 や 2-4 など。空なら何もしない): ",
+		msgUnknownTypeArg:          "type %q はルールに定義されておらず、ルールにない type は使えません",
+		msgDryRunInvalid:           "メッセージがルールに違反しています (%d 件)",
+		msgRenderedHeader:          "(GitHub での表示: %s)",
+		msgEditHelp:                "変更のコミットメッセージを入力してください。'#' で始まる行は無視され、\n空のメッセージはコミットを中止します。",
+		msgEditStaged:              "コミット予定の変更:",
+		msgEditEmpty:               "コミットメッセージが空のため中止します",
+		msgCochangedScope:          "最近のコミット %d 件でこれらのファイルに使用",
+		msgLintFixNeedsFiles:       "--fix で書き換えられるのはメッセージファイルのみです (標準入力やコミットは不可)",
+		msgHeaderTooLong:           "ヘッダーが %d 文字あり、上限 %d 文字を超えています",
+		msgHeaderTooLongAsk:        "このままコミットしますか? (y: はい、それ以外: 説明を修正): ",
+		msgSigningNoKey:            "commit.gpgsign が true ですが、署名用の鍵が見つかりません (user.signingkey)",
+		msgSigningKeyMissing:       "commit.gpgsign が true ですが、署名用の鍵 %s が使えません",
+		msgSigningAsk:              "署名せずにコミットしますか? (y: --no-gpg-sign、それ以外: 中止): ",
+		msgFooterRequired:          "フッター %s は必須です",
+		msgTypeOrderUnknown:        "typeorder にルールで定義されていない type があります: %v",
+		msgVersionHint:             "このコミットで %s バージョンが上がります (現在: %s → 次: %s)",
+		msgScopeFromPaths:          "(ステージされたファイルから)",
+		msgFormatAttempt:           "%s として %v",
+		msgRuleDefaultWarning:      "%v; 既定のルールを使います",
+		msgScopePruneWarning:       "スコープ履歴は整理されません: %v",
+		msgScopeHistoryDisabled:    "スコープ履歴は無効です",
+		msgScopePruneNothing:       "削除するスコープはありません",
+		msgScopePruned:             "削除: %s",
+		msgScopeNotFound:           "スコープ %s は履歴にありません",
+		msgInvalidTrailerToken:     "%q はトレーラーのトークンではありません (英数字とハイフン、または BREAKING CHANGE)",
+		msgTrailerWarning:          "トレーラー %s は省かれます: %v",
+		msgRuleURLNotHTTPS:         "ルールの URL は https のみ使えます (%s は不可)",
+		msgRuleURLCached:           "%s を取得できないため、%s のキャッシュを使います: %v",
+		msgRuleURLReadOnly:         "%s は URL のため編集できません",
+		msgStagedHeader:            "ステージ済み: %s",
+		msgStagedModified:          "変更 %d",
+		msgStagedAdded:             "追加 %d",
+		msgStagedDeleted:           "削除 %d",
+		msgStagedRenamed:           "名前変更 %d",
+		msgStagedOther:             "その他 %d",
+		msgStagedMore:              "...ほか %d 件",
+		msgWarning:                 "cx: 警告: %s",
+		msgInterrupted:             "中断しました。コミットしていません",
+		msgInvalidEmojiShortcode:   "%q は既知の絵文字のショートコードではありません",
+		msgFunFacts:                "🎉 今日 %s のコミットです (このリポジトリで今週 %d 件)",
+		msgPromptTypeList:          "Type (番号か名前): ",
+		msgPromptTypeListPaged:     "Type (番号か名前。n/p: ページ %d/%d): ",
+		msgTypeNumberOutOfRange:    "%d 番の type はありません",
+		msgHeaderFormatFailed:      "%s の headerFormat でヘッダーを作れません: %v。代わりに既定の形式で作りました:",
+		msgHeaderFormatFailedAsk:   "このヘッダーでコミットしますか? [y/N]: ",
+		msgExtendsCycle:            "extends が循環しています: %s",
+		msgRuleExtendsEdit:         "%s は %s を extends しているため編集できません (ルール全体が書き込まれてしまいます)",
+		msgNativeFallback:          "go-git ではなく git でコミットします: %s",
+		msgNativeHooks:             "フック %s があります",
+		msgNativeSigning:           "commit.gpgsign が有効です",
+		msgNativeAmend:             "amend です",
+		msgNativeNoIdentity:        "user.name か user.email が設定されていません",
+		msgCommitted:               "[%s %s] %s",
+		msgTypeNearDuplicates:      "type %s は大文字小文字か記号だけが異なります。git cx rule dedupe でまとめられます",
+		msgNoNearDuplicates:        "大文字小文字か記号だけが異なる type はありません",
+		msgDedupeGroup:             "次の type は大文字小文字か記号だけが異なります:",
+		msgDedupeAsk:               "残す type (他はその alias になります。1-%d、空でスキップ): ",
+		msgDedupeInvalid:           "1 から %d の番号を答えてください (空でスキップ)",
+		msgHeaderFormatInvalid:     "%s の headerFormat が正しくありません: %v (変数: %s)。代わりに既定の形式を使います",
+		msgMessageFormatInvalid:    "%[2]s の %[1]s が正しくありません: %[3]v (変数: %[4]s)。使いません",
+		msgMessageFormatFailed:     "%s で作れません: %v。使わずに書きます",
+		msgSnippet:                 "スニペット",
+		msgSnippetsFileWarning:     "description のスニペット %s を読めません: %v",
+		msgSnippetsFileRelative:    "リモートのルールファイルでは descriptionSnippetsFile %s は絶対パスにしてください",
+		msgPromptNameInvalid:       "%[2]s のプロンプト %[1]q は無視します: 名前は標準の変数以外の英数字と _ で、重複しないようにしてください",
+		msgPromptRequired:          "%s は必須です",
+		msgDiffFailed:              "ステージされた差分を表示できません: %v",
+		msgDiffHint:                "(F2 か ?diff: 差分を表示)",
+		msgGenOverwrite:            "%s は存在します。上書きしますか? [y/N]: ",
+		msgGenAskEmoji:             "タイプに絵文字を付けますか?",
+		msgGenAskDenyAdlib:         "ルールファイルにないタイプを拒否しますか?",
+		msgGenAskBreaking:          "BREAKING CHANGE を尋ねますか?",
+		msgGenAskMaxHeader:         "ヘッダーの最大長 (0: 無制限) [%d]: ",
+		msgGenInvalidLength:        "0 以上の数を入力してください。",
+		msgDisabled:                "git-cx は %s で無効にされています。git commit を実行します",
+		msgGenOutsideWorktree:      "%s はワークツリー %s の外にあります。gitconfig cx.rule はその相対パスでなければなりません",
+		msgGenConfigSet:            "%s.%s = %s を設定しました",
+		msgGenConfigReplaced:       "%s.%s = %s を設定しました (元は %s)",
+		msgGenConfigKept:           "%s.%s = %s はそのままにしました",
+		msgGenConfigFile:           "書き込み先: %s (git config --unset で元に戻せます)",
+		msgGenNoHooksDir:           "リポジトリのフックディレクトリが見つかりません",
+		msgGenHookExists:           "%s は存在します。--force で上書きできます",
+		msgGenHookInstalled:        "インストールしました: %s (削除すれば元に戻せます)",
+		msgGenKeepRule:             "%s はそのままにしました",
+		msgHeaderPreview:           "プレビュー: %s (%v)",
+		msgWorktreeNotFound:        "--worktree %s: ディレクトリではありません",
+		msgWorktreeNotRepository:   "--worktree %s は git リポジトリのワークツリー内ではありません",
+		msgScopeBackupNoDir:        "スコープ履歴をバックアップするユーザー設定ディレクトリが見つかりません",
+		msgScopeNoBackup:           "%s の復元できるバックアップはありません",
+		msgScopeUndoModified:       "%s は git-cx が最後に書き込んでから変更されています。変更を失わないよう復元しません",
+		msgScopeUndoAsk:            "%s を %s の状態に戻しますか? [y/N]: ",
+		msgScopeUndone:             "%s を復元しました",
+		msgScopeClearAsk:           "%[2]s の %[1]d 個のスコープをすべて削除しますか? [y/N]: ",
+		msgUnstaged:                "ステージされていませんが、次のファイルが変更されています:",
+		msgUnstagedAsk:             "[a] すべてステージ, [i] 選んでステージ, [q] 終了: ",
+		msgSelectFiles:             "%d/%d 件選択 (space: 切り替え, a: すべて, enter: ステージ, q: 終了)",
+		msgInvalidTypeBreaking:     "types: %s: breaking は never, allowed, ask のいずれかです (%q は使えません)",
+		msgInvalidEmojiRendering:   "emojiRendering は unicode, shortcode, none のいずれかです (%q は使えません)",
+	},
+}
+
+var currentLang = langEnglish
+
+// tr returns the message of key in the current language, formatted with args if given.
+func tr(key string, args ...any) string {
+	m, found := catalog[currentLang][key]
+	if !found {
+		m = catalog[langEnglish][key]
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(m, args...)
+	}
+	return m
+}
+
+// setLang selects the language by (in order) the flag, gitconfig cx.lang and the locale environment variables.
+func setLang(flag string, repos *git.Repository) {
+	candidates := []string{flag}
+	if repos != nil {
+		if cfg := getGitConfig(repos, configLang); cfg != nil {
+			candidates = append(candidates, *cfg)
+		}
+	}
+	candidates = append(candidates, os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG"))
+
+	for _, c := range candidates {
+		if c == "" {
+			continue
+		}
+		if l := normalizeLang(c); l != "" {
+			currentLang = l
+			return
+		}
+		// the first given one is used even if unsupported
+		currentLang = langEnglish
+		return
+	}
+}
+
+// normalizeLang converts a locale like ja_JP.UTF-8 into a supported language, or returns "".
+func normalizeLang(locale string) string {
+	l := strings.ToLower(locale)
+	l, _, _ = strings.Cut(l, ".")
+	l, _, _ = strings.Cut(l, "_")
+	l, _, _ = strings.Cut(l, "-")
+
+	if _, found := catalog[l]; found {
+		return l
+	}
+	return ""
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// formatArgs returns the verbs of format by the argument, numbered from 1.
+func formatArgs(format string) map[int]string {
+	verbs := regexp.MustCompile(`%(\[([0-9]+)\])?[-+# 0]*[0-9]*(\.[0-9]+)?([a-zA-Z%])`)
+
+	args := make(map[int]string)
+	n := 0
+	for _, m := range verbs.FindAllStringSubmatch(format, -1) {
+		if m[4] == "%" {
+			continue
+		}
+		if m[2] != "" {
+			n, _ = strconv.Atoi(m[2])
+		} else {
+			n++
+		}
+		args[n] = m[4]
+	}
+	return args
+}
+
+func TestCatalogComplete(t *testing.T) {
+	for key, en := range catalog[langEnglish] {
+		for lang, messages := range catalog {
+			m, found := messages[key]
+			if !found {
+				t.Errorf("%s: %s is missing", lang, key)
+				continue
+			}
+			if got, want := formatArgs(m), formatArgs(en); !reflect.DeepEqual(got, want) {
+				t.Errorf("%s: %s formats %v, want %v as in English", lang, key, got, want)
+			}
+		}
+	}
+	for lang, messages := range catalog {
+		for key := range messages {
+			if _, found := catalog[langEnglish][key]; !found {
+				t.Errorf("%s: %s is not in English", lang, key)
+			}
+		}
+	}
+}
+
+func TestNormalizeLang(t *testing.T) {
+	tests := []struct {
+		locale string
+		want   string
+	}{
+		{locale: "ja", want: langJapanese},
+		{locale: "ja_JP.UTF-8", want: langJapanese},
+		{locale: "ja-JP", want: langJapanese},
+		{locale: "EN_us", want: langEnglish},
+		{locale: "C", want: ""},
+		{locale: "fr_FR.UTF-8", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			if got := normalizeLang(tt.locale); got != tt.want {
+				t.Errorf("normalizeLang(%q) = %q, want %q", tt.locale, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetLang(t *testing.T) {
+	defer func(l string) { currentLang = l }(currentLang)
+
+	tests := []struct {
+		name string
+		flag string
+		env  map[string]string
+		want string
+	}{
+		{name: "flag", flag: "ja", env: map[string]string{"LANG": "en_US.UTF-8"}, want: langJapanese},
+		{name: "LANG", env: map[string]string{"LANG": "ja_JP.UTF-8"}, want: langJapanese},
+		{name: "LC_ALL before LANG", env: map[string]string{"LC_ALL": "en_US.UTF-8", "LANG": "ja_JP.UTF-8"}, want: langEnglish},
+		{name: "unsupported is English", env: map[string]string{"LC_ALL": "fr_FR.UTF-8", "LANG": "ja_JP.UTF-8"}, want: langEnglish},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, k := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+				t.Setenv(k, tt.env[k])
+			}
+			currentLang = ""
+			setLang(tt.flag, nil)
+			if currentLang != tt.want {
+				t.Errorf("currentLang = %q, want %q", currentLang, tt.want)
+			}
+		})
+	}
+}
+
+// Messages shown by the prompts are looked up by tr, not written as literals.
+func TestPromptsUseCatalog(t *testing.T) {
+	files := []string{"prompts.go", "picker.go", "typeselect.go", "headerlength.go", "staged.go"}
+	words := regexp.MustCompile(`[A-Za-z]{2,}`)
+
+	fset := token.NewFileSet()
+	for _, file := range files {
+		f, err := parser.ParseFile(fset, file, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ast.Inspect(f, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			sel, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			pkg, ok := sel.X.(*ast.Ident)
+			if !ok || !(pkg.Name == "fmt" && strings.HasPrefix(sel.Sel.Name, "Print") ||
+				pkg.Name == "fmt" && strings.HasPrefix(sel.Sel.Name, "Fprint") ||
+				pkg.Name == "errors" && sel.Sel.Name == "New") {
+				return true
+			}
+			for _, arg := range call.Args {
+				lit, ok := arg.(*ast.BasicLit)
+				if !ok || lit.Kind != token.STRING {
+					continue
+				}
+				if s, _ := strconv.Unquote(lit.Value); words.MatchString(s) {
+					t.Errorf("%s: %s.%s(%s) is not looked up by tr", fset.Position(lit.Pos()), pkg.Name, sel.Sel.Name, lit.Value)
+				}
+			}
+			return true
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// With --native or gitconfig cx.native-commit, the commit is made by go-git, not needing git on PATH.
+// git is still used when go-git can not do what git would: running the pre-commit hook, signing and amending.
+// The message hooks are run by git-cx (see hookedMessage).
+
+const configNativeCommit = "native-commit"
+
+// commitsNatively reports whether to commit by go-git, by --native or gitconfig cx.native-commit.
+func (c globalCmd) commitsNatively() bool {
+	if c.Native {
+		return true
+	}
+	if c.repository == nil {
+		return false
+	}
+	if cfg := getGitConfig(c.repository, configNativeCommit); cfg != nil {
+		on, _ := strconv.ParseBool(strings.TrimSpace(*cfg))
+		return on
+	}
+	return false
+}
+
+// nativeCommitProblem returns why the commit can not be made by go-git, or "".
+// noGPGSign is true if signing is skipped.
+func (c globalCmd) nativeCommitProblem(noGPGSign bool) string {
+	if c.Amend {
+		return tr(msgNativeAmend)
+	}
+	if !c.NoVerify && hookPath(c.repository, "pre-commit") != "" {
+		return tr(msgNativeHooks, "pre-commit")
+	}
+	if sign, err := strconv.ParseBool(gitConfigOption(c.repository, "commit", "gpgsign")); err == nil && sign && !noGPGSign {
+		return tr(msgNativeSigning)
+	}
+	if _, ok := commitSignature(c.repository, "AUTHOR"); !ok {
+		return tr(msgNativeNoIdentity)
+	}
+	if _, ok := commitSignature(c.repository, "COMMITTER"); !ok {
+		return tr(msgNativeNoIdentity)
+	}
+	return ""
+}
+
+// nativeCommit commits what is staged with msg by go-git, as git commit -F would.
+func (c globalCmd) nativeCommit(msg string) error {
+	wt, err := c.repository.Worktree()
+	if err != nil {
+		return err
+	}
+
+	author, _ := commitSignature(c.repository, "AUTHOR")
+	committer, _ := commitSignature(c.repository, "COMMITTER")
+
+	// signed off already for commit-msg
+	msg = normalizeMessage(msg)
+
+	_, err = wt.Commit(msg, &git.CommitOptions{
+		Author:    &author,
+		Committer: &committer,
+	})
+	if err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+// commitSignature returns the identity of role (AUTHOR or COMMITTER) as git does:
+// by $GIT_<role>_NAME and $GIT_<role>_EMAIL, or else user.name and user.email. ok is false if either is unknown.
+func commitSignature(repos *git.Repository, role string) (sig object.Signature, ok bool) {
+	name, email := os.Getenv("GIT_"+role+"_NAME"), os.Getenv("GIT_"+role+"_EMAIL")
+	if name == "" {
+		name = gitConfigOption(repos, "user", "name")
+	}
+	if email == "" {
+		email = gitConfigOption(repos, "user", "email")
+	}
+	if name == "" || email == "" {
+		return object.Signature{}, false
+	}
+	return object.Signature{Name: name, Email: email, When: time.Now()}, true
+}
+
+// printCommitted writes the commit at HEAD like `[main 1a2b3c4] feat: add retry flag`, as git commit does.
+func printCommitted(repos *git.Repository) {
+	head, err := repos.Head()
+	if err != nil {
+		return
+	}
+	commit, err := repos.CommitObject(head.Hash())
+	if err != nil {
+		return
+	}
+
+	branch := "HEAD"
+	if head.Name().IsBranch() {
+		branch = head.Name().Short()
+	}
+	header, _, _ := strings.Cut(commit.Message, "\n")
+	fmt.Println(tr(msgCommitted, branch, shortHash(head.Hash().String()), header))
+}
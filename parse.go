@@ -0,0 +1,211 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ConventionalCommit is a commit message split into its conventional commits components.
+type ConventionalCommit struct {
+	Type        string   `json:"type"`
+	Scope       string   `json:"scope,omitempty"`
+	Bang        bool     `json:"bang,omitempty"`
+	Description string   `json:"description"`
+	Body        string   `json:"body,omitempty"`
+	Footers     []Footer `json:"footers,omitempty"`
+
+	// BreakingChange is the value of the first BREAKING CHANGE (or BREAKING-CHANGE) footer.
+	BreakingChange string `json:"breakingChange,omitempty"`
+
+	// BreakingChanges are the values of all BREAKING CHANGE footers.
+	BreakingChanges []string `json:"breakingChanges,omitempty"`
+
+	// Breaking is true if Bang, BreakingChange or the Cx-Breaking trailer says so.
+	Breaking bool `json:"breaking,omitempty"`
+}
+
+// Footer is a git trailer like footer: `Token: value` or `Token #value`.
+type Footer struct {
+	Token     string `json:"token"`
+	Separator string `json:"separator"`
+	Value     string `json:"value"`
+}
+
+const (
+	breakingChangeToken      = "BREAKING CHANGE"
+	breakingChangeTokenAlias = "BREAKING-CHANGE"
+)
+
+// machine trailers (Rule.MachineTrailers)
+const (
+	machineTypeToken     = "Cx-Type"
+	machineScopeToken    = "Cx-Scope"
+	machineBreakingToken = "Cx-Breaking"
+)
+
+var (
+	// a space after the colon is required, as in the spec; lint --fix inserts it (see headerNoSpacePattern)
+	headerPattern = regexp.MustCompile(`^([^\s():!]+)(?:\(([^()]*)\))?(!)?:(?: (.*))?$`)
+	footerPattern = regexp.MustCompile(`^(BREAKING CHANGE|[A-Za-z0-9][A-Za-z0-9-]*)(: | #)(.*)$`)
+)
+
+func (f Footer) String() string {
+	return f.Token + f.Separator + f.Value
+}
+
+// IsBreakingChange reports whether f is BREAKING CHANGE or its alias BREAKING-CHANGE.
+func (f Footer) IsBreakingChange() bool {
+	return f.Token == breakingChangeToken || f.Token == breakingChangeTokenAlias
+}
+
+// parseCommitMessage parses msg as `type(scope)!: description`, the body and the footers.
+// ok is false if the header does not match; then the whole header is in Description.
+func parseCommitMessage(msg string) (cc ConventionalCommit, ok bool) {
+	msg = strings.ReplaceAll(msg, "\r\n", "\n")
+	msg = strings.TrimRight(msg, "\n")
+	header, rest, _ := strings.Cut(msg, "\n")
+	header = strings.TrimSpace(header)
+
+	m := headerPattern.FindStringSubmatch(header)
+	if m == nil {
+		cc.Description = header
+	} else {
+		cc.Type = m[1]
+		cc.Scope = m[2]
+		cc.Bang = m[3] != ""
+		cc.Description = strings.TrimSpace(m[4])
+		ok = true
+	}
+
+	rest = strings.Trim(rest, "\n")
+	if rest == "" {
+		cc.Breaking = cc.Bang
+		return cc, ok
+	}
+	lines := strings.Split(rest, "\n")
+
+	start := footerStart(lines)
+	cc.Body = strings.Trim(strings.Join(lines[:start], "\n"), "\n")
+	cc.Footers = parseFooters(lines[start:])
+
+	for _, f := range cc.Footers {
+		if f.IsBreakingChange() {
+			cc.BreakingChanges = append(cc.BreakingChanges, f.Value)
+		}
+	}
+	if len(cc.BreakingChanges) > 0 {
+		cc.BreakingChange = cc.BreakingChanges[0]
+	}
+	cc.Breaking = cc.Bang || cc.BreakingChange != ""
+
+	// machine trailers are preferred, since they do not depend on the header format
+	for _, f := range cc.Footers {
+		switch {
+		case strings.EqualFold(f.Token, machineTypeToken):
+			cc.Type = f.Value
+			ok = true
+		case strings.EqualFold(f.Token, machineScopeToken):
+			cc.Scope = f.Value
+		case strings.EqualFold(f.Token, machineBreakingToken):
+			cc.Breaking = f.Value == "true"
+		}
+	}
+
+	return cc, ok
+}
+
+// isCxToken reports whether token is of a trailer written by git-cx, such as Cx-Type and Cx-Wip.
+func isCxToken(token string) bool {
+	return len(token) >= 3 && strings.EqualFold(token[:3], "Cx-")
+}
+
+// machineTrailers returns the trailers of the structured answers.
+func machineTrailers(typ, scope string, breaking bool) string {
+	lines := []string{machineTypeToken + ": " + typ}
+	if scope != "" {
+		lines = append(lines, machineScopeToken+": "+scope)
+	}
+	lines = append(lines, machineBreakingToken+": "+strconv.FormatBool(breaking))
+	return strings.Join(lines, "\n")
+}
+
+// footerStart returns the index of the first line of the footers, or len(lines) if none.
+//
+// Footers are the trailing paragraphs that start with a footer line.
+// A BREAKING CHANGE line always starts the footers, even without a preceding blank line.
+func footerStart(lines []string) int {
+	start := len(lines)
+
+	// paragraphs from the end
+	end := len(lines)
+	for end > 0 {
+		p := end - 1
+		for p > 0 && lines[p-1] != "" {
+			p--
+		}
+		if !footerPattern.MatchString(lines[p]) {
+			break
+		}
+		start = p
+
+		end = p
+		for end > 0 && lines[end-1] == "" {
+			end--
+		}
+	}
+
+	for i := 0; i < start; i++ {
+		if m := footerPattern.FindStringSubmatch(lines[i]); m != nil && (m[1] == breakingChangeToken || m[1] == breakingChangeTokenAlias) {
+			return i
+		}
+	}
+
+	return start
+}
+
+// parseFooters parses lines into footers. Lines that are not footer lines continue the value of the previous one.
+func parseFooters(lines []string) []Footer {
+	var footers []Footer
+	for _, line := range lines {
+		if m := footerPattern.FindStringSubmatch(line); m != nil {
+			footers = append(footers, Footer{
+				Token:     m[1],
+				Separator: m[2],
+				Value:     m[3],
+			})
+			continue
+		}
+
+		if len(footers) == 0 {
+			continue
+		}
+		f := &footers[len(footers)-1]
+		f.Value += "\n" + line
+	}
+
+	for i := range footers {
+		footers[i].Value = strings.TrimRight(footers[i].Value, "\n")
+	}
+	return footers
+}
+
+// scissorsLine follows the comment character on the line above the diff of `git commit -v`.
+const scissorsLine = "------------------------ >8 ------------------------"
+
+// stripCommentLines removes lines starting with comment (core.commentChar, see commentChar) as git does,
+// and cuts msg at the scissors line, removing the diff below it.
+func stripCommentLines(msg, comment string) string {
+	lines := strings.Split(msg, "\n")
+	out := lines[:0]
+	for _, l := range lines {
+		if strings.TrimRight(l, "\r") == comment+" "+scissorsLine {
+			break
+		}
+		if strings.HasPrefix(l, comment) {
+			continue
+		}
+		out = append(out, l)
+	}
+	return strings.Join(out, "\n")
+}
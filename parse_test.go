@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/shu-go/git-cx/internal/testutil"
+)
+
+func TestParseCommitMessage(t *testing.T) {
+	tests := []struct {
+		name   string
+		msg    string
+		want   ConventionalCommit
+		wantOK bool
+	}{
+		{
+			name:   "header only",
+			msg:    "feat(api)!: add retry flag\n",
+			want:   ConventionalCommit{Type: "feat", Scope: "api", Bang: true, Description: "add retry flag", Breaking: true},
+			wantOK: true,
+		},
+		{
+			name:   "not conventional",
+			msg:    "Add retry flag",
+			want:   ConventionalCommit{Description: "Add retry flag"},
+			wantOK: false,
+		},
+		{
+			name:   "no space after the colon",
+			msg:    "feat(api):add retry flag",
+			want:   ConventionalCommit{Description: "feat(api):add retry flag"},
+			wantOK: false,
+		},
+		{
+			name:   "no description",
+			msg:    "feat: \n",
+			want:   ConventionalCommit{Type: "feat"},
+			wantOK: true,
+		},
+		{
+			name: "body and footers",
+			msg:  "fix: handle nil\n\nThe body.\nNote: not a footer in the body\n\nRefs #123\nReviewed-by: Alice\nAcked-by: Bob",
+			want: ConventionalCommit{
+				Type: "fix", Description: "handle nil",
+				Body: "The body.\nNote: not a footer in the body",
+				Footers: []Footer{
+					{Token: "Refs", Separator: " #", Value: "123"},
+					{Token: "Reviewed-by", Separator: ": ", Value: "Alice"},
+					{Token: "Acked-by", Separator: ": ", Value: "Bob"},
+				},
+			},
+			wantOK: true,
+		},
+		{
+			name: "BREAKING-CHANGE with a continuation line",
+			msg:  "feat: drop v1\n\nBREAKING-CHANGE: v1 is removed\n  use v2 instead\nRefs: #1",
+			want: ConventionalCommit{
+				Type: "feat", Description: "drop v1",
+				Footers: []Footer{
+					{Token: "BREAKING-CHANGE", Separator: ": ", Value: "v1 is removed\n  use v2 instead"},
+					{Token: "Refs", Separator: ": ", Value: "#1"},
+				},
+				BreakingChange:  "v1 is removed\n  use v2 instead",
+				BreakingChanges: []string{"v1 is removed\n  use v2 instead"},
+				Breaking:        true,
+			},
+			wantOK: true,
+		},
+		{
+			name: "BREAKING CHANGE without a blank line",
+			msg:  "feat: drop v1\n\nThe body.\nBREAKING CHANGE: v1 is removed\nBREAKING CHANGE: so is v0",
+			want: ConventionalCommit{
+				Type: "feat", Description: "drop v1",
+				Body: "The body.",
+				Footers: []Footer{
+					{Token: "BREAKING CHANGE", Separator: ": ", Value: "v1 is removed"},
+					{Token: "BREAKING CHANGE", Separator: ": ", Value: "so is v0"},
+				},
+				BreakingChange:  "v1 is removed",
+				BreakingChanges: []string{"v1 is removed", "so is v0"},
+				Breaking:        true,
+			},
+			wantOK: true,
+		},
+		{
+			name: "machine trailers",
+			msg:  "\u2728 add retry flag\n\nCx-Type: feat\nCx-Scope: api\nCx-Breaking: true",
+			want: ConventionalCommit{
+				Type: "feat", Scope: "api", Description: "\u2728 add retry flag",
+				Footers: []Footer{
+					{Token: "Cx-Type", Separator: ": ", Value: "feat"},
+					{Token: "Cx-Scope", Separator: ": ", Value: "api"},
+					{Token: "Cx-Breaking", Separator: ": ", Value: "true"},
+				},
+				Breaking: true,
+			},
+			wantOK: true,
+		},
+		{
+			name:   "CRLF",
+			msg:    "fix: handle nil\r\n\r\nRefs: #1\r\n",
+			want:   ConventionalCommit{Type: "fix", Description: "handle nil", Footers: []Footer{{Token: "Refs", Separator: ": ", Value: "#1"}}},
+			wantOK: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseCommitMessage(tt.msg)
+			if ok != tt.wantOK || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseCommitMessage() = %+v, %v, want %+v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+// A message built from the answers is parsed back into them.
+func TestBuildupCommitMessageParsesBack(t *testing.T) {
+	tests := []struct {
+		name  string
+		given ConventionalCommit
+	}{
+		{name: "header only", given: ConventionalCommit{Type: "feat", Description: "add retry flag"}},
+		{name: "scope and body", given: ConventionalCommit{Type: "fix", Scope: "api", Description: "handle nil", Body: "The body.\n\nThe second paragraph."}},
+		{name: "breaking changes", given: ConventionalCommit{Type: "feat", Description: "drop v1", BreakingChanges: []string{"v1 is removed", "so is v0"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := defaultRule(false)
+			rule.HeaderFormat = "{{.type}}{{with .scope}}({{.}}){{end}}: {{.description}}"
+			rule.UseBreakingChange = true
+			c := globalCmd{rule: &rule, given: tt.given}
+			c.Type, c.Message = tt.given.Type, tt.given.Description
+
+			msg, _, _, err := c.buildupCommitMessage(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, ok := parseCommitMessage(msg)
+			if !ok {
+				t.Fatalf("%q is not parsed", msg)
+			}
+			if got.Type != tt.given.Type || got.Scope != tt.given.Scope || got.Description != tt.given.Description ||
+				got.Body != tt.given.Body || !reflect.DeepEqual(got.BreakingChanges, tt.given.BreakingChanges) {
+				t.Errorf("parsed %q into %+v, want %+v", msg, got, tt.given)
+			}
+		})
+	}
+}
+
+func TestStripCommentLines(t *testing.T) {
+	const scissors = "# ------------------------ >8 ------------------------"
+
+	tests := []struct {
+		name    string
+		msg     string
+		comment string
+		want    string
+	}{
+		{
+			name:    "comments",
+			msg:     "feat: add retry flag\n# Please enter the commit message\n\nthe body\n",
+			comment: "#",
+			want:    "feat: add retry flag\n\nthe body\n",
+		},
+		{
+			name:    "diff below the scissors line",
+			msg:     "feat: add retry flag\n\nthe body\n" + scissors + "\n# Do not modify or remove the line above.\ndiff --git a/a.go b/a.go\n+Refs: a line of the diff\n",
+			comment: "#",
+			want:    "feat: add retry flag\n\nthe body",
+		},
+		{
+			name:    "scissors line with CRLF",
+			msg:     "feat: add retry flag\r\n" + scissors + "\r\ndiff --git a/a.go b/a.go\r\n",
+			comment: "#",
+			want:    "feat: add retry flag\r",
+		},
+		{
+			name:    "another comment character",
+			msg:     "feat: add retry flag\n; a comment\n#1 is not a comment\n; ------------------------ >8 ------------------------\ndiff\n",
+			comment: ";",
+			want:    "feat: add retry flag\n#1 is not a comment",
+		},
+		{
+			name:    "scissors of another comment character is kept",
+			msg:     "feat: add retry flag\n\n" + scissors[1:] + "\n",
+			comment: "#",
+			want:    "feat: add retry flag\n\n" + scissors[1:] + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stripCommentLines(tt.msg, tt.comment); got != tt.want {
+				t.Errorf("stripCommentLines() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommentChar(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "", want: "#"},
+		{value: "auto", want: "#"},
+		{value: ";", want: ";"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			// not the global gitconfig of the user
+			t.Setenv("HOME", t.TempDir())
+			t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+			r := testutil.NewRepo(t)
+			if tt.value != "" {
+				r.SetConfig("core", "commentChar", tt.value)
+			}
+			if got := commentChar(r.Repository); got != tt.want {
+				t.Errorf("commentChar() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	prompt "github.com/elk-language/go-prompt"
+)
+
+// pickFiles lets the user choose some of files by their numbers.
+func pickFiles(files []string) ([]string, error) {
+	for i, f := range files {
+		fmt.Fprintf(os.Stderr, "%3d: %s\n", i+1, f)
+	}
+
+	answer, err := promptInput(prompt.WithPrefix(tr(msgPickFiles)))
+	if err != nil {
+		return nil, err
+	}
+
+	var picked []string
+	for _, i := range parsePicks(answer, len(files)) {
+		picked = append(picked, files[i])
+	}
+	return picked, nil
+}
+
+// parsePicks parses 1-based numbers and ranges like "1 3, 5-7" into 0-based indexes less than n, in order without duplicates.
+// Invalid elements are ignored.
+func parsePicks(s string, n int) []int {
+	seen := make(map[int]bool)
+	var picks []int
+	for _, elem := range strings.FieldsFunc(s, func(r rune) bool { return r == ' ' || r == ',' }) {
+		from, to, isRange := strings.Cut(elem, "-")
+		lo, err := strconv.Atoi(from)
+		if err != nil {
+			continue
+		}
+		hi := lo
+		if isRange {
+			if hi, err = strconv.Atoi(to); err != nil {
+				continue
+			}
+		}
+
+		for i := lo; i <= hi; i++ {
+			if i < 1 || i > n || seen[i-1] {
+				continue
+			}
+			seen[i-1] = true
+			picks = append(picks, i-1)
+		}
+	}
+	return picks
+}
+
+// selectPageSize is the number of files shown at once by selectFiles.
+const selectPageSize = 15
+
+// selectFiles shows items (files with their labels) as a list to choose some by space and confirm by enter,
+// and returns the chosen files in order. Nothing is chosen at first.
+// Ctrl+C, Escape and q return ErrUserAborted.
+func selectFiles(files, labels []string) ([]string, error) {
+	reader := prompt.NewStdinReader()
+	if err := reader.Open(); err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	out := prompt.NewStderrWriter()
+
+	s := fileSelection{labels: labels, chosen: make([]bool, len(files))}
+	s.height = min(len(files), selectPageSize, max(int(reader.GetWinSize().Row)-2, 1))
+	drawn := 0
+	for {
+		if drawn > 0 {
+			out.CursorUp(drawn)
+		}
+		out.WriteRawString("\r")
+		out.EraseDown()
+		drawn = s.draw(out, int(reader.GetWinSize().Col)-1)
+		if err := out.Flush(); err != nil {
+			return nil, err
+		}
+
+		done, err := s.handle(readKeys(reader))
+		if done || err != nil {
+			out.CursorUp(drawn)
+			out.WriteRawString("\r")
+			out.EraseDown()
+			out.Flush()
+			if err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+
+	var picked []string
+	for i, f := range files {
+		if s.chosen[i] {
+			picked = append(picked, f)
+		}
+	}
+	return picked, nil
+}
+
+// readKeys waits for input of reader, which does not block.
+func readKeys(reader prompt.Reader) []byte {
+	buf := make([]byte, 1024)
+	for {
+		if n, err := reader.Read(buf); err == nil && n > 0 {
+			return buf[:n]
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// fileSelection is the state of selectFiles.
+type fileSelection struct {
+	labels []string
+	chosen []bool
+	cursor int
+	// top is the first of the height labels shown
+	top    int
+	height int
+}
+
+// draw writes the hint and the labels around the cursor within width columns, and returns the number of lines.
+func (s *fileSelection) draw(out prompt.Writer, width int) int {
+	n := 0
+	for _, c := range s.chosen {
+		if c {
+			n++
+		}
+	}
+	out.WriteRawString(truncateWidth(tr(msgSelectFiles, n, len(s.chosen)), width) + "\r\n")
+
+	for i := s.top; i < s.top+s.height; i++ {
+		cursor, check := " ", " "
+		if i == s.cursor {
+			cursor = ">"
+		}
+		if s.chosen[i] {
+			check = "x"
+		}
+		out.WriteRawString(truncateWidth(fmt.Sprintf("%s [%s] %s", cursor, check, s.labels[i]), width) + "\r\n")
+	}
+	return s.height + 1
+}
+
+// handle applies keys, and reports whether the selection is confirmed.
+func (s *fileSelection) handle(keys []byte) (bool, error) {
+	switch prompt.GetKey(keys) {
+	case prompt.Up, prompt.ControlP:
+		s.move(-1)
+		return false, nil
+	case prompt.Down, prompt.ControlN, prompt.Tab:
+		s.move(1)
+		return false, nil
+	case prompt.PageUp:
+		s.move(-s.height)
+		return false, nil
+	case prompt.PageDown:
+		s.move(s.height)
+		return false, nil
+	case prompt.Enter, prompt.ControlM:
+		return true, nil
+	case prompt.ControlC, prompt.Escape:
+		return false, withMessage(ErrUserAborted, tr(msgAborted))
+	default:
+		//nop
+	}
+
+	// typed ahead, several keys may come at once
+	for _, k := range keys {
+		switch k {
+		case ' ':
+			s.chosen[s.cursor] = !s.chosen[s.cursor]
+		case 'a':
+			all := !slices.Contains(s.chosen, false)
+			for i := range s.chosen {
+				s.chosen[i] = !all
+			}
+		case 'j':
+			s.move(1)
+		case 'k':
+			s.move(-1)
+		case '\r', '\n':
+			return true, nil
+		case 'q':
+			return false, withMessage(ErrUserAborted, tr(msgAborted))
+		default:
+			//nop
+		}
+	}
+	return false, nil
+}
+
+// move moves the cursor by delta within the labels, scrolling them to show it.
+func (s *fileSelection) move(delta int) {
+	s.cursor = max(0, min(len(s.labels)-1, s.cursor+delta))
+	if s.cursor < s.top {
+		s.top = s.cursor
+	}
+	if s.cursor >= s.top+s.height {
+		s.top = s.cursor - s.height + 1
+	}
+}
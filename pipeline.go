@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+
+	git "github.com/go-git/go-git/v5"
+)
+
+// The commit flow of Run is a pipeline of stages, each of which runs the hooks registered to it in order.
+// A feature extends the flow by registering a hook in newCommitPipeline, instead of being written into Run.
+
+// the stages of the commit flow, in the order they run
+const (
+	stageResolveConfig = "resolve-config"
+	stageStage         = "stage"
+	stageCollect       = "collect"
+	stageValidate      = "validate"
+	stageRender        = "render"
+	stageConfirm       = "confirm"
+	stageCommit        = "commit"
+	stagePersist       = "persist"
+	stageNotify        = "notify"
+)
+
+var commitStages = []string{
+	stageResolveConfig,
+	stageStage,
+	stageCollect,
+	stageValidate,
+	stageRender,
+	stageConfirm,
+	stageCommit,
+	stagePersist,
+	stageNotify,
+}
+
+// commitFlow is the state passed through the hooks of a commit.
+type commitFlow struct {
+	ctx  context.Context
+	args []string
+
+	wt *git.Worktree
+	st git.Status
+
+	msg      string
+	answered messageAnswers
+	rendered string
+
+	// noGPGSign is true if the user chose to commit without signing
+	noGPGSign bool
+
+	// native is true if the commit is made by go-git
+	native bool
+
+	// runsHooks is true if git-cx runs the message hooks, since git commit does not
+	runsHooks bool
+
+	// stopped is set by a hook to end the flow successfully, skipping the hooks after it
+	stopped bool
+
+	// cleanups are run when the flow ends, in the reverse order, whether or not it succeeded
+	cleanups []func()
+}
+
+// stop ends the flow successfully after the current hook.
+func (f *commitFlow) stop() {
+	f.stopped = true
+}
+
+// onEnd adds fn to be run when the flow ends.
+func (f *commitFlow) onEnd(fn func()) {
+	f.cleanups = append(f.cleanups, fn)
+}
+
+// committedStage reports whether the commit has been made before stage runs.
+func committedStage(stage string) bool {
+	return stage == stagePersist || stage == stageNotify
+}
+
+// flowHook is a step of the commit flow. An error ends the flow.
+type flowHook struct {
+	name string
+	fn   func(c *globalCmd, f *commitFlow) error
+}
+
+// commitPipeline is the hooks of the commit flow by stage.
+type commitPipeline struct {
+	hooks map[string][]flowHook
+}
+
+// register adds fn as the last hook of stage.
+// name describes the hook. It panics on an unknown stage since it is a programming error.
+func (p *commitPipeline) register(stage, name string, fn func(c *globalCmd, f *commitFlow) error) {
+	if !in(stage, commitStages...) {
+		panic("unknown stage: " + stage)
+	}
+	if p.hooks == nil {
+		p.hooks = make(map[string][]flowHook)
+	}
+	p.hooks[stage] = append(p.hooks[stage], flowHook{name: name, fn: fn})
+}
+
+// run runs the hooks of all the stages in order, until one fails or stops the flow.
+func (p *commitPipeline) run(c *globalCmd, f *commitFlow) error {
+	defer func() {
+		for i := len(f.cleanups) - 1; i >= 0; i-- {
+			f.cleanups[i]()
+		}
+	}()
+
+	for _, stage := range commitStages {
+		for _, h := range p.hooks[stage] {
+			// Ctrl+C ends the flow before committing; once committed, it is recorded
+			if !committedStage(stage) && f.ctx.Err() != nil {
+				return context.Cause(f.ctx)
+			}
+			if err := h.fn(c, f); err != nil {
+				return err
+			}
+			if f.stopped {
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+// newCommitPipeline returns the pipeline of Run.
+// The hooks are listed here, not registered by init, so that the order is explicit.
+func newCommitPipeline() *commitPipeline {
+	p := &commitPipeline{}
+
+	p.register(stageResolveConfig, "open repository", (*globalCmd).openFlowRepository)
+	p.register(stageResolveConfig, "disabled", (*globalCmd).passThroughIfDisabled)
+	p.register(stageResolveConfig, "rule", (*globalCmd).prepareFlow)
+	p.register(stageResolveConfig, "disabled by rule", (*globalCmd).passThroughIfDisabled)
+	p.register(stageResolveConfig, "answers", (*globalCmd).flowAnswers)
+
+	p.register(stageStage, "all", (*globalCmd).stageAll)
+	p.register(stageStage, "status", (*globalCmd).flowStatus)
+	p.register(stageStage, "partially staged", (*globalCmd).offerPartiallyStaged)
+	p.register(stageStage, "related untracked", (*globalCmd).offerRelatedUntracked)
+	p.register(stageStage, "unstaged", (*globalCmd).offerUnstaged)
+	p.register(stageStage, "staged", (*globalCmd).checkStaged)
+
+	p.register(stageCollect, "signing", (*globalCmd).checkFlowSigning)
+	p.register(stageCollect, "commit mode", (*globalCmd).decideCommitMode)
+	p.register(stageCollect, "prepare-commit-msg", (*globalCmd).prefillByHook)
+	p.register(stageCollect, "diff preview", (*globalCmd).enableDiffPreview)
+	p.register(stageCollect, "staged summary", (*globalCmd).flowStagedSummary)
+	p.register(stageCollect, "prompts", (*globalCmd).collectMessage)
+	p.register(stageCollect, "edit", (*globalCmd).editFlowMessage)
+
+	p.register(stageValidate, "signoff", (*globalCmd).flowSignoff)
+	p.register(stageValidate, "commit-msg", (*globalCmd).checkByHook)
+	p.register(stageValidate, "version hint", (*globalCmd).showVersionHint)
+
+	p.register(stageRender, "debug", (*globalCmd).printFlowDebug)
+	p.register(stageRender, "dry run", (*globalCmd).printFlowDryRun)
+
+	p.register(stageCommit, "commit", (*globalCmd).commitFlowMessage)
+
+	p.register(stagePersist, "history", (*globalCmd).persistFlow)
+
+	p.register(stageNotify, "fun facts", (*globalCmd).notifyFunFacts)
+
+	return p
+}
@@ -0,0 +1,345 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/shu-go/git-cx/internal/testutil"
+)
+
+func TestCommitPipelineInterrupted(t *testing.T) {
+	tests := []struct {
+		name        string
+		interruptAt string
+		want        []string
+		wantErr     error
+	}{
+		{name: "not interrupted", want: []string{stageCollect, stageCommit, stagePersist}},
+		{name: "before committing", interruptAt: stageCollect, want: []string{stageCollect}, wantErr: ErrInterrupted},
+		{name: "after committing", interruptAt: stageCommit, want: []string{stageCollect, stageCommit, stagePersist}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancelCause(context.Background())
+			defer cancel(nil)
+
+			var ran []string
+			p := &commitPipeline{}
+			for _, stage := range []string{stageCollect, stageCommit, stagePersist} {
+				p.register(stage, stage, func(c *globalCmd, f *commitFlow) error {
+					ran = append(ran, stage)
+					if stage == tt.interruptAt {
+						cancel(errInterrupted())
+					}
+					return nil
+				})
+			}
+
+			cleaned := false
+			f := &commitFlow{ctx: ctx}
+			f.onEnd(func() { cleaned = true })
+
+			err := p.run(&globalCmd{}, f)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Errorf("run() = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(ran, tt.want) {
+				t.Errorf("ran = %v, want %v", ran, tt.want)
+			}
+			if !cleaned {
+				t.Error("the cleanups are not run")
+			}
+		})
+	}
+}
+
+// The whole flow of git cx, from the prompts to the commit, through the pipeline.
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name    string
+		cmd     globalCmd
+		rule    string
+		answers []string
+		want    string
+	}{
+		{
+			name:    "prompts",
+			answers: []string{"feat", "api", "add retry flag", "", ""},
+			want:    "feat(api): add retry flag\n",
+		},
+		{
+			name:    "body",
+			answers: []string{"fix", "", "retry once", "the first line", "the second line", "", "the next paragraph", "", ""},
+			want:    "fix: retry once\n\nthe first line the second line\n\nthe next paragraph\n",
+		},
+		{
+			name:    "breaking change",
+			rule:    "usebreakingchange: true\n",
+			answers: []string{"feat", "api", "drop v1", "", "", "v1 is removed", ""},
+			want:    "feat(api)!: drop v1\n\nBREAKING CHANGE: v1 is removed\n",
+		},
+		{
+			name: "without prompts",
+			cmd:  globalCmd{Type: "docs", Scope: "api", Message: "describe retries"},
+			want: "docs(api): describe retries\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+			r := testutil.NewRepo(t)
+			if tt.rule != "" {
+				r.WriteFile(".cx.yaml", tt.rule)
+			}
+			r.CommitFile("README.md", "retry\n", "chore: init")
+			r.WriteFile("api/retry.go", "package api\n")
+			r.Stage("api/retry.go")
+			r.Chdir()
+			p := scriptPrompts(t, tt.answers...)
+
+			tt.cmd.Native, tt.cmd.Quiet = true, true
+			if err := tt.cmd.Run(nil); err != nil {
+				t.Fatal(err)
+			}
+			if got := r.HeadMessage(); got != tt.want {
+				t.Errorf("HEAD = %q, want %q", got, tt.want)
+			}
+			if rest := p.Remaining(); len(rest) != 0 {
+				t.Errorf("answers not asked: %q", rest)
+			}
+			if got := r.CommitCount(); got != 2 {
+				t.Errorf("%d commits, want 2", got)
+			}
+		})
+	}
+}
+
+// A linked worktree commits to its own branch, with the config and the history of the repository.
+func TestRunInLinkedWorktree(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	r := testutil.NewRepo(t)
+	r.CommitFile("README.md", "retry\n", "chore: init")
+	wt := r.NewWorktree("topic")
+	wt.WriteFile("api/retry.go", "package api\n")
+	wt.Stage("api/retry.go")
+	wt.Chdir()
+	scriptPrompts(t, "feat", "api", "add retry flag", "", "")
+
+	c := globalCmd{Native: true, Quiet: true}
+	if err := c.Run(nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := wt.Messages(2), []string{"feat(api): add retry flag\n", "chore: init"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("topic = %q, want %q", got, want)
+	}
+	if got := r.HeadMessage(); got != "chore: init" {
+		t.Errorf("main = %q, want it untouched", got)
+	}
+}
+
+// --all commits the other changes, leaving out a submodule with new commits with --ignore-submodules.
+func TestRunAllWithSubmodule(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	r, sub := testutil.NewRepoWithSubmodule(t, "lib")
+	sub.CommitFile("lib.go", "package lib\n", "feat: add lib")
+	r.WriteFile("notes.txt", "notes\n")
+	r.Chdir()
+
+	wt, err := r.Repository.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ignore := range []bool{false, true} {
+		c := globalCmd{IgnoreSubmodules: ignore, repository: r.Repository}
+		st, err := c.worktreeStatus(context.Background(), wt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, found := st["lib"]; found == ignore {
+			t.Errorf("ignore=%v: the status of the submodule is found: %v", ignore, found)
+		}
+	}
+
+	r.CommitFile("notes.txt", "notes\n", "docs: add notes")
+	r.WriteFile("notes.txt", "more notes\n")
+	c := globalCmd{All: true, IgnoreSubmodules: true, Type: "docs", Message: "update notes", Native: true, Quiet: true}
+	if err := c.Run(nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := r.HeadMessage(); got != "docs: update notes\n" {
+		t.Errorf("HEAD = %q", got)
+	}
+	head, err := r.Repository.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit, err := r.Repository.CommitObject(head.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := changedFiles(commit); !reflect.DeepEqual(got, []string{"notes.txt"}) {
+		t.Errorf("committed %v, want [notes.txt]", got)
+	}
+}
+
+// The messages committed through the whole pipeline, from the scripted answers, are in testdata/pipeline_*.txt.
+func TestPipelineGolden(t *testing.T) {
+	tests := []struct {
+		golden  string
+		rule    string
+		cmd     globalCmd
+		answers []string
+	}{
+		{
+			golden:  "pipeline_default.txt",
+			answers: []string{"feat", "api", "add retry flag", "Failed requests are retried", "with a backoff.", "", ""},
+		},
+		{
+			golden:  "pipeline_emoji.txt",
+			rule:    `{"headerFormat": "{{.type}}{{.scope_with_parens}}{{.bang}}: {{.emoji}}{{.description}}", "types": {"feat": {"desc": "A new feature", "emoji": ":sparkles:"}}}`,
+			answers: []string{"feat", "api", "add retry flag", "", ""},
+		},
+		{
+			golden:  "pipeline_breaking.txt",
+			rule:    `{"useBreakingChange": true}`,
+			answers: []string{"feat", "api", "drop v1", "", "", "v1 is removed", "so is v0", ""},
+		},
+		{
+			golden:  "pipeline_machine_trailers.txt",
+			rule:    `{"machineTrailers": true}`,
+			answers: []string{"fix", "api", "retry once", "", ""},
+		},
+		{
+			golden: "pipeline_signoff.txt",
+			cmd:    globalCmd{Type: "docs", Scope: "api", Message: "describe retries", Signoff: true},
+		},
+	}
+	// the tests run in the fixture repositories
+	testdata, err := filepath.Abs("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+			r := testutil.NewRepo(t)
+			if tt.rule != "" {
+				r.WriteFile(".cx.json", tt.rule)
+			}
+			r.CommitFile("README.md", "retry\n", "chore: init")
+			r.WriteFile("api/retry.go", "package api\n")
+			r.Stage("api/retry.go")
+			r.Chdir()
+			scriptPrompts(t, tt.answers...)
+
+			tt.cmd.Native, tt.cmd.Quiet = true, true
+			captureStdout(t, func() {
+				if err := tt.cmd.Run(nil); err != nil {
+					t.Error(err)
+				}
+			})
+			got := r.HeadMessage()
+
+			golden := filepath.Join(testdata, tt.golden)
+			if *update {
+				if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(want) {
+				t.Errorf("HEAD =\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+// The summary of --debug is in testdata/debug_summary_*.txt, with the fixture repository as $REPO.
+func TestDebugSummaryGolden(t *testing.T) {
+	tests := []struct {
+		golden string
+		rule   string
+	}{
+		{golden: "debug_summary_default.txt"},
+		{golden: "debug_summary_rule.txt", rule: `{"headerFormat": "{{.type}}{{.scope_with_parens}}: {{.emoji}}{{.description}}", "emojiRendering": "shortcode", "types": {"feat": {"desc": "A new feature", "emoji": ":sparkles:"}}}`},
+	}
+	testdata, err := filepath.Abs("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+			r := testutil.NewRepo(t)
+			if tt.rule != "" {
+				r.WriteFile(".cx.json", tt.rule)
+			}
+			r.CommitFile("README.md", "retry\n", "chore: init")
+			r.WriteFile("api/retry.go", "package api\n")
+			r.WriteFile("README.md", "retry with a backoff\n")
+			r.Stage("api/retry.go", "README.md")
+			r.Chdir()
+
+			stderr := os.Stderr
+			f, err := os.Create(filepath.Join(t.TempDir(), "stderr"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			os.Stderr = f
+			defer func() { os.Stderr = stderr }()
+
+			c := globalCmd{Native: true, Quiet: true, Debug: true, Type: "feat", Scope: "api", Message: "add retry flag"}
+			msg := captureStdout(t, func() {
+				if err := c.Run(nil); err != nil {
+					t.Error(err)
+				}
+			})
+			if r.CommitCount() != 1 {
+				t.Error("committed with --debug")
+			}
+			if !strings.HasPrefix(msg, "feat(api): ") {
+				t.Errorf("message = %q", msg)
+			}
+
+			content, err := os.ReadFile(f.Name())
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := strings.ReplaceAll(string(content), realPath(r.Dir), "$REPO")
+			got = strings.ReplaceAll(got, filepath.ToSlash(realPath(r.Dir)), "$REPO")
+
+			golden := filepath.Join(testdata, tt.golden)
+			if *update {
+				if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(want) {
+				t.Errorf("summary =\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
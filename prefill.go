@@ -0,0 +1,122 @@
+package main
+
+import (
+	"errors"
+	"strings"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// prefillFrom parses the message of the commit rev into the initial text of the prompts.
+func (c globalCmd) prefillFrom(repos *git.Repository, rev string) (ConventionalCommit, error) {
+	msg, err := commitMessage(repos, rev)
+	if err != nil {
+		return ConventionalCommit{}, err
+	}
+	return c.prefillFromMessage(msg), nil
+}
+
+// prefillFromMessage parses msg into the initial text of the prompts.
+// If msg is not conventional, only the description is filled with its subject.
+func (c globalCmd) prefillFromMessage(msg string) ConventionalCommit {
+	cc, ok := parseCommitMessage(msg)
+	if !ok {
+		subject, _, _ := strings.Cut(msg, "\n")
+		return ConventionalCommit{Description: strings.TrimSpace(subject)}
+	}
+
+	cc.Description = c.trimEmoji(cc.Type, cc.Scope, cc.Description)
+	return cc
+}
+
+// commitMessage returns the message of the commit rev.
+func commitMessage(repos *git.Repository, rev string) (string, error) {
+	hash, err := repos.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return "", err
+	}
+	commit, err := repos.CommitObject(*hash)
+	if err != nil {
+		return "", err
+	}
+	return commit.Message, nil
+}
+
+// defaultScope returns the initial text of the scope prompt by Rule.DefaultScope.
+// It is recorded in the history only if submitted, as typed ones are.
+func (c globalCmd) defaultScope(repos *git.Repository) string {
+	switch c.rule.DefaultScope {
+	case defaultScopeLastCommit:
+		ref, err := repos.Head()
+		if err != nil {
+			return ""
+		}
+		cc, _ := c.commits.parseHash(ref.Hash())
+		return cc.Scope
+
+	case defaultScopeLastUsed:
+		if names := sortedScopes(c.scopes); len(names) > 0 {
+			return names[0]
+		}
+		return ""
+
+	default:
+		return ""
+	}
+}
+
+// answersFromArgs interprets `git cx [TYPE] [DESCRIPTION...]`.
+// If the first argument is not a type of the rule, all of them are the description,
+// unless ad-lib types are denied.
+func answersFromArgs(rule *Rule, args []string) (ConventionalCommit, error) {
+	if len(args) == 0 {
+		return ConventionalCommit{}, nil
+	}
+
+	if typ := rule.canonicalType(args[0]); rule.Types.Contains(typ) && !strings.HasPrefix(typ, "#") {
+		return ConventionalCommit{
+			Type:        typ,
+			Description: strings.TrimSpace(strings.Join(args[1:], " ")),
+		}, nil
+	}
+
+	if rule.DenyAdlibType {
+		return ConventionalCommit{}, errors.New(tr(msgUnknownTypeArg, args[0]))
+	}
+	return ConventionalCommit{Description: strings.TrimSpace(strings.Join(args, " "))}, nil
+}
+
+// answersFromFlags overrides the answers by --type, --scope, --message, --body and --breaking.
+// The type and the breaking change are validated here, since no prompts re-ask them.
+func (c *globalCmd) answersFromFlags() error {
+	if c.Type != "" {
+		c.Type = c.rule.canonicalType(c.Type)
+		if _, found := c.rule.Types.Get(c.Type); strings.HasPrefix(c.Type, "#") || (!found && c.rule.DenyAdlibType) {
+			return withMessage(ErrInvalidMessage, tr(msgUnknownValue, "type", c.Type, strings.Join(c.rule.typeNames(), ", ")))
+		}
+		c.given.Type = c.Type
+	}
+	if scope := strings.TrimSpace(c.Scope); scope != "" {
+		c.given.Scope = scope
+	}
+	if desc := strings.TrimSpace(c.Message); desc != "" {
+		c.given.Description = desc
+	}
+	if body := strings.TrimSpace(c.Body); body != "" {
+		c.given.Body = body
+	}
+	if bc := strings.TrimSpace(c.Breaking); bc != "" {
+		if ct, found := c.rule.Types.Get(c.given.Type); found && ct.Breaking == breakingNever {
+			return withMessage(ErrInvalidMessage, tr(msgLintBreakingDenied, c.given.Type))
+		}
+		c.given.BreakingChanges = []string{bc}
+	}
+	return nil
+}
+
+// promptless reports whether all the prompts are skipped, for scripts and editor integrations.
+// The type and the description are enough; the others are left empty unless given.
+func (c globalCmd) promptless() bool {
+	return c.Type != "" && strings.TrimSpace(c.Message) != ""
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// profiler records how long the phases of a run take, for --profile.
+// A nil profiler records nothing, so that measuring costs nothing unless enabled.
+type profiler struct {
+	start  time.Time
+	phases []profilePhase
+}
+
+type profilePhase struct {
+	name     string
+	duration time.Duration
+}
+
+func newProfiler(enabled bool) *profiler {
+	if !enabled {
+		return nil
+	}
+	return &profiler{start: time.Now()}
+}
+
+func nop() {}
+
+// measure starts the phase name and returns the function that ends it.
+func (p *profiler) measure(name string) func() {
+	if p == nil {
+		return nop
+	}
+
+	start := time.Now()
+	return func() {
+		p.phases = append(p.phases, profilePhase{name: name, duration: time.Since(start)})
+	}
+}
+
+// print writes the phases in the order they ended, and the total.
+// The labels are not translated so that the output can be compared.
+func (p *profiler) print(w io.Writer) {
+	if p == nil {
+		return
+	}
+
+	fmt.Fprintln(w, "[profile]")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, ph := range p.phases {
+		fmt.Fprintf(tw, "%s\t%s\n", ph.name, ph.duration.Round(time.Microsecond))
+	}
+	fmt.Fprintf(tw, "total\t%s\n", time.Since(p.start).Round(time.Microsecond))
+	tw.Flush()
+	fmt.Fprintln(w, "[/profile]")
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	prompt "github.com/elk-language/go-prompt"
+	pstrings "github.com/elk-language/go-prompt/strings"
+)
+
+// Prompter reads the answers: in the terminal, or from a script in tests (see internal/testutil).
+type Prompter interface {
+	// Input asks a line by the prompt made of opts.
+	// Ctrl+C and Ctrl+D on an empty line result in ErrInterrupted.
+	Input(opts ...prompt.Option) (string, error)
+
+	// ReadLine reads a line of the body, which is not asked by a prompt.
+	// Ctrl+D results in io.EOF, and ctx done first in its cause.
+	ReadLine(ctx context.Context) (string, error)
+}
+
+// prompter reads all the answers.
+var prompter Prompter = &terminalPrompter{}
+
+// promptInput asks a line by prompter, writing the warnings before the prompt is rendered.
+func promptInput(opts ...prompt.Option) (string, error) {
+	flushWarnings()
+	return prompter.Input(opts...)
+}
+
+// terminalPrompter asks by go-prompt and reads the body from stdin.
+type terminalPrompter struct {
+	stdin *bufio.Reader
+}
+
+// Input is prompt.Input.
+//
+// prompt.Input returns "" both for an empty answer and for Ctrl+D, and keeps going on Ctrl+C.
+// Here Ctrl+C and Ctrl+D on an empty line cancel the prompt with ErrInterrupted,
+// so that the caller can abort the whole flow instead of taking "" as an answer.
+//
+// While stagedDiff is set, F2 and the answer ?diff show the staged diff and ask again (see diffpreview.go).
+func (p *terminalPrompter) Input(opts ...prompt.Option) (string, error) {
+	var typed *string
+	var cursor pstrings.RuneNumber
+	for {
+		entered, canceled, diff := false, false, false
+		o := append(opts[:len(opts):len(opts)],
+			prompt.WithExecuteOnEnterCallback(func(*prompt.Prompt, int) (int, bool) {
+				entered = true
+				return 0, true
+			}),
+			prompt.WithKeyBind(prompt.KeyBind{
+				Key: prompt.ControlC,
+				Fn: func(*prompt.Prompt) bool {
+					canceled = true
+					return false
+				},
+			}),
+			prompt.WithExitChecker(func(string, bool) bool {
+				return canceled || diff
+			}),
+		)
+		if stagedDiff != nil {
+			o = append(o, prompt.WithKeyBind(prompt.KeyBind{
+				Key: prompt.F2,
+				Fn: func(p *prompt.Prompt) bool {
+					diff = true
+					text := p.Buffer().Text()
+					typed, cursor = &text, p.Buffer().Document().CurrentRuneIndex()
+					return false
+				},
+			}))
+		}
+		if typed != nil {
+			o = append(o, withText(*typed, cursor))
+		}
+
+		answer := prompt.Input(o...)
+		if entered && stagedDiff != nil && strings.TrimSpace(answer) == diffToken {
+			// as if the prompt was just shown
+			diff, typed = true, nil
+		}
+		if diff {
+			if err := stagedDiff(); err != nil {
+				fmt.Fprintln(os.Stderr, tr(msgDiffFailed, err))
+			}
+			continue
+		}
+
+		if canceled || !entered {
+			return "", errInterrupted()
+		}
+		return answer, nil
+	}
+}
+
+func (p *terminalPrompter) ReadLine(ctx context.Context) (string, error) {
+	if p.stdin == nil {
+		p.stdin = bufio.NewReader(os.Stdin)
+	}
+	return readLineContext(ctx, p.stdin)
+}
+
+// withText replaces the text of the prompt, such as the initial text, by text with the cursor at cursor.
+func withText(text string, cursor pstrings.RuneNumber) prompt.Option {
+	return func(p *prompt.Prompt) error {
+		p.DeleteBeforeCursorRunes(p.Buffer().Document().CurrentRuneIndex())
+		p.DeleteRunes(pstrings.RuneCountInString(p.Buffer().Text()))
+		p.InsertTextMoveCursor(text, false)
+		p.CursorLeftRunes(pstrings.RuneCountInString(text) - cursor)
+		return nil
+	}
+}
+
+func errInterrupted() error {
+	return withMessage(ErrInterrupted, tr(msgInterrupted))
+}
@@ -0,0 +1,326 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Unknown keys in a rule file are kept in Rule.Extra and CommitType.Extra so
+// that rewriting the file (gen --from-rule, ...) does not drop them.
+// JSON values are held as yaml.Node too, since JSON is a subset of YAML.
+
+type rawRule Rule
+type rawCommitType CommitType
+
+func (r *Rule) UnmarshalYAML(value *yaml.Node) error {
+	if err := value.Decode((*rawRule)(r)); err != nil {
+		return err
+	}
+
+	r.Extra = unknownYAMLKeys(value, reflect.TypeOf(rawRule{}))
+	return nil
+}
+
+func (r Rule) MarshalYAML() (any, error) {
+	node := yaml.Node{}
+	if err := node.Encode(rawRule(r)); err != nil {
+		return nil, err
+	}
+
+	appendYAMLExtra(&node, r.Extra)
+	return &node, nil
+}
+
+func (r *Rule) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, (*rawRule)(r)); err != nil {
+		return err
+	}
+
+	extra, err := unknownJSONKeys(b, reflect.TypeOf(rawRule{}))
+	if err != nil {
+		return err
+	}
+	r.Extra = extra
+	return nil
+}
+
+func (r Rule) MarshalJSON() ([]byte, error) {
+	b, err := json.Marshal(rawRule(r))
+	if err != nil {
+		return nil, err
+	}
+
+	return appendJSONExtra(b, r.Extra)
+}
+
+func (c *CommitType) UnmarshalYAML(value *yaml.Node) error {
+	ct := rawCommitType{}
+	if err := value.Decode(&ct); err != nil {
+		return err
+	}
+
+	ct.Extra = unknownYAMLKeys(value, reflect.TypeOf(rawCommitType{}))
+	*c = CommitType(ct)
+	return nil
+}
+
+func (c CommitType) MarshalYAML() (any, error) {
+	node := yaml.Node{}
+	if err := node.Encode(rawCommitType(c)); err != nil {
+		return nil, err
+	}
+
+	appendYAMLExtra(&node, c.Extra)
+	return &node, nil
+}
+
+func (c *CommitType) UnmarshalJSON(b []byte) error {
+	// c may be reused by the decoder of OrderedMap
+	ct := rawCommitType{}
+	if err := json.Unmarshal(b, &ct); err != nil {
+		return err
+	}
+
+	extra, err := unknownJSONKeys(b, reflect.TypeOf(rawCommitType{}))
+	if err != nil {
+		return err
+	}
+	ct.Extra = extra
+	*c = CommitType(ct)
+	return nil
+}
+
+func (c CommitType) MarshalJSON() ([]byte, error) {
+	b, err := json.Marshal(rawCommitType(c))
+	if err != nil {
+		return nil, err
+	}
+
+	return appendJSONExtra(b, c.Extra)
+}
+
+// rawField is a key unknown to this version and its value.
+type rawField struct {
+	Key   string
+	Value yaml.Node
+
+	// Preceding are the keys before it in the file, nearest first,
+	// to write it back after the nearest of them that is written.
+	Preceding []string
+}
+
+func unknownYAMLKeys(value *yaml.Node, t reflect.Type) []rawField {
+	if value.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	known := fieldNames(t, "yaml")
+
+	var extra []rawField
+	var preceding []string
+	for i := 0; i+1 < len(value.Content); i += 2 {
+		k := value.Content[i].Value
+		if !known[k] {
+			extra = append(extra, rawField{Key: k, Value: *value.Content[i+1], Preceding: preceding})
+		}
+		preceding = append([]string{k}, preceding...)
+	}
+	return extra
+}
+
+func appendYAMLExtra(node *yaml.Node, extra []rawField) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+
+	for _, f := range extra {
+		keys := make([]string, 0, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			keys = append(keys, node.Content[i].Value)
+		}
+		at := 2 * insertionIndex(keys, f.Preceding)
+
+		v := f.Value
+		node.Content = slices.Insert(node.Content, at,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: f.Key},
+			&v,
+		)
+	}
+}
+
+func unknownJSONKeys(b []byte, t reflect.Type) ([]rawField, error) {
+	fields, _, err := jsonFields(b)
+	if err != nil {
+		return nil, err
+	}
+
+	known := fieldNames(t, "json")
+
+	var extra []rawField
+	var preceding []string
+	for _, f := range fields {
+		k := f.key
+		prev := preceding
+		preceding = append([]string{k}, preceding...)
+
+		// encoding/json matches keys case-insensitively
+		if known[strings.ToLower(k)] {
+			continue
+		}
+
+		doc := yaml.Node{}
+		if err := yaml.Unmarshal(f.value, &doc); err != nil {
+			return nil, err
+		}
+		if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+			continue
+		}
+		clearStyle(doc.Content[0])
+		extra = append(extra, rawField{Key: k, Value: *doc.Content[0], Preceding: prev})
+	}
+	return extra, nil
+}
+
+func appendJSONExtra(b []byte, extra []rawField) ([]byte, error) {
+	if len(extra) == 0 {
+		return b, nil
+	}
+
+	fields, ok, err := jsonFields(b)
+	if err != nil || !ok {
+		return b, err
+	}
+
+	for _, f := range extra {
+		var v any
+		if err := f.Value.Decode(&v); err != nil {
+			return nil, err
+		}
+		vb, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+
+		keys := make([]string, 0, len(fields))
+		for _, jf := range fields {
+			keys = append(keys, jf.key)
+		}
+		fields = slices.Insert(fields, insertionIndex(keys, f.Preceding), jsonField{key: f.Key, value: vb})
+	}
+
+	buf := bytes.Buffer{}
+	buf.WriteByte('{')
+	for i, f := range fields {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		kb, err := json.Marshal(f.key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(kb)
+		buf.WriteByte(':')
+		buf.Write(f.value)
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+// insertionIndex returns where to insert a key in keys, right after the nearest of preceding found.
+// Keys are compared case-insensitively, since those in a JSON file may differ in case from the fields.
+func insertionIndex(keys, preceding []string) int {
+	for _, p := range preceding {
+		for i, k := range keys {
+			if strings.EqualFold(k, p) {
+				return i + 1
+			}
+		}
+	}
+	return 0
+}
+
+type jsonField struct {
+	key   string
+	value json.RawMessage
+}
+
+// jsonFields returns the members of the JSON object b in order.
+// ok is false if b is not an object.
+func jsonFields(b []byte) (fields []jsonField, ok bool, err error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, false, err
+	}
+	if tok != json.Delim('{') {
+		return nil, false, nil
+	}
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, false, err
+		}
+		k, _ := tok.(string)
+
+		var v json.RawMessage
+		if err := dec.Decode(&v); err != nil {
+			return nil, false, err
+		}
+		fields = append(fields, jsonField{key: k, value: v})
+	}
+	return fields, true, nil
+}
+
+// clearStyle drops the JSON flavored styles (quoted, flow) so that the value
+// looks natural when written in YAML.
+func clearStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, c := range node.Content {
+		clearStyle(c)
+	}
+}
+
+// fieldNames returns the keys that the encoder for tag (yaml or json) uses for
+// the fields of t. JSON names are lowercased to be compared case-insensitively.
+func fieldNames(t reflect.Type, tag string) map[string]bool {
+	names := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(f.Tag.Get(tag), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+			if tag == "yaml" {
+				name = strings.ToLower(name)
+			}
+		}
+		if tag == "json" {
+			name = strings.ToLower(name)
+		}
+		names[name] = true
+	}
+	return names
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// keysIn returns the keys of the mapping node in order, which are in names.
+func keysIn(node *yaml.Node, names []string) []string {
+	if node.Kind == yaml.DocumentNode {
+		node = node.Content[0]
+	}
+	var keys []string
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		for _, n := range names {
+			if node.Content[i].Value == n {
+				keys = append(keys, n)
+			}
+		}
+	}
+	return keys
+}
+
+func TestRuleExtraRoundTrip(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		unmarshal func([]byte, any) error
+		marshal   func(any) ([]byte, error)
+		wantRule  []string
+		wantType  []string
+	}{
+		{
+			name: "yaml",
+			content: `x-first: 1
+headerformat: '{{.type}}: {{.description}}'
+x-team: core
+x-owner: [a, b]
+types:
+  feat:
+    x-icon: star
+    desc: A new feature
+    x-color: {fg: green}
+    emoji: ':sparkles:'
+x-last: true
+`,
+			unmarshal: yaml.Unmarshal,
+			marshal:   yaml.Marshal,
+			wantRule:  []string{"x-first", "headerformat", "x-team", "x-owner", "types", "x-last"},
+			wantType:  []string{"x-icon", "desc", "x-color", "emoji"},
+		},
+		{
+			name: "json",
+			content: `{
+  "x-first": 1,
+  "headerFormat": "{{.type}}: {{.description}}",
+  "x-team": "core",
+  "x-owner": ["a", "b"],
+  "types": {
+    "feat": {"x-icon": "star", "description": "A new feature", "x-color": {"fg": "green"}, "emoji": ":sparkles:"}
+  },
+  "x-last": true
+}`,
+			unmarshal: json.Unmarshal,
+			marshal:   func(v any) ([]byte, error) { return json.MarshalIndent(v, "", "  ") },
+			wantRule:  []string{"x-first", "headerFormat", "x-team", "x-owner", "types", "x-last"},
+			wantType:  []string{"x-icon", "description", "x-color", "emoji"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rule Rule
+			if err := tt.unmarshal([]byte(tt.content), &rule); err != nil {
+				t.Fatal(err)
+			}
+			b, err := tt.marshal(rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// JSON is read as YAML to see the order of the keys
+			var doc yaml.Node
+			if err := yaml.Unmarshal(b, &doc); err != nil {
+				t.Fatal(err)
+			}
+			if got := keysIn(&doc, tt.wantRule); !reflect.DeepEqual(got, tt.wantRule) {
+				t.Errorf("rule keys = %v, want %v\n%s", got, tt.wantRule, b)
+			}
+			types := mappingValue(doc.Content[0], "types")
+			if types == nil {
+				t.Fatalf("no types\n%s", b)
+			}
+			feat := mappingValue(types, "feat")
+			if got := keysIn(feat, tt.wantType); !reflect.DeepEqual(got, tt.wantType) {
+				t.Errorf("type keys = %v, want %v\n%s", got, tt.wantType, b)
+			}
+
+			// and once more, as it is
+			var again Rule
+			if err := tt.unmarshal(b, &again); err != nil {
+				t.Fatal(err)
+			}
+			b2, err := tt.marshal(again)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b2) != string(b) {
+				t.Errorf("second round trip =\n%s\nwant\n%s", b2, b)
+			}
+		})
+	}
+}
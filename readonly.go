@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// In a read-only checkout, such as a CI workspace or a mounted volume, git-cx runs in the read-only mode:
+// nothing is persisted (the scope and description history and the body autosave),
+// instead of failing with warnings here and there after the prompts.
+
+// unwritablePaths returns the files among filenames that can not be written.
+func unwritablePaths(filenames ...string) []string {
+	var paths []string
+	for _, f := range filenames {
+		if f != "" && !writable(f) {
+			paths = append(paths, f)
+		}
+	}
+	return paths
+}
+
+// writable reports whether filename can be overwritten, or created with its missing parent directories.
+func writable(filename string) bool {
+	if f, err := os.OpenFile(longPath(filename), os.O_WRONLY, 0); err == nil {
+		f.Close()
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return false
+	}
+
+	// the nearest existing directory, where a temporary file or the missing directories are created
+	dir := filepath.Dir(filename)
+	for {
+		if _, err := os.Stat(longPath(dir)); err == nil {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return false
+		}
+		dir = parent
+	}
+
+	probe, err := os.CreateTemp(longPath(dir), ".cx-probe-*")
+	if err != nil {
+		return false
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+	return true
+}
+
+// readOnly reports whether the persistence is skipped.
+func (c globalCmd) readOnly() bool {
+	return len(c.readOnlyPaths) > 0
+}
+
+// persists reports whether the history is written: not in the read-only mode, by --dry-run nor by compose.
+func (c globalCmd) persists() bool {
+	return !c.readOnly() && !c.DryRun && !c.composing
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// A rule file can be published at an https URL, set as gitconfig cx.rule.
+// The fetched file is cached in the user config directory and refetched after remoteRuleTTL,
+// asking the server by the ETag whether it has changed.
+// When the fetch fails, the cached copy is used however old it is, and the fetch is retried after remoteRuleRetry.
+
+const (
+	remoteRuleTimeout  = 3 * time.Second
+	remoteRuleTTL      = time.Hour
+	remoteRuleRetry    = 5 * time.Minute
+	remoteRuleCacheDir = "rules"
+)
+
+// remoteRuleCache is the cache file of a remote rule file.
+type remoteRuleCache struct {
+	URL       string    `json:"url"`
+	ETag      string    `json:"etag,omitempty"`
+	FetchedAt time.Time `json:"fetchedAt"`
+	CheckedAt time.Time `json:"checkedAt"`
+	Content   string    `json:"content"`
+
+	// LastError is the error of the last fetch, "" if it succeeded
+	LastError string `json:"lastError,omitempty"`
+}
+
+// isRuleURL reports whether cx.rule is a URL rather than a path.
+func isRuleURL(s string) bool {
+	return strings.Contains(s, "://")
+}
+
+// readRemoteRule reads the rule file at rawURL through the cache, with the rule files it extends.
+// It fails only if neither the URL nor the cache can be read.
+func readRemoteRule(rawURL string, limit int64) (*Rule, error) {
+	content, err := readRemoteRuleContent(rawURL, limit)
+	if err != nil {
+		return nil, err
+	}
+	r, err := parseRule(ruleRefName(rawURL), content)
+	if err != nil {
+		return nil, err
+	}
+	if r, err = extendRule(r, rawURL, content, limit, []string{rawURL}); err != nil {
+		return nil, err
+	}
+	if err := normalizeRule(r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// readRemoteRuleContent reads the content of the rule file at rawURL through the cache.
+// Fetched content that can not be parsed is not cached, and the cached one is used instead.
+func readRemoteRuleContent(rawURL string, limit int64) ([]byte, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "https" {
+		return nil, errors.New(tr(msgRuleURLNotHTTPS, u.Scheme))
+	}
+
+	cacheFile := remoteRuleCachePath(rawURL)
+	cache := readRemoteRuleCache(cacheFile)
+
+	if cache != nil && !cache.expired() {
+		return []byte(cache.Content), nil
+	}
+
+	fetched, ferr := fetchRemoteRule(rawURL, cache, limit)
+	if ferr == nil {
+		_, err := parseRule(u.Path, []byte(fetched.Content))
+		if err == nil {
+			if cacheFile != "" {
+				writeRemoteRuleCache(cacheFile, fetched)
+			}
+			return []byte(fetched.Content), nil
+		}
+		ferr = err
+	}
+
+	if cache == nil {
+		return nil, ferr
+	}
+	if cacheFile != "" {
+		cache.CheckedAt = time.Now()
+		cache.LastError = ferr.Error()
+		writeRemoteRuleCache(cacheFile, cache)
+	}
+	warn(tr(msgRuleURLCached, rawURL, humanizeAge(time.Since(cache.FetchedAt)), ferr))
+	return []byte(cache.Content), nil
+}
+
+// fetchRemoteRule gets the rule file at rawURL.
+// If the server tells that it has not changed since cache, cache is returned as fetched now.
+func fetchRemoteRule(rawURL string, cache *remoteRuleCache, limit int64) (*remoteRuleCache, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), remoteRuleTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if cache != nil && cache.ETag != "" {
+		req.Header.Set("If-None-Match", cache.ETag)
+	}
+
+	client := http.Client{
+		// not to be redirected to http
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if req.URL.Scheme != "https" {
+				return errors.New(tr(msgRuleURLNotHTTPS, req.URL.String()))
+			}
+			if len(via) >= 10 {
+				return errors.New("too many redirects")
+			}
+			return nil
+		},
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cache != nil:
+		fetched := *cache
+		fetched.FetchedAt = time.Now()
+		fetched.CheckedAt = fetched.FetchedAt
+		fetched.LastError = ""
+		return &fetched, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("%s: %s", rawURL, resp.Status)
+	}
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(content)) > limit {
+		size := resp.ContentLength
+		if size < 0 {
+			size = int64(len(content))
+		}
+		return nil, &fileTooLargeError{Name: rawURL, Size: size, Limit: limit}
+	}
+
+	now := time.Now()
+	return &remoteRuleCache{
+		URL:       rawURL,
+		ETag:      resp.Header.Get("ETag"),
+		FetchedAt: now,
+		CheckedAt: now,
+		Content:   string(content),
+	}, nil
+}
+
+// expired reports whether to fetch again: after remoteRuleTTL, or remoteRuleRetry if the last fetch failed.
+func (c remoteRuleCache) expired() bool {
+	if c.LastError != "" {
+		return time.Since(c.CheckedAt) >= remoteRuleRetry
+	}
+	return time.Since(c.CheckedAt) >= remoteRuleTTL
+}
+
+// remoteRuleCachePath returns the cache file of rawURL in the user config directory, or "" if unknown.
+func remoteRuleCachePath(rawURL string) string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	h := sha256.Sum256([]byte(rawURL))
+	return filepath.Join(dir, userConfigFolder, remoteRuleCacheDir, hex.EncodeToString(h[:8])+".json")
+}
+
+func readRemoteRuleCache(filename string) *remoteRuleCache {
+	if filename == "" {
+		return nil
+	}
+	content, err := os.ReadFile(longPath(filename))
+	if err != nil {
+		return nil
+	}
+	cache := remoteRuleCache{}
+	if err := json.Unmarshal(content, &cache); err != nil {
+		return nil
+	}
+	return &cache
+}
+
+// writeRemoteRuleCache writes cache, ignoring errors since the cache is only an optimization and a fallback.
+func writeRemoteRuleCache(filename string, cache *remoteRuleCache) {
+	content, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(longPath(filepath.Dir(filename)), 0o755); err != nil {
+		return
+	}
+	_ = writeFileAtomic(filename, content)
+}
+
+// remoteRuleStatus describes the cache of rawURL like `fetched 5m0s ago`, for --debug.
+func remoteRuleStatus(rawURL string) string {
+	cache := readRemoteRuleCache(remoteRuleCachePath(rawURL))
+	if cache == nil {
+		return "not cached"
+	}
+	status := fmt.Sprintf("fetched %v ago", time.Since(cache.FetchedAt).Round(time.Second))
+	if cache.LastError != "" {
+		status += ", last fetch failed: " + cache.LastError
+	}
+	return status
+}
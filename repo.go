@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"os"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+)
+
+// openRepository opens the repository containing the working directory and selects the language.
+// Outside a repository, it returns ErrNoRepository with the message telling where it searched.
+func (c globalCmd) openRepository() (*git.Repository, error) {
+	repos, err := plainOpen(".")
+	setLang(string(c.Lang), repos)
+	if errors.Is(err, git.ErrRepositoryNotExists) {
+		wd, wdErr := os.Getwd()
+		if wdErr != nil {
+			wd = "."
+		}
+		return nil, withMessage(ErrNoRepository, tr(msgNotRepository, wd))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return repos, nil
+}
+
+// plainOpen opens the repository containing dir.
+// A linked worktree (git worktree add) is opened with the common git directory,
+// where its config, refs and objects are.
+func plainOpen(dir string) (*git.Repository, error) {
+	return git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true, EnableDotGitCommonDir: true})
+}
+
+// gitConfigOption returns the option key of section in the gitconfig of repos, or else in the global one.
+func gitConfigOption(repos *git.Repository, section, key string) string {
+	if repos != nil {
+		if cfg, err := repos.Config(); err == nil {
+			if v := cfg.Raw.Section(section).Option(key); v != "" {
+				return v
+			}
+		}
+	}
+	if cfg, err := config.LoadConfig(config.GlobalScope); err == nil {
+		return cfg.Raw.Section(section).Option(key)
+	}
+	return ""
+}
+
+// commentChar returns core.commentChar of repos, # if it is not set.
+// auto, which lets git choose a character not in the message, is # too, since what git chose is not known.
+func commentChar(repos *git.Repository) string {
+	switch c := gitConfigOption(repos, "core", "commentChar"); c {
+	case "", "auto":
+		return "#"
+	default:
+		return c
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Before scopes rm, rename, prune and clear change the scope history file, its content is backed up
+// in the user config directory, a directory for each history file:
+//
+//	git-cx/scope-backups/<hash of the path>/20240102T150405.000000000Z.yaml
+//	git-cx/scope-backups/<hash of the path>/state.json
+//
+// state.json tells the history file and the hash of what git-cx wrote to it last,
+// so that scopes undo does not overwrite changes made by others (an editor, a merge) since.
+
+const (
+	scopeBackupsFolder   = "scope-backups"
+	scopeBackupStateFile = "state.json"
+	scopeBackupTimestamp = "20060102T150405.000000000Z"
+)
+
+// scopeBackupLimit is the number of backups kept for a history file.
+const scopeBackupLimit = 10
+
+// scopeBackupState is state.json of the backups of a history file.
+type scopeBackupState struct {
+	File    string `json:"file"`
+	Written string `json:"written"`
+}
+
+// scopeBackupDir returns the directory of the backups of the history file filename, or "" if unknown.
+func scopeBackupDir(filename string) string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(realPath(abs)))
+	return filepath.Join(dir, userConfigFolder, scopeBackupsFolder, hex.EncodeToString(sum[:8]))
+}
+
+// contentHash returns the hash of content in state.json.
+func contentHash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// backupScopesFile backs up the history file filename before it is changed, and drops the old backups.
+// A file not written yet has nothing to back up.
+func backupScopesFile(filename string) error {
+	content, err := os.ReadFile(longPath(filename))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	dir := scopeBackupDir(filename)
+	if dir == "" {
+		return errors.New(tr(msgScopeBackupNoDir))
+	}
+	if err := os.MkdirAll(longPath(dir), 0o755); err != nil {
+		return err
+	}
+
+	name := time.Now().UTC().Format(scopeBackupTimestamp) + fileExt(filename)
+	if err := writeFileAtomic(filepath.Join(dir, name), content); err != nil {
+		return err
+	}
+	// until the change following is written, the file is the backup
+	if err := writeScopeBackupState(dir, filename, content); err != nil {
+		return err
+	}
+
+	backups := scopeBackups(dir)
+	for _, old := range backups[:max(0, len(backups)-scopeBackupLimit)] {
+		os.Remove(longPath(filepath.Join(dir, old)))
+	}
+	return nil
+}
+
+// recordScopesWritten records content as written by git-cx to the history file filename, if it has backups.
+func recordScopesWritten(filename string, content []byte) {
+	dir := scopeBackupDir(filename)
+	if dir == "" {
+		return
+	}
+	if _, err := os.Stat(longPath(filepath.Join(dir, scopeBackupStateFile))); err != nil {
+		return
+	}
+	writeScopeBackupState(dir, filename, content)
+}
+
+func writeScopeBackupState(dir, filename string, written []byte) error {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return err
+	}
+	content, err := json.MarshalIndent(scopeBackupState{File: abs, Written: contentHash(written)}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(dir, scopeBackupStateFile), content)
+}
+
+func readScopeBackupState(dir string) scopeBackupState {
+	var state scopeBackupState
+	if content, err := os.ReadFile(longPath(filepath.Join(dir, scopeBackupStateFile))); err == nil {
+		json.Unmarshal(content, &state)
+	}
+	return state
+}
+
+// scopeBackups returns the names of the backups in dir, oldest first.
+func scopeBackups(dir string) []string {
+	entries, err := os.ReadDir(longPath(dir))
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+	for _, e := range entries {
+		if _, ok := scopeBackupTime(e.Name()); ok && e.Type().IsRegular() {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// scopeBackupTime returns when the backup name was made.
+func scopeBackupTime(name string) (time.Time, bool) {
+	stamp := strings.TrimSuffix(name, filepath.Ext(name))
+	t, err := time.Parse(scopeBackupTimestamp, stamp)
+	return t, err == nil
+}
+
+// undoScopesFile restores the newest backup of the history file filename after a confirmation, and removes the backup.
+// It refuses if the file is changed since git-cx wrote it last, not to lose the change.
+func undoScopesFile(filename string) error {
+	dir := scopeBackupDir(filename)
+	backups := scopeBackups(dir)
+	if len(backups) == 0 {
+		return errors.New(tr(msgScopeNoBackup, filename))
+	}
+	newest := filepath.Join(dir, backups[len(backups)-1])
+	backup, err := os.ReadFile(longPath(newest))
+	if err != nil {
+		return err
+	}
+
+	current, err := os.ReadFile(longPath(filename))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if h := contentHash(current); h != readScopeBackupState(dir).Written && h != contentHash(backup) {
+		return errors.New(tr(msgScopeUndoModified, filename))
+	}
+
+	t, _ := scopeBackupTime(filepath.Base(newest))
+	ok, err := confirm(tr(msgScopeUndoAsk, filename, humanizeAge(time.Since(t))), false)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return withMessage(ErrUserAborted, tr(msgAborted))
+	}
+
+	if err := writeFileAtomic(filename, backup); err != nil {
+		return err
+	}
+	recordScopesWritten(filename, backup)
+	return os.Remove(longPath(newest))
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// scopeLogDepth is how many commits are looked up for scopes without recorded paths.
+const scopeLogDepth = 100
+
+// rankedScopes returns the scopes newest first.
+// With scopeFilter: stagedPaths, the scopes used for the staged directories come first.
+// Scopes are never hidden, so that the list falls back to the normal one when nothing matches.
+func (c globalCmd) rankedScopes(ctx context.Context) []string {
+	names := sortedScopes(c.scopes)
+	if c.rule == nil || c.rule.ScopeFilter != scopeFilterStagedPaths {
+		return names
+	}
+
+	staged := stagedDirs(c.scopeFiles(), c.rule.StagedDirsDepth)
+	if len(staged) == 0 {
+		return names
+	}
+
+	var logPaths map[string][]string
+	for _, s := range names {
+		if len(c.scopes[s].Paths) == 0 {
+			// legacy entries
+			logPaths = scopePathsFromLog(ctx, c.commits, scopeLogDepth, c.rule.StagedDirsDepth)
+			break
+		}
+	}
+
+	var matched, others []string
+	for _, s := range names {
+		paths := c.scopes[s].Paths
+		if len(paths) == 0 {
+			paths = logPaths[s]
+		}
+		if pathsOverlap(paths, staged) {
+			matched = append(matched, s)
+		} else {
+			others = append(others, s)
+		}
+	}
+	return append(matched, others...)
+}
+
+// pathScopeParents are the directories whose subdirectories are scopes, rather than themselves.
+var pathScopeParents = []string{"cmd", "pkg", "internal"}
+
+// scopesFromPaths returns the top-level directories of files as scopes,
+// or the second-level ones under pathScopeParents, in the order of files.
+func scopesFromPaths(files []string) []string {
+	var scopes []string
+	for _, dir := range stagedDirs(files, 2) {
+		elems := strings.Split(dir, "/")
+		scope := elems[0]
+		if in(scope, pathScopeParents...) {
+			if len(elems) < 2 {
+				continue
+			}
+			scope = elems[1]
+		}
+		if !in(scope, scopes...) {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
+
+// pathsOverlap reports whether any of a is b or an ancestor or a descendant of any of b.
+func pathsOverlap(a, b []string) bool {
+	for _, pa := range a {
+		for _, pb := range b {
+			if pa == pb || strings.HasPrefix(pa, pb+"/") || strings.HasPrefix(pb, pa+"/") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// scopePathsFromLog returns the directories (up to dirsDepth levels) changed by the latest depth commits
+// per scope in their headers.
+// Errors result in what has been found so far, but nil if ctx is done.
+func scopePathsFromLog(ctx context.Context, log *commitLog, depth, dirsDepth int) map[string][]string {
+	paths := make(map[string][]string)
+
+	for _, commit := range log.latest(ctx, depth) {
+		if ctx.Err() != nil {
+			break
+		}
+		cc, ok := log.parse(commit)
+		if !ok || cc.Scope == "" {
+			continue
+		}
+
+		for _, d := range stagedDirs(log.changedFiles(commit), dirsDepth) {
+			if !in(d, paths[cc.Scope]...) {
+				paths[cc.Scope] = append(paths[cc.Scope], d)
+			}
+		}
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	return paths
+}
+
+// changedFiles returns the files changed by commit (against its first parent).
+func changedFiles(commit *object.Commit) []string {
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil
+	}
+
+	var parentTree *object.Tree
+	if parent, err := commit.Parent(0); err == nil {
+		parentTree, _ = parent.Tree()
+	}
+
+	changes, err := object.DiffTree(parentTree, tree)
+	if err != nil {
+		return nil
+	}
+
+	var files []string
+	for _, ch := range changes {
+		name := ch.To.Name
+		if name == "" {
+			name = ch.From.Name
+		}
+		files = append(files, name)
+	}
+	sort.Strings(files)
+	return files
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	git "github.com/go-git/go-git/v5"
+)
+
+// defaultScopeHistoryLimit is the number of entries kept in the scope history if Rule.ScopeHistoryLimit is 0.
+const defaultScopeHistoryLimit = 50
+
+const (
+	configScopeHistoryLimit  = "scopeHistoryLimit"
+	configScopeHistoryMaxAge = "scopeHistoryMaxAge"
+)
+
+// scopePruning is the policy of dropping entries from the scope history.
+type scopePruning struct {
+	// Limit is the number of entries kept, newest first (0: unlimited)
+	Limit int
+
+	// MaxAge drops entries last used longer ago (0: no limit)
+	MaxAge time.Duration
+}
+
+// scopePruningOf returns the policy by gitconfig cx.scopeHistoryLimit and cx.scopeHistoryMaxAge, or else by the rule.
+func scopePruningOf(rule *Rule, repos *git.Repository) (scopePruning, error) {
+	limit, age := rule.ScopeHistoryLimit, rule.ScopeHistoryMaxAge
+	if repos != nil {
+		if cfg := getGitConfig(repos, configScopeHistoryLimit); cfg != nil {
+			n, err := strconv.Atoi(strings.TrimSpace(*cfg))
+			if err != nil {
+				return scopePruning{}, fmt.Errorf("%s.%s: %w", configSection, configScopeHistoryLimit, err)
+			}
+			limit = n
+		}
+		if cfg := getGitConfig(repos, configScopeHistoryMaxAge); cfg != nil {
+			age = *cfg
+		}
+	}
+
+	p := scopePruning{}
+	switch {
+	case limit == 0:
+		p.Limit = defaultScopeHistoryLimit
+	case limit > 0:
+		p.Limit = limit
+	}
+
+	d, err := parseAge(age)
+	if err != nil {
+		return scopePruning{}, fmt.Errorf("%s: %w", configScopeHistoryMaxAge, err)
+	}
+	p.MaxAge = d
+
+	return p, nil
+}
+
+// parseAge parses an age like 90d, 2w or 3m (a month is 30 days). "" is 0.
+func parseAge(s string) (time.Duration, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return 0, nil
+	}
+
+	var unit time.Duration
+	switch s[len(s)-1] {
+	case 'd':
+		unit = 24 * time.Hour
+	case 'w':
+		unit = 7 * 24 * time.Hour
+	case 'm':
+		unit = 30 * 24 * time.Hour
+	default:
+		return 0, fmt.Errorf("unknown unit of age %q (d, w or m)", s)
+	}
+
+	n, err := strconv.Atoi(s[:len(s)-1])
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid age %q", s)
+	}
+	return time.Duration(n) * unit, nil
+}
+
+// pruneScopes drops the entries of scopes older than p.MaxAge or beyond p.Limit, newest first,
+// and returns the names dropped, newest first.
+// Entries without the timestamp are not dropped by the age.
+func pruneScopes(scopes Scopes, p scopePruning, now time.Time) []string {
+	var pruned []string
+	kept := 0
+	for _, name := range sortedScopes(scopes) {
+		e := scopes[name]
+		tooOld := p.MaxAge > 0 && !e.LastUsed.IsZero() && now.Sub(e.LastUsed) > p.MaxAge
+		if tooOld || (p.Limit > 0 && kept >= p.Limit) {
+			pruned = append(pruned, name)
+			delete(scopes, name)
+			continue
+		}
+		kept++
+	}
+	return pruned
+}
+
+// pruneScopeHistory applies the policy to c.scopes before it is written.
+// An invalid policy is warned and the history is kept as it is.
+func (c globalCmd) pruneScopeHistory() {
+	p, err := scopePruningOf(c.rule, c.repository)
+	if err != nil {
+		warn(tr(msgScopePruneWarning, err))
+		return
+	}
+	pruneScopes(c.scopes, p, time.Now())
+}
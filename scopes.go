@@ -0,0 +1,337 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	scopeTimestampRFC3339 = "rfc3339"
+	scopeTimestampDate    = "date"
+	scopeTimestampUnix    = "unix"
+)
+
+// The scope history file has two formats.
+//
+// legacy (version 1):
+//
+//	scope: timestamp
+//
+// version 2, written when any entry has more than its timestamp:
+//
+//	version: 2
+//	scopes:
+//	  scope:
+//	    lastused: timestamp
+//	    paths: [dir1, dir2]
+//	    commit: hash
+//	histories:
+//	  prompt:
+//	    answer: timestamp
+//
+// The reader accepts both, and also timestamps as entries of version 2.
+// histories are the answers of Rule.Prompts (see readPromptHistories).
+
+const scopesFileVersion = 2
+
+func (s *Scopes) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: scopes must be a mapping", value.Line)
+	}
+
+	entries := value
+	if isScopesV2(value) {
+		entries = mappingValue(value, "scopes")
+	}
+
+	sc := make(Scopes)
+	for i := 0; i+1 < len(entries.Content); i += 2 {
+		k, v := entries.Content[i], entries.Content[i+1]
+		e, err := parseScopeEntry(v)
+		if err != nil {
+			return fmt.Errorf("line %d: %s: %w", v.Line, k.Value, err)
+		}
+		sc[k.Value] = e
+	}
+	*s = sc
+	return nil
+}
+
+func (s *Scopes) UnmarshalJSON(b []byte) error {
+	// JSON is YAML
+	node := yaml.Node{}
+	if err := yaml.Unmarshal(b, &node); err != nil {
+		return err
+	}
+	if node.Kind != yaml.DocumentNode || len(node.Content) == 0 {
+		*s = make(Scopes)
+		return nil
+	}
+	return s.UnmarshalYAML(node.Content[0])
+}
+
+func parseScopeEntry(v *yaml.Node) (ScopeEntry, error) {
+	if v.Kind == yaml.ScalarNode {
+		t, err := parseScopeTimestamp(v.Value)
+		return ScopeEntry{LastUsed: t}, err
+	}
+	if v.Kind != yaml.MappingNode {
+		return ScopeEntry{}, errors.New("must be a timestamp or a mapping")
+	}
+
+	e := ScopeEntry{}
+	if lu := mappingValue(v, "lastused"); lu != nil {
+		t, err := parseScopeTimestamp(lu.Value)
+		if err != nil {
+			return ScopeEntry{}, err
+		}
+		e.LastUsed = t
+	}
+	if paths := mappingValue(v, "paths"); paths != nil {
+		if err := paths.Decode(&e.Paths); err != nil {
+			return ScopeEntry{}, err
+		}
+	}
+	if commit := mappingValue(v, "commit"); commit != nil {
+		e.Commit = commit.Value
+	}
+	return e, nil
+}
+
+// isScopesV2 reports whether the root of a scope history file is of version 2,
+// with an integer version and scopes as a mapping.
+// A legacy file may have scopes named version or scopes, whose values are timestamps.
+func isScopesV2(root *yaml.Node) bool {
+	if root.Kind != yaml.MappingNode {
+		return false
+	}
+	v := mappingValue(root, "version")
+	if v == nil || v.Kind != yaml.ScalarNode || v.ShortTag() != "!!int" {
+		return false
+	}
+	if _, err := strconv.Atoi(v.Value); err != nil {
+		return false
+	}
+	entries := mappingValue(root, "scopes")
+	return entries != nil && entries.Kind == yaml.MappingNode
+}
+
+// mappingValue returns the value of key (case-insensitive) in mapping node m.
+func mappingValue(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if strings.EqualFold(m.Content[i].Value, key) {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// parseScopeTimestamp accepts RFC3339 (with or without fractional seconds), YYYY-MM-DD and unix seconds.
+func parseScopeTimestamp(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	if u, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(u, 0), nil
+	}
+
+	return time.Time{}, fmt.Errorf("unknown timestamp format %q", s)
+}
+
+// formatScopeTimestamp converts t to the value written to the scope history file.
+func formatScopeTimestamp(t time.Time, format string) any {
+	switch format {
+	case scopeTimestampDate:
+		return t.Local().Format("2006-01-02")
+	case scopeTimestampUnix:
+		return t.Unix()
+	default:
+		return t.Format(time.RFC3339)
+	}
+}
+
+// writeScopesFile writes scopes newest first, in the format of the existing file,
+// or for a new file, in JSON if filename ends with .json, otherwise in YAML.
+// The histories of the prompts in the file are kept.
+func writeScopesFile(filename string, scopes Scopes, format string) error {
+	return writeScopesAndHistories(filename, scopes, nil, format)
+}
+
+// writeScopesAndHistories is writeScopesFile writing histories too. nil histories keeps those in the file.
+func writeScopesAndHistories(filename string, scopes Scopes, histories PromptHistories, format string) error {
+	asJSON := in(fileExt(filename), ".json")
+	if existing, err := os.ReadFile(longPath(filename)); err == nil && len(bytes.TrimSpace(existing)) > 0 {
+		// the extension does not matter
+		asJSON = isJSONContent("", existing)
+		if histories == nil {
+			histories = make(PromptHistories)
+			parsePromptHistories(existing, histories)
+		}
+	}
+
+	node, err := scopesNode(scopes, histories, format)
+	if err != nil {
+		return err
+	}
+
+	var content []byte
+	if asJSON {
+		content, err = nodeToJSON(node)
+		if err == nil {
+			buf := bytes.Buffer{}
+			if err = json.Indent(&buf, content, "", "  "); err == nil {
+				content = append(buf.Bytes(), '\n')
+			}
+		}
+	} else {
+		content, err = yaml.Marshal(node)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := writeFileAtomic(filename, content); err != nil {
+		return err
+	}
+	recordScopesWritten(filename, content)
+	return nil
+}
+
+// sortedScopes returns the names of scopes, newest first.
+func sortedScopes(scopes Scopes) []string {
+	names := make([]string, 0, len(scopes))
+	for k := range scopes {
+		names = append(names, k)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		ti, tj := scopes[names[i]].LastUsed, scopes[names[j]].LastUsed
+		if ti.Equal(tj) {
+			return names[i] < names[j]
+		}
+		return ti.After(tj)
+	})
+	return names
+}
+
+// scopesNode builds the content of the scope history file.
+// OrderedMap is not used since it can not marshal `any` values into YAML.
+func scopesNode(scopes Scopes, histories PromptHistories, format string) (*yaml.Node, error) {
+	v2 := false
+	for _, h := range histories {
+		v2 = v2 || len(h) > 0
+	}
+	for _, e := range scopes {
+		v2 = v2 || len(e.Paths) > 0 || e.Commit != ""
+	}
+
+	entries := &yaml.Node{Kind: yaml.MappingNode}
+	for _, name := range sortedScopes(scopes) {
+		e := scopes[name]
+
+		var value any = formatScopeTimestamp(e.LastUsed, format)
+		if v2 {
+			value = struct {
+				LastUsed any      `yaml:"lastused"`
+				Paths    []string `yaml:"paths,omitempty,flow"`
+				Commit   string   `yaml:"commit,omitempty"`
+			}{
+				LastUsed: value,
+				Paths:    e.Paths,
+				Commit:   e.Commit,
+			}
+		}
+
+		k, v := yaml.Node{}, yaml.Node{}
+		if err := k.Encode(name); err != nil {
+			return nil, err
+		}
+		if err := v.Encode(value); err != nil {
+			return nil, err
+		}
+		entries.Content = append(entries.Content, &k, &v)
+	}
+
+	if !v2 {
+		return entries, nil
+	}
+
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	root.Content = append(root.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Value: "version"},
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(scopesFileVersion)},
+		&yaml.Node{Kind: yaml.ScalarNode, Value: "scopes"},
+		entries,
+	)
+	hs, err := promptHistoriesNode(histories, format)
+	if err != nil {
+		return nil, err
+	}
+	if len(hs.Content) > 0 {
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: "histories"}, hs)
+	}
+	return root, nil
+}
+
+// nodeToJSON converts node into JSON, keeping the order of mappings.
+func nodeToJSON(node *yaml.Node) ([]byte, error) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		buf := bytes.Buffer{}
+		buf.WriteByte('{')
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			k, err := json.Marshal(node.Content[i].Value)
+			if err != nil {
+				return nil, err
+			}
+			v, err := nodeToJSON(node.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(k)
+			buf.WriteByte(':')
+			buf.Write(v)
+		}
+		buf.WriteByte('}')
+		return buf.Bytes(), nil
+
+	case yaml.SequenceNode:
+		buf := bytes.Buffer{}
+		buf.WriteByte('[')
+		for i, c := range node.Content {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			v, err := nodeToJSON(c)
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(v)
+		}
+		buf.WriteByte(']')
+		return buf.Bytes(), nil
+
+	default:
+		var v any
+		if err := node.Decode(&v); err != nil {
+			return nil, err
+		}
+		return json.Marshal(v)
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestScopesUnmarshalYAML(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]time.Time
+	}{
+		{
+			name:    "legacy",
+			content: "api: 2024-01-02T03:04:05Z\nui: 2024-01-03\n",
+			want: map[string]time.Time{
+				"api": time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+				"ui":  time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:    "legacy with a scope named version",
+			content: "version: 2024-01-02T03:04:05Z\napi: 2024-01-03T00:00:00Z\n",
+			want: map[string]time.Time{
+				"version": time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+				"api":     time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:    "legacy with scopes named version and scopes in unix seconds",
+			content: "version: 1700000000\nscopes: 1700000100\n",
+			want: map[string]time.Time{
+				"version": time.Unix(1700000000, 0),
+				"scopes":  time.Unix(1700000100, 0),
+			},
+		},
+		{
+			name:    "v2",
+			content: "version: 2\nscopes:\n  api:\n    lastused: 2024-01-02T03:04:05Z\n    paths: [api]\n  ui: 2024-01-03T00:00:00Z\n",
+			want: map[string]time.Time{
+				"api": time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+				"ui":  time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:    "v2 without scopes",
+			content: "version: 2\nscopes: {}\n",
+			want:    map[string]time.Time{},
+		},
+		{
+			name:    "v2 in JSON",
+			content: `{"version":2,"scopes":{"api":{"lastused":"2024-01-02T03:04:05Z"}}}`,
+			want: map[string]time.Time{
+				"api": time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var scopes Scopes
+			if err := yaml.Unmarshal([]byte(tt.content), &scopes); err != nil {
+				t.Fatal(err)
+			}
+			if len(scopes) != len(tt.want) {
+				t.Fatalf("scopes = %v, want %v", scopes, tt.want)
+			}
+			for name, want := range tt.want {
+				if got := scopes[name].LastUsed; !got.Equal(want) {
+					t.Errorf("%s = %v, want %v", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestScopesFileRoundTrip(t *testing.T) {
+	for _, name := range []string{"scopes.yaml", "scopes.json"} {
+		t.Run(name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), name)
+			want := Scopes{
+				"version": {LastUsed: time.Unix(100, 0)},
+				"api":     {LastUsed: time.Unix(200, 0), Paths: []string{"api", "cmd/api"}, Commit: "0123456"},
+			}
+			if err := writeScopesFile(filename, want, scopeTimestampUnix); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := tryReadScopesFile(filename, defaultConfigFileLimit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(want) {
+				t.Fatalf("scopes = %v, want %v", got, want)
+			}
+			for scope, w := range want {
+				g := got[scope]
+				if !g.LastUsed.Equal(w.LastUsed) || g.Commit != w.Commit || len(g.Paths) != len(w.Paths) {
+					t.Errorf("%s = %+v, want %+v", scope, g, w)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteScopesFileReplacesAtomically(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, ".scope-history.yaml")
+	for i := 1; i <= 2; i++ {
+		if err := writeScopesFile(filename, Scopes{"api": {LastUsed: time.Unix(int64(i), 0)}}, scopeTimestampUnix); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != ".scope-history.yaml" {
+		t.Errorf("files = %v, want only the history file", entries)
+	}
+	got, err := tryReadScopesFile(filename, defaultConfigFileLimit)
+	if err != nil || !got["api"].LastUsed.Equal(time.Unix(2, 0)) {
+		t.Errorf("scopes = %v, %v, want api at 2", got, err)
+	}
+}
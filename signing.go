@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	git "github.com/go-git/go-git/v5"
+)
+
+// signingCheckTimeout bounds the pre-flight check of commit signing, which may run gpg.
+const signingCheckTimeout = time.Second
+
+// signingProblem tells why git commit would fail to sign by commit.gpgsign, or returns "" if signing is
+// not required or seems to work.
+// It only catches what is cheap to see: no key configured, a missing SSH key file, or gpg not finding
+// the secret key in time. Unknown formats (x509) are not checked.
+func signingProblem(ctx context.Context, repos *git.Repository) string {
+	if sign, err := strconv.ParseBool(gitConfigOption(repos, "commit", "gpgsign")); err != nil || !sign {
+		return ""
+	}
+
+	key := gitConfigOption(repos, "user", "signingkey")
+	switch format := gitConfigOption(repos, "gpg", "format"); format {
+	case "ssh":
+		if key == "" {
+			return tr(msgSigningNoKey)
+		}
+		// a literal public key
+		if strings.HasPrefix(key, "key::") || strings.HasPrefix(key, "ssh-") {
+			return ""
+		}
+		if _, err := os.Stat(longPath(expandHome(key))); err != nil {
+			return tr(msgSigningKeyMissing, key)
+		}
+		return ""
+
+	case "", "openpgp":
+		program := gitConfigOption(repos, "gpg", "program")
+		if program == "" {
+			program = "gpg"
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, signingCheckTimeout)
+		defer cancel()
+		args := []string{"--batch", "--list-secret-keys"}
+		if key != "" {
+			args = append(args, key)
+		}
+		cmd := exec.CommandContext(ctx, program, args...)
+		cmd.WaitDelay = signingCheckTimeout
+		out, err := cmd.Output()
+		if err != nil || len(bytes.TrimSpace(out)) == 0 {
+			if key == "" {
+				return tr(msgSigningNoKey)
+			}
+			return tr(msgSigningKeyMissing, key)
+		}
+		return ""
+
+	default:
+		return ""
+	}
+}
+
+// expandHome expands a leading ~/ of path into the home directory.
+func expandHome(path string) string {
+	rest, found := strings.CutPrefix(path, "~/")
+	if !found {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, rest)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	git "github.com/go-git/go-git/v5"
+)
+
+const (
+	configSignoff = "signoff"
+
+	signoffToken = "Signed-off-by"
+)
+
+// signsOff reports whether to commit with -s, by --signoff or gitconfig cx.signoff.
+func (c globalCmd) signsOff() bool {
+	if c.Signoff {
+		return true
+	}
+	if c.repository == nil {
+		return false
+	}
+	if cfg := getGitConfig(c.repository, configSignoff); cfg != nil {
+		on, _ := strconv.ParseBool(strings.TrimSpace(*cfg))
+		return on
+	}
+	return false
+}
+
+// signoffTrailer returns the trailer git commit -s adds, by the committer identity, or "" if unknown.
+func signoffTrailer(repos *git.Repository) string {
+	name, email := os.Getenv("GIT_COMMITTER_NAME"), os.Getenv("GIT_COMMITTER_EMAIL")
+	if name == "" {
+		name = gitConfigOption(repos, "user", "name")
+	}
+	if email == "" {
+		email = gitConfigOption(repos, "user", "email")
+	}
+	if name == "" && email == "" {
+		return ""
+	}
+	return signoffToken + ": " + name + " <" + email + ">"
+}
+
+// gitTrailerPattern is a trailer line as git interpret-trailers sees it. BREAKING CHANGE is not, for the space.
+var gitTrailerPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]*: `)
+
+// withSignoff appends trailer to msg as git commit -s does, for the preview of --debug and --dry-run:
+// in the last paragraph if it consists of trailers, otherwise as a new paragraph.
+// As git does, nothing is appended if the last line is already trailer.
+func withSignoff(msg, trailer string) string {
+	if trailer == "" {
+		return msg
+	}
+
+	msg = strings.TrimRight(msg, "\n")
+	i := strings.LastIndex(msg, "\n\n")
+	if i < 0 {
+		return msg + "\n\n" + trailer
+	}
+
+	lines := strings.Split(msg[i+2:], "\n")
+	if lines[len(lines)-1] == trailer {
+		return msg
+	}
+	for _, line := range lines {
+		if !gitTrailerPattern.MatchString(line) {
+			return msg + "\n\n" + trailer
+		}
+	}
+	return msg + "\n" + trailer
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+
+	prompt "github.com/elk-language/go-prompt"
+	pstrings "github.com/elk-language/go-prompt/strings"
+)
+
+// Description snippets are boilerplate descriptions offered by the completer of the description prompt,
+// like `bump ${1:dependency} to ${2:version}`.
+// After a snippet is chosen, Tab jumps to its placeholders in the order of their numbers, emptying each to type in.
+// A placeholder skipped by Tab or Enter without typing, or not jumped to at all, becomes its default text.
+//
+// go-prompt handles Tab for the completion before any key binding,
+// so snippetReader turns Tab into Ctrl+] while the input has placeholders, and Ctrl+] is bound to the jump.
+
+// placeholderPattern matches ${1} and ${1:default}.
+var placeholderPattern = regexp.MustCompile(`\$\{(\d+)(?::([^}]*))?\}`)
+
+// descriptionSnippets returns the snippets of the rule and of Rule.DescriptionSnippetsFile.
+func (c globalCmd) descriptionSnippets() []string {
+	snippets := append([]string{}, c.rule.DescriptionSnippets...)
+	if c.rule.DescriptionSnippetsFile == "" {
+		return snippets
+	}
+
+	filename := expandHome(c.rule.DescriptionSnippetsFile)
+	if !filepath.IsAbs(filename) {
+		if isRuleURL(c.rulePath) {
+			warn(tr(msgSnippetsFileRelative, c.rule.DescriptionSnippetsFile))
+			return snippets
+		}
+		filename = filepath.Join(filepath.Dir(c.rulePath), filename)
+	}
+	content, err := readConfigFile(filename, configFileLimit(c.repository))
+	if err != nil {
+		warn(tr(msgSnippetsFileWarning, filename, err))
+		return snippets
+	}
+	return append(snippets, parseSnippets(content)...)
+}
+
+// parseSnippets reads a snippet a line. Blank lines and lines starting with # are skipped.
+func parseSnippets(content []byte) []string {
+	var snippets []string
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		snippets = append(snippets, line)
+	}
+	return snippets
+}
+
+// nextPlaceholder returns the placeholder of the smallest number in text:
+// its start and end in runes, and its default text.
+func nextPlaceholder(text string) (start, end int, def string, found bool) {
+	number := -1
+	for _, m := range placeholderPattern.FindAllStringSubmatchIndex(text, -1) {
+		n, err := strconv.Atoi(text[m[2]:m[3]])
+		if err != nil || (number >= 0 && n >= number) {
+			continue
+		}
+		number = n
+		start = utf8.RuneCountInString(text[:m[0]])
+		end = start + utf8.RuneCountInString(text[m[0]:m[1]])
+		def = ""
+		if m[4] >= 0 {
+			def = text[m[4]:m[5]]
+		}
+		found = true
+	}
+	return start, end, def, found
+}
+
+// expandPlaceholders replaces the placeholders in text by their default texts.
+func expandPlaceholders(text string) string {
+	return placeholderPattern.ReplaceAllString(text, "$2")
+}
+
+// placeholderJumper moves the cursor through the placeholders of the description prompt.
+// A placeholder jumped to is emptied to type in. Its default is inserted if nothing is typed there
+// until the next jump or Enter.
+type placeholderJumper struct {
+	// jumping is true while the input has placeholders, updated by the completer.
+	// It is read by snippetReader, which runs in another goroutine.
+	jumping atomic.Bool
+
+	// the last placeholder emptied, and the text right after that
+	pending bool
+	pos     int
+	def     string
+	text    string
+}
+
+// jump moves the cursor to the next placeholder. It is the key binding of Ctrl+].
+func (j *placeholderJumper) jump(p *prompt.Prompt) bool {
+	if j.pending && p.Buffer().Text() == j.text {
+		j.moveTo(p, j.pos)
+		p.InsertTextMoveCursor(j.def, false)
+	}
+	j.pending = false
+
+	text := p.Buffer().Text()
+	start, end, def, found := nextPlaceholder(text)
+	if !found {
+		return false
+	}
+
+	j.moveTo(p, start)
+	p.DeleteRunes(pstrings.RuneNumber(end - start))
+	j.pending, j.pos, j.def, j.text = true, start, def, p.Buffer().Text()
+	return true
+}
+
+func (j *placeholderJumper) moveTo(p *prompt.Prompt, pos int) {
+	cursor := int(p.Buffer().Document().CurrentRuneIndex())
+	if pos < cursor {
+		p.CursorLeftRunes(pstrings.RuneNumber(cursor - pos))
+	} else if pos > cursor {
+		p.CursorRightRunes(pstrings.RuneNumber(pos - cursor))
+	}
+}
+
+// finish returns the input with the default of the last placeholder if nothing is typed there,
+// and with the other placeholders replaced by their defaults.
+func (j *placeholderJumper) finish(input string) string {
+	if j.pending && input == j.text {
+		runes := []rune(input)
+		input = string(runes[:j.pos]) + j.def + string(runes[j.pos:])
+	}
+	return expandPlaceholders(input)
+}
+
+// snippetReader is the terminal input, turning Tab into Ctrl+] while jumping is true.
+type snippetReader struct {
+	prompt.Reader
+	jumping *atomic.Bool
+}
+
+func (r snippetReader) Read(b []byte) (int, error) {
+	n, err := r.Reader.Read(b)
+	if n == 1 && b[0] == '\t' && r.jumping.Load() {
+		b[0] = 0x1d // Ctrl+]
+	}
+	return n, err
+}
+
+// options returns the options of a prompt jumping to placeholders by Tab while its input has them.
+// jumping is to be updated by the completer, which is called on every change of the input.
+// reader is the terminal input.
+func (j *placeholderJumper) options(reader prompt.Reader) []prompt.Option {
+	return []prompt.Option{
+		prompt.WithReader(snippetReader{Reader: reader, jumping: &j.jumping}),
+		prompt.WithKeyBind(prompt.KeyBind{Key: prompt.ControlSquareClose, Fn: j.jump}),
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	prompt "github.com/elk-language/go-prompt"
+)
+
+func TestParseSnippets(t *testing.T) {
+	content := "# dependencies\nbump ${1:dependency} to ${2:version}\r\n\n  fix typo in ${1}  \n"
+	want := []string{"bump ${1:dependency} to ${2:version}", "fix typo in ${1}"}
+	if got := parseSnippets([]byte(content)); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseSnippets() = %q, want %q", got, want)
+	}
+}
+
+func TestNextPlaceholder(t *testing.T) {
+	tests := []struct {
+		text       string
+		start, end int
+		def        string
+		found      bool
+	}{
+		{text: "fix typo"},
+		{text: "bump ${1:dependency} to ${2:version}", start: 5, end: 20, def: "dependency", found: true},
+		{text: "bump lib to ${2:version}", start: 12, end: 24, def: "version", found: true},
+		{text: "to ${2:b} from ${1}", start: 15, end: 19, def: "", found: true},
+		{text: "説明 ${1:既定}", start: 3, end: 10, def: "既定", found: true},
+		{text: "${10:x} ${9:y}", start: 8, end: 14, def: "y", found: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			start, end, def, found := nextPlaceholder(tt.text)
+			if start != tt.start || end != tt.end || def != tt.def || found != tt.found {
+				t.Errorf("nextPlaceholder() = %d, %d, %q, %v, want %d, %d, %q, %v",
+					start, end, def, found, tt.start, tt.end, tt.def, tt.found)
+			}
+		})
+	}
+}
+
+func TestExpandPlaceholders(t *testing.T) {
+	got := expandPlaceholders("bump ${1:dependency} to ${2:version}${3}")
+	if want := "bump dependency to version"; got != want {
+		t.Errorf("expandPlaceholders() = %q, want %q", got, want)
+	}
+}
+
+func TestPlaceholderJumper(t *testing.T) {
+	tests := []struct {
+		name  string
+		typed []string // typed at each placeholder jumped to
+		want  string
+	}{
+		{name: "typed", typed: []string{"go-git", "v5.12"}, want: "bump go-git to v5.12"},
+		{name: "skipped", typed: []string{"", "v5.12"}, want: "bump dependency to v5.12"},
+		{name: "last skipped", typed: []string{"go-git", ""}, want: "bump go-git to version"},
+		{name: "not jumped", typed: []string{"go-git"}, want: "bump go-git to version"},
+		{name: "nothing", want: "bump dependency to version"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := prompt.New(func(string) {}, prompt.WithInitialText("bump ${1:dependency} to ${2:version}"))
+			j := &placeholderJumper{}
+			for _, s := range tt.typed {
+				if !j.jump(p) {
+					t.Fatalf("no placeholder in %q", p.Buffer().Text())
+				}
+				p.InsertTextMoveCursor(s, false)
+			}
+			if got := j.finish(p.Buffer().Text()); got != tt.want {
+				t.Errorf("input = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+type bytesReader struct {
+	io.Reader
+}
+
+func (bytesReader) Open() error                 { return nil }
+func (bytesReader) Close() error                { return nil }
+func (bytesReader) GetWinSize() *prompt.WinSize { return &prompt.WinSize{Row: 24, Col: 80} }
+
+func TestSnippetReader(t *testing.T) {
+	for _, jumping := range []bool{false, true} {
+		j := &placeholderJumper{}
+		j.jumping.Store(jumping)
+		r := snippetReader{Reader: bytesReader{bytes.NewReader([]byte("\t"))}, jumping: &j.jumping}
+
+		b := make([]byte, 8)
+		n, err := r.Read(b)
+		if err != nil || n != 1 {
+			t.Fatalf("Read() = %d, %v", n, err)
+		}
+		want := byte('\t')
+		if jumping {
+			want = 0x1d
+		}
+		if b[0] != want {
+			t.Errorf("Tab is read as %#x while jumping is %v, want %#x", b[0], jumping, want)
+		}
+	}
+}
+
+func TestDescriptionSnippets(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "snippets.txt"), []byte("fix typo in ${1}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rule := defaultRule(false)
+	rule.DescriptionSnippets = []string{"bump ${1:dependency}"}
+	rule.DescriptionSnippetsFile = "snippets.txt"
+	c := globalCmd{rule: &rule, rulePath: filepath.Join(dir, ".cx.yaml")}
+
+	want := []string{"bump ${1:dependency}", "fix typo in ${1}"}
+	if got := c.descriptionSnippets(); !reflect.DeepEqual(got, want) {
+		t.Errorf("descriptionSnippets() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,396 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-billy/v5/util"
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+)
+
+// stagedFiles returns the sorted paths of files staged in st.
+func stagedFiles(st git.Status) []string {
+	var files []string
+	for f, s := range st {
+		if s.Staging != git.Unmodified && s.Staging != git.Untracked {
+			files = append(files, f)
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// stagedRenames returns the staged renames (new path -> old path).
+//
+// go-git reports most staged renames as a pair of deleted and added files,
+// so they are reconstructed by the contents in HEAD and the index.
+func stagedRenames(repos *git.Repository, st git.Status) map[string]string {
+	renames := make(map[string]string)
+
+	deleted := make(map[string]bool)
+	added := make(map[string]bool)
+	for f, s := range st {
+		switch s.Staging {
+		case git.Renamed, git.Copied:
+			if s.Extra != "" && s.Extra != f {
+				renames[f] = s.Extra
+			}
+		case git.Deleted:
+			deleted[f] = true
+		case git.Added:
+			added[f] = true
+		}
+	}
+	if len(deleted) == 0 || len(added) == 0 || repos == nil {
+		return renames
+	}
+
+	head, err := repos.Head()
+	if err != nil {
+		return renames
+	}
+	commit, err := repos.CommitObject(head.Hash())
+	if err != nil {
+		return renames
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return renames
+	}
+	byHash := make(map[plumbing.Hash]string)
+	for f := range deleted {
+		if e, err := tree.FindEntry(f); err == nil {
+			byHash[e.Hash] = f
+		}
+	}
+
+	idx, err := repos.Storer.Index()
+	if err != nil {
+		return renames
+	}
+	for _, e := range idx.Entries {
+		if !added[e.Name] {
+			continue
+		}
+		if old, found := byHash[e.Hash]; found {
+			renames[e.Name] = old
+			delete(byHash, e.Hash)
+		}
+	}
+	return renames
+}
+
+// stagedSummary returns the lines like `M path` of the staged files, and `R old -> new` for renames.
+func stagedSummary(st git.Status, renames map[string]string) []string {
+	olds := make(map[string]bool)
+	for _, old := range renames {
+		olds[old] = true
+	}
+
+	var lines []string
+	for _, f := range stagedFiles(st) {
+		if old, found := renames[f]; found {
+			lines = append(lines, fmt.Sprintf("R %s -> %s", old, f))
+			continue
+		}
+		if olds[f] && st[f].Staging == git.Deleted {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%c %s", st[f].Staging, f))
+	}
+	return lines
+}
+
+// stagedSummaryMaxFiles is the number of files listed by printStagedSummary.
+const stagedSummaryMaxFiles = 10
+
+// printStagedSummary writes the numbers of the staged files by the kind of change and the files, up to stagedSummaryMaxFiles.
+func (c globalCmd) printStagedSummary(w io.Writer) {
+	lines := stagedSummary(c.status, c.renames)
+	if len(lines) == 0 {
+		return
+	}
+
+	counts := make(map[byte]int)
+	for _, line := range lines {
+		counts[line[0]]++
+	}
+	var parts []string
+	for _, k := range []struct {
+		code byte
+		msg  string
+	}{
+		{byte(git.Modified), msgStagedModified},
+		{byte(git.Added), msgStagedAdded},
+		{byte(git.Deleted), msgStagedDeleted},
+		{byte(git.Renamed), msgStagedRenamed},
+	} {
+		if n := counts[k.code]; n > 0 {
+			parts = append(parts, tr(k.msg, n))
+			delete(counts, k.code)
+		}
+	}
+	other := 0
+	for _, n := range counts {
+		other += n
+	}
+	if other > 0 {
+		parts = append(parts, tr(msgStagedOther, other))
+	}
+
+	header := tr(msgStagedHeader, strings.Join(parts, ", "))
+	if stagedDiff != nil {
+		header += " " + tr(msgDiffHint)
+	}
+	fmt.Fprintln(w, header)
+	for i, line := range lines {
+		if i == stagedSummaryMaxFiles {
+			fmt.Fprintln(w, "  "+tr(msgStagedMore, len(lines)-i))
+			break
+		}
+		fmt.Fprintln(w, "  "+line)
+	}
+}
+
+// scopeFiles returns the staged files to infer scopes from.
+// The old paths of renames are included or the renamed files are excluded, as Rule.RenameScopes says.
+func (c globalCmd) scopeFiles() []string {
+	files := stagedFiles(c.status)
+	if len(c.renames) == 0 {
+		return files
+	}
+
+	if c.rule.RenameScopes == renameScopesNone {
+		skip := make(map[string]bool)
+		for f, old := range c.renames {
+			skip[f], skip[old] = true, true
+		}
+		result := files[:0]
+		for _, f := range files {
+			if !skip[f] {
+				result = append(result, f)
+			}
+		}
+		return result
+	}
+
+	seen := make(map[string]bool)
+	for _, f := range files {
+		seen[f] = true
+	}
+	for _, old := range c.renames {
+		if !seen[old] {
+			seen[old] = true
+			files = append(files, old)
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// stagedDirs returns the deduplicated directories of files, up to depth levels (at least 1).
+// Files in the root directory are not counted.
+func stagedDirs(files []string, depth int) []string {
+	if depth < 1 {
+		depth = 1
+	}
+
+	seen := make(map[string]bool)
+	var dirs []string
+	for _, f := range files {
+		dir := path.Dir(f)
+		if dir == "." {
+			continue
+		}
+
+		elems := strings.Split(dir, "/")
+		if len(elems) > depth {
+			elems = elems[:depth]
+		}
+		dir = strings.Join(elems, "/")
+
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// allStagingPlan returns the files to be staged by --all.
+//
+// renames (new path -> old path) is from detectRenames.
+// Untracked files are staged only if they are the new side of renames and not ignored.
+// Tracked files are staged even in ignored directories, as git does.
+func allStagingPlan(st git.Status, renames map[string]string, ign ignoreMatcher) []string {
+	var files []string
+	for f, s := range st {
+		switch s.Worktree {
+		case git.Modified, git.Added, git.Deleted, git.Copied, git.UpdatedButUnmerged:
+			files = append(files, f)
+		case git.Renamed:
+			// both the removal of the old path and the addition of the new one
+			files = append(files, f)
+			if s.Extra != "" && s.Extra != f {
+				files = append(files, s.Extra)
+			}
+		case git.Untracked:
+			if _, found := renames[f]; found && !ign.ignored(f) {
+				files = append(files, f)
+			}
+		default:
+			//nop
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// detectRenames returns untracked files (new path -> old path) whose contents are
+// the same as tracked files deleted in the worktree.
+//
+// go-git reports a renamed file as a pair of deleted and untracked ones.
+// Ignored untracked files are not taken as renamed.
+func detectRenames(repos *git.Repository, wt *git.Worktree, st git.Status, ign ignoreMatcher) map[string]string {
+	deleted := make(map[string]bool)
+	var untracked []string
+	for f, s := range st {
+		switch {
+		case s.Worktree == git.Deleted && s.Staging != git.Untracked:
+			deleted[f] = true
+		case s.Worktree == git.Untracked && !ign.ignored(f):
+			untracked = append(untracked, f)
+		}
+	}
+	if len(deleted) == 0 || len(untracked) == 0 {
+		return nil
+	}
+
+	idx, err := repos.Storer.Index()
+	if err != nil {
+		return nil
+	}
+	byHash := make(map[plumbing.Hash]string)
+	for _, e := range idx.Entries {
+		if deleted[e.Name] {
+			byHash[e.Hash] = e.Name
+		}
+	}
+
+	sort.Strings(untracked)
+	renames := make(map[string]string)
+	for _, f := range untracked {
+		content, err := util.ReadFile(wt.Filesystem, f)
+		if err != nil {
+			continue
+		}
+
+		h := plumbing.ComputeHash(plumbing.BlobObject, content)
+		if old, found := byHash[h]; found {
+			renames[f] = old
+			delete(byHash, h)
+		}
+	}
+	return renames
+}
+
+// partiallyStagedFiles returns the staged files that are modified again in the worktree.
+func partiallyStagedFiles(st git.Status) []string {
+	var files []string
+	for f, s := range st {
+		if (s.Staging == git.Modified || s.Staging == git.Added) && s.Worktree == git.Modified {
+			files = append(files, f)
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// relatedUntrackedFiles returns the untracked files in, above or below the directories of the staged files.
+// Ignored files are left out.
+func relatedUntrackedFiles(st git.Status, ign ignoreMatcher) []string {
+	var dirs []string
+	for _, f := range stagedFiles(st) {
+		dirs = append(dirs, path.Dir(f))
+	}
+
+	var files []string
+	for f, s := range st {
+		if s.Worktree == git.Untracked && s.Staging == git.Untracked && !ign.ignored(f) && pathsOverlap([]string{path.Dir(f)}, dirs) {
+			files = append(files, f)
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// unstagedFiles returns the files changed in the worktree but not staged, including untracked ones.
+// Ignored files are left out.
+func unstagedFiles(st git.Status, ign ignoreMatcher) []string {
+	var files []string
+	for f, s := range st {
+		switch s.Worktree {
+		case git.Modified, git.Added, git.Deleted, git.Renamed, git.Copied, git.UpdatedButUnmerged:
+			files = append(files, f)
+		case git.Untracked:
+			if !ign.ignored(f) {
+				files = append(files, f)
+			}
+		default:
+			//nop
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+func addFiles(wt *git.Worktree, files []string) error {
+	for _, f := range files {
+		if _, err := wt.Add(f); err != nil {
+			return fmt.Errorf("try git gc: adding %s: %w", f, err)
+		}
+	}
+	return nil
+}
+
+// submodulePaths returns the paths of submodules, from the index and .gitmodules.
+func submodulePaths(repos *git.Repository, wt *git.Worktree) map[string]bool {
+	paths := make(map[string]bool)
+
+	if idx, err := repos.Storer.Index(); err == nil {
+		for _, e := range idx.Entries {
+			if e.Mode == filemode.Submodule {
+				paths[e.Name] = true
+			}
+		}
+	}
+
+	if subs, err := wt.Submodules(); err == nil {
+		for _, sub := range subs {
+			paths[path.Clean(sub.Config().Path)] = true
+		}
+	}
+
+	return paths
+}
+
+// excludePaths returns a copy of st without paths.
+func excludePaths(st git.Status, paths map[string]bool) git.Status {
+	if len(paths) == 0 {
+		return st
+	}
+
+	filtered := make(git.Status, len(st))
+	for f, s := range st {
+		if paths[f] {
+			continue
+		}
+		filtered[f] = s
+	}
+	return filtered
+}
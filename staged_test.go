@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	git "github.com/go-git/go-git/v5"
+
+	"github.com/shu-go/git-cx/internal/testutil"
+)
+
+func TestAllStagingPlan(t *testing.T) {
+	tests := []struct {
+		name    string
+		st      git.Status
+		renames map[string]string
+		want    []string
+	}{
+		{
+			name: "modified and deleted",
+			st: git.Status{
+				"a.go": {Staging: git.Unmodified, Worktree: git.Modified},
+				"b.go": {Staging: git.Unmodified, Worktree: git.Deleted},
+			},
+			want: []string{"a.go", "b.go"},
+		},
+		{
+			name: "renamed",
+			st: git.Status{
+				"new.go": {Staging: git.Unmodified, Worktree: git.Renamed, Extra: "old.go"},
+			},
+			want: []string{"new.go", "old.go"},
+		},
+		{
+			name: "untracked side of a rename",
+			st: git.Status{
+				"old.go":   {Staging: git.Unmodified, Worktree: git.Deleted},
+				"new.go":   {Staging: git.Untracked, Worktree: git.Untracked},
+				"other.go": {Staging: git.Untracked, Worktree: git.Untracked},
+			},
+			renames: map[string]string{"new.go": "old.go"},
+			want:    []string{"new.go", "old.go"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := allStagingPlan(tt.st, tt.renames, ignoreMatcher{}); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("allStagingPlan() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStageAllCommitsRename(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not found")
+	}
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	r := testutil.NewRepo(t)
+	r.CommitFile("old.txt", "the content of a renamed file\n", "chore: add old.txt")
+	if err := os.Rename(filepath.Join(r.Dir, "old.txt"), filepath.Join(r.Dir, "new.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	wt, err := r.Repository.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := globalCmd{All: true, repository: r.Repository}
+	if err := c.stageAll(&commitFlow{ctx: context.Background(), wt: wt}); err != nil {
+		t.Fatal(err)
+	}
+	r.Commit("refactor: rename old.txt")
+
+	cmd := exec.Command("git", "show", "--name-status", "--format=", "HEAD")
+	cmd.Dir = r.Dir
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.TrimSpace(string(out)), "R100\told.txt\tnew.txt"; got != want {
+		t.Errorf("git show --name-status = %q, want %q", got, want)
+	}
+}
+
+func TestStagedRenames(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	r := testutil.NewRepo(t)
+	r.WriteFile("pkg/a/x.go", "package a\n")
+	r.WriteFile("keep.txt", "k\n")
+	r.Stage("pkg/a/x.go", "keep.txt")
+	r.Commit("chore: init")
+
+	r.WriteFile("pkg/b/x.go", "package a\n")
+	r.Remove("pkg/a/x.go")
+	r.WriteFile("keep.txt", "k2\n")
+	r.Stage("pkg/a/x.go", "pkg/b/x.go", "keep.txt")
+
+	wt, err := r.Repository.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	st, err := wt.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	renames := stagedRenames(r.Repository, st)
+	if want := map[string]string{"pkg/b/x.go": "pkg/a/x.go"}; !reflect.DeepEqual(renames, want) {
+		t.Fatalf("stagedRenames() = %v, want %v", renames, want)
+	}
+	if got, want := stagedSummary(st, renames), []string{"M keep.txt", "R pkg/a/x.go -> pkg/b/x.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("stagedSummary() = %q, want %q", got, want)
+	}
+
+	tests := []struct {
+		renameScopes string
+		want         []string
+	}{
+		{renameScopes: "", want: []string{"pkg/a", "pkg/b"}},
+		{renameScopes: renameScopesBoth, want: []string{"pkg/a", "pkg/b"}},
+		{renameScopes: renameScopesNone, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run("renameScopes "+tt.renameScopes, func(t *testing.T) {
+			c := globalCmd{rule: &Rule{RenameScopes: tt.renameScopes}, status: st, renames: renames}
+			if got := stagedDirs(c.scopeFiles(), 2); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("scope dirs = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// templateFuncs are the functions available in the templates of the rule file, written after a pipe
+// like `{{.type | upper}}` or `{{.description | trunc 50}}`.
+var templateFuncs = template.FuncMap{
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"title": titleCase,
+	"trunc": truncRunes,
+	"default": func(def, s string) string {
+		if s == "" {
+			return def
+		}
+		return s
+	},
+	"trimPrefix": func(prefix, s string) string {
+		return strings.TrimPrefix(s, prefix)
+	},
+	"replace": func(old, new, s string) string {
+		return strings.ReplaceAll(s, old, new)
+	},
+}
+
+// titleCase makes the first letter of each word uppercase.
+func titleCase(s string) string {
+	runes := []rune(s)
+	for i, r := range runes {
+		if i == 0 || unicode.IsSpace(runes[i-1]) {
+			runes[i] = unicode.ToUpper(r)
+		}
+	}
+	return string(runes)
+}
+
+// truncRunes cuts s to n characters. A negative n leaves s as it is.
+func truncRunes(n int, s string) string {
+	if n < 0 {
+		return s
+	}
+	if r := []rune(s); len(r) > n {
+		return string(r[:n])
+	}
+	return s
+}
+
+// renderTemplate executes format, a template written in the rule file, with data.
+//
+// This and checkTemplate are the only places templates are executed.
+// Answers typed by users (type, scope, description, body, ...) must be passed only as data,
+// never concatenated into format, so that `{{.type}}`, backticks or `%s` in them appear literally
+// and can not call template functions.
+func renderTemplate(format string, data map[string]string) (string, error) {
+	templ, err := template.New("").Funcs(templateFuncs).Parse(format)
+	if err != nil {
+		return "", err
+	}
+
+	buf := bytes.Buffer{}
+	if err := templ.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// checkTemplate reports an error if format does not parse or uses a variable other than keys.
+func checkTemplate(format string, keys []string) error {
+	templ, err := template.New("").Funcs(templateFuncs).Option("missingkey=error").Parse(format)
+	if err != nil {
+		return err
+	}
+
+	data := make(map[string]string, len(keys))
+	for _, k := range keys {
+		data[k] = k
+	}
+	return templ.Execute(io.Discard, data)
+}
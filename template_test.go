@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// Answers are substituted as data, never parsed as templates.
+func TestAnswersAreNotTemplates(t *testing.T) {
+	given := ConventionalCommit{
+		Type:        "feat",
+		Scope:       "{{.type}}",
+		Description: "print `%s` with {{.scope | upper}}",
+		Body:        "{{template \"x\"}} and {{printf \"%d\" 1}}",
+	}
+	literals := []string{given.Scope, given.Description, given.Body}
+
+	tests := []struct {
+		name string
+		rule func(r *Rule)
+	}{
+		{name: "default"},
+		{
+			name: "custom header",
+			rule: func(r *Rule) {
+				r.HeaderFormat = "{{.type | upper}}{{.scope_with_parens}}: {{.description | trunc 100}}"
+			},
+		},
+		{
+			name: "body and footer formats",
+			rule: func(r *Rule) {
+				r.BodyFormat = "{{.body}}\n\n(about {{.scope}})"
+				r.FooterFormat = "Refs: {{.description}}"
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := defaultRule(false)
+			if tt.rule != nil {
+				tt.rule(&rule)
+			}
+			c := globalCmd{rule: &rule, given: given}
+			c.Type, c.Message = given.Type, given.Description
+
+			msg, _, _, err := c.buildupCommitMessage(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, s := range literals {
+				if !strings.Contains(msg, s) {
+					t.Errorf("%q is not in the message literally:\n%s", s, msg)
+				}
+			}
+		})
+	}
+}
+
+func TestTemplateFuncs(t *testing.T) {
+	data := map[string]string{"type": "feat", "scope": "", "description": "add the retry flag to the api client"}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{format: "{{.type | upper}}", want: "FEAT"},
+		{format: "{{\"FeAt\" | lower}}", want: "feat"},
+		{format: "{{.description | title}}", want: "Add The Retry Flag To The Api Client"},
+		{format: "{{.description | trunc 7}}", want: "add the"},
+		{format: "{{.description | trunc 100}}", want: "add the retry flag to the api client"},
+		{format: "{{\"日本語の説明\" | trunc 3}}", want: "日本語"},
+		{format: "{{.scope | default \"core\"}}", want: "core"},
+		{format: "{{.type | default \"core\"}}", want: "feat"},
+		{format: "{{.description | trimPrefix \"add \"}}", want: "the retry flag to the api client"},
+		{format: "{{.description | replace \" \" \"-\"}}", want: "add-the-retry-flag-to-the-api-client"},
+		{format: "{{.description | trunc 13 | title}}", want: "Add The Retry"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			got, err := renderTemplate(tt.format, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("renderTemplate(%q) = %q, want %q", tt.format, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckTemplate(t *testing.T) {
+	keys := []string{"type", "description"}
+
+	tests := []struct {
+		format  string
+		wantErr bool
+	}{
+		{format: "{{.type}}: {{.description}}"},
+		{format: "{{.type | upper}}: {{.description | trunc 50}}"},
+		{format: "{{if .type}}{{.type}}{{end}}: {{.description}}"},
+		{format: "{{.type}}({{.scope}}): {{.description}}", wantErr: true},
+		{format: "{{.type", wantErr: true},
+		{format: "{{.type | shout}}", wantErr: true},
+		{format: "{{.description | trunc}}", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			if err := checkTemplate(tt.format, keys); (err != nil) != tt.wantErr {
+				t.Errorf("checkTemplate(%q) = %v, want an error: %v", tt.format, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// TrailerDef is a trailer appended to every commit, like `Built-on: {{.hostname}}`.
+type TrailerDef struct {
+	// Token is the token of the trailer, like Built-on
+	Token string `json:"token"`
+
+	// Value is a template of the value, with the variables of trailerVariables
+	Value string `json:"value"`
+}
+
+var trailerTokenPattern = regexp.MustCompile(`^(BREAKING CHANGE|[A-Za-z0-9][A-Za-z0-9-]*)$`)
+
+// trailerVariables returns the variables of TrailerDef.Value: hostname, username, os and version.
+func trailerVariables() map[string]string {
+	hostname, _ := os.Hostname()
+
+	username := os.Getenv("USER")
+	if u, err := user.Current(); err == nil {
+		username = u.Username
+	} else if username == "" {
+		username = os.Getenv("USERNAME")
+	}
+
+	version := Version
+	if version == "" {
+		version = "dev"
+	}
+
+	return map[string]string{
+		"hostname": hostname,
+		"username": username,
+		"os":       runtime.GOOS,
+		"version":  version,
+	}
+}
+
+// checkExtraTrailers returns an error if a token of rule.ExtraTrailers is not valid as a trailer
+// or its value is not a valid template.
+func checkExtraTrailers(rule *Rule, rulePath string) error {
+	var keys []string
+	for k := range trailerVariables() {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, t := range rule.ExtraTrailers {
+		if !trailerTokenPattern.MatchString(t.Token) {
+			return &RuleInvalidError{Path: rulePath, Cause: fmt.Errorf("extraTrailers: %s", tr(msgInvalidTrailerToken, t.Token))}
+		}
+		if err := checkTemplate(t.Value, keys); err != nil {
+			return &RuleInvalidError{Path: rulePath, Cause: fmt.Errorf("extraTrailers: %s: %w (%s)", t.Token, err, strings.Join(keys, ", "))}
+		}
+	}
+	return nil
+}
+
+// extraTrailers renders rule.ExtraTrailers as `Token: value` lines. Trailers rendered empty are left out.
+func extraTrailers(rule *Rule) []string {
+	if len(rule.ExtraTrailers) == 0 {
+		return nil
+	}
+
+	vars := trailerVariables()
+	var lines []string
+	for _, t := range rule.ExtraTrailers {
+		value, err := renderTemplate(t.Value, vars)
+		if err != nil {
+			warn(tr(msgTrailerWarning, t.Token, err))
+			continue
+		}
+		// a trailer is a line
+		value = strings.Join(strings.Fields(value), " ")
+		if value != "" {
+			lines = append(lines, t.Token+": "+value)
+		}
+	}
+	return lines
+}
+
+// isExtraTrailer reports whether token is a trailer of rule.ExtraTrailers.
+func isExtraTrailer(rule *Rule, token string) bool {
+	for _, t := range rule.ExtraTrailers {
+		if strings.EqualFold(t.Token, token) {
+			return true
+		}
+	}
+	return false
+}
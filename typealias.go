@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	prompt "github.com/elk-language/go-prompt"
+)
+
+// Merged rule files may define the same type twice, like feat and Feat, or fix and fix!.
+// Such near-duplicates are warned on load, and `git cx rule dedupe` merges them into one type,
+// recording the others as its aliases. An alias is accepted as the type in the prompt and the arguments,
+// and a type of the base that is an alias in the extending file is dropped.
+
+// canonicalType returns the type typ is an alias of, or typ itself.
+func (r Rule) canonicalType(typ string) string {
+	if typ == "" || r.Types.Contains(typ) {
+		return typ
+	}
+	for _, k := range r.Types.Keys() {
+		ct, _ := r.Types.Get(k)
+		if in(typ, ct.Aliases...) {
+			return k
+		}
+	}
+	return typ
+}
+
+// typeDuplicateKey returns the key telling near-duplicate types: lowercase letters and digits only.
+func typeDuplicateKey(typ string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, typ)
+}
+
+// nearDuplicateTypes returns the groups of types differing only by case or punctuation, in the rule order.
+func (r Rule) nearDuplicateTypes() [][]string {
+	var keys []string
+	groups := make(map[string][]string)
+	for _, k := range r.typeNames() {
+		dk := typeDuplicateKey(k)
+		if dk == "" {
+			continue
+		}
+		if _, found := groups[dk]; !found {
+			keys = append(keys, dk)
+		}
+		groups[dk] = append(groups[dk], k)
+	}
+
+	var dups [][]string
+	for _, dk := range keys {
+		if len(groups[dk]) > 1 {
+			dups = append(dups, groups[dk])
+		}
+	}
+	return dups
+}
+
+// warnNearDuplicateTypes warns of the near-duplicate types with the rule files they come from.
+func warnNearDuplicateTypes(rule *Rule) {
+	for _, group := range rule.nearDuplicateTypes() {
+		names := make([]string, 0, len(group))
+		for _, k := range group {
+			if src := rule.typeSources[k]; src != "" {
+				names = append(names, fmt.Sprintf("%s (%s)", k, src))
+			} else {
+				names = append(names, k)
+			}
+		}
+		warn(tr(msgTypeNearDuplicates, strings.Join(names, ", ")))
+	}
+}
+
+// setTypeSources records that all the types of r come from ref.
+func (r *Rule) setTypeSources(ref string) {
+	r.typeSources = make(map[string]string)
+	for _, k := range r.Types.Keys() {
+		r.typeSources[k] = ref
+	}
+}
+
+type ruleDedupeCmd struct {
+}
+
+func (c ruleDedupeCmd) Run(g globalCmd, rc ruleCmd, args []string) error {
+	return rc.editRule(g, func(rule *Rule) error {
+		groups := rule.nearDuplicateTypes()
+		if len(groups) == 0 {
+			fmt.Fprintln(os.Stderr, tr(msgNoNearDuplicates))
+			return nil
+		}
+
+		for _, group := range groups {
+			canonical, err := chooseCanonicalType(rule, group)
+			if err != nil {
+				return err
+			}
+			if canonical == "" {
+				continue
+			}
+			mergeTypeAliases(rule, canonical, group)
+		}
+		return nil
+	})
+}
+
+// chooseCanonicalType asks which of group to keep, or returns "" to leave them as they are.
+func chooseCanonicalType(rule *Rule, group []string) (string, error) {
+	fmt.Fprintln(os.Stderr, tr(msgDedupeGroup))
+	for i, k := range group {
+		ct, _ := rule.Types.Get(k)
+		fmt.Fprintf(os.Stderr, "%3d: %-10s %s\n", i+1, k, ct.Desc)
+	}
+
+	for {
+		answer, err := promptInput(prompt.WithPrefix(tr(msgDedupeAsk, len(group))))
+		if err != nil {
+			return "", err
+		}
+		answer = strings.TrimSpace(answer)
+		if answer == "" {
+			return "", nil
+		}
+		if n, err := strconv.Atoi(answer); err == nil && 1 <= n && n <= len(group) {
+			return group[n-1], nil
+		}
+		fmt.Fprintln(os.Stderr, tr(msgDedupeInvalid, len(group)))
+	}
+}
+
+// mergeTypeAliases removes the types of group but canonical, adding them and their aliases to the aliases of canonical.
+func mergeTypeAliases(rule *Rule, canonical string, group []string) {
+	ct, _ := rule.Types.Get(canonical)
+	for _, k := range group {
+		if k == canonical {
+			continue
+		}
+		loser, _ := rule.Types.Get(k)
+		for _, a := range append([]string{k}, loser.Aliases...) {
+			if a != canonical && !in(a, ct.Aliases...) {
+				ct.Aliases = append(ct.Aliases, a)
+			}
+		}
+		if ct.Desc == "" {
+			ct.Desc = loser.Desc
+		}
+		if ct.Emoji == "" {
+			ct.Emoji = loser.Emoji
+		}
+		rule.Types.Delete(k)
+	}
+	rule.Types.Set(canonical, ct)
+}
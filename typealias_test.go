@@ -0,0 +1,111 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/shu-go/orderedmap"
+)
+
+func aliasTestRule(types ...string) *Rule {
+	om := orderedmap.New[string, CommitType]()
+	for _, k := range types {
+		om.Set(k, CommitType{Desc: k})
+	}
+	return &Rule{Types: om}
+}
+
+func TestCanonicalType(t *testing.T) {
+	rule := aliasTestRule("feat", "fix")
+	ct, _ := rule.Types.Get("feat")
+	ct.Aliases = []string{"feature", "Feat"}
+	rule.Types.Set("feat", ct)
+
+	tests := []struct {
+		typ  string
+		want string
+	}{
+		{typ: "feat", want: "feat"},
+		{typ: "feature", want: "feat"},
+		{typ: "FEATURE", want: "feat"},
+		{typ: "Feat", want: "feat"},
+		{typ: "fix", want: "fix"},
+		{typ: "wip", want: "wip"},
+		{typ: "", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.typ, func(t *testing.T) {
+			if got := rule.canonicalType(tt.typ); got != tt.want {
+				t.Errorf("canonicalType(%q) = %q, want %q", tt.typ, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNearDuplicateTypes(t *testing.T) {
+	tests := []struct {
+		name  string
+		types []string
+		want  [][]string
+	}{
+		{name: "none", types: []string{"feat", "fix", "feature"}},
+		{name: "case", types: []string{"feat", "fix", "Feat"}, want: [][]string{{"feat", "Feat"}}},
+		{name: "punctuation", types: []string{"fix", "fix!", "feat", "f-e-a-t", "docs"}, want: [][]string{{"fix", "fix!"}, {"feat", "f-e-a-t"}}},
+		{name: "punctuation only", types: []string{"!", "?"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := aliasTestRule(tt.types...).nearDuplicateTypes(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("nearDuplicateTypes() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeTypeAliases(t *testing.T) {
+	rule := aliasTestRule("feat", "fix", "Feat", "FEAT")
+	ct, _ := rule.Types.Get("Feat")
+	ct.Emoji, ct.Aliases = ":sparkles:", []string{"feature"}
+	rule.Types.Set("Feat", ct)
+	ct, _ = rule.Types.Get("feat")
+	ct.Desc = ""
+	rule.Types.Set("feat", ct)
+
+	// FEAT is already accepted as the alias Feat, case-insensitively
+	mergeTypeAliases(rule, "feat", []string{"feat", "Feat", "FEAT"})
+
+	if got := rule.Types.Keys(); !reflect.DeepEqual(got, []string{"feat", "fix"}) {
+		t.Errorf("types = %v", got)
+	}
+	ct, _ = rule.Types.Get("feat")
+	want := CommitType{Desc: "Feat", Emoji: ":sparkles:", Aliases: []string{"Feat", "feature"}}
+	if !reflect.DeepEqual(ct, want) {
+		t.Errorf("feat = %+v, want %+v", ct, want)
+	}
+}
+
+func TestTypeSources(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.yaml")
+	child := filepath.Join(dir, ".cx.yaml")
+	if err := os.WriteFile(base, []byte("types:\n  feat:\n    desc: A\n  fix:\n    desc: B\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(child, []byte("extends: base.yaml\ntypes:\n  Feat:\n    desc: C\n  fix:\n    desc: D\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	rule, err := tryReadRuleFile(child, defaultConfigFileLimit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"feat": absRuleRef(base), "fix": child, "Feat": child}
+	if !reflect.DeepEqual(rule.typeSources, want) {
+		t.Errorf("typeSources = %v, want %v", rule.typeSources, want)
+	}
+	if got := rule.nearDuplicateTypes(); !reflect.DeepEqual(got, [][]string{{"feat", "Feat"}}) {
+		t.Errorf("nearDuplicateTypes() = %q", got)
+	}
+}
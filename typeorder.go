@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	prompt "github.com/elk-language/go-prompt"
+	"gopkg.in/yaml.v3"
+)
+
+// TypeOrdering is Rule.TypeOrder: a mode (rule or frequency), or a list of types suggested first.
+type TypeOrdering struct {
+	Mode  string
+	Types []string
+}
+
+func (o *TypeOrdering) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.SequenceNode {
+		return value.Decode(&o.Types)
+	}
+	return value.Decode(&o.Mode)
+}
+
+func (o TypeOrdering) MarshalYAML() (any, error) {
+	if len(o.Types) > 0 {
+		return o.Types, nil
+	}
+	return o.Mode, nil
+}
+
+func (o *TypeOrdering) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, &o.Types); err == nil {
+		return nil
+	}
+	return json.Unmarshal(b, &o.Mode)
+}
+
+func (o TypeOrdering) MarshalJSON() ([]byte, error) {
+	if len(o.Types) > 0 {
+		return json.Marshal(o.Types)
+	}
+	return json.Marshal(o.Mode)
+}
+
+// typeOrderMode returns the mode of Rule.TypeOrder, rule if a list or omitted.
+func (r Rule) typeOrderMode() string {
+	if r.TypeOrder == nil || len(r.TypeOrder.Types) > 0 || r.TypeOrder.Mode == "" {
+		return typeOrderRule
+	}
+	return r.TypeOrder.Mode
+}
+
+// typeOrderList returns the list of Rule.TypeOrder, or nil if a mode.
+func (r Rule) typeOrderList() []string {
+	if r.TypeOrder == nil {
+		return nil
+	}
+	return r.TypeOrder.Types
+}
+
+// unknownOrderedTypes returns the types in the list of Rule.TypeOrder that are not defined.
+func (r Rule) unknownOrderedTypes() []string {
+	var unknown []string
+	for _, t := range r.typeOrderList() {
+		if _, found := r.Types.Get(t); !found {
+			unknown = append(unknown, t)
+		}
+	}
+	return unknown
+}
+
+// sortTypeSuggestions orders items by the list of Rule.TypeOrder, or by the frequency with typeOrder: frequency.
+// Types not in the list follow in the order of definition.
+func (c globalCmd) sortTypeSuggestions(ctx context.Context, items []prompt.Suggest) {
+	if list := c.rule.typeOrderList(); len(list) > 0 {
+		if unknown := c.rule.unknownOrderedTypes(); len(unknown) > 0 {
+			warn(tr(msgTypeOrderUnknown, unknown))
+		}
+
+		rank := make(map[string]int, len(list))
+		for i, t := range list {
+			if _, found := rank[t]; !found {
+				rank[t] = i + 1
+			}
+		}
+		sort.SliceStable(items, func(i, j int) bool {
+			ri, rj := rank[items[i].Text], rank[items[j].Text]
+			if ri == 0 || rj == 0 {
+				return ri != 0 && rj == 0
+			}
+			return ri < rj
+		})
+		return
+	}
+
+	if c.rule.typeOrderMode() == typeOrderFrequency {
+		done := c.profile.measure("type frequency")
+		counts := c.typeFrequency(ctx)
+		done()
+		sort.SliceStable(items, func(i, j int) bool {
+			return counts[items[i].Text] > counts[items[j].Text]
+		})
+	}
+}
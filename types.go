@@ -1,6 +1,7 @@
 package main
 
 import (
+	"strings"
 	"time"
 
 	"github.com/shu-go/orderedmap"
@@ -9,18 +10,256 @@ import (
 type CommitType struct {
 	Desc  string `json:"description,omitempty"`
 	Emoji string `json:"emoji,omitempty"`
+
+	// Breaking overrides Rule.UseBreakingChange for this type (never, allowed or ask)
+	Breaking string `json:"breaking,omitempty" yaml:",omitempty"`
+
+	// Aliases are other names accepted as this type, like Feat for feat
+	Aliases []string `json:"aliases,omitempty" yaml:",omitempty"`
+
+	// Extra holds keys unknown to this version, in the order of the file
+	Extra []rawField `json:"-" yaml:"-"`
 }
 
 type Rule struct {
+	// MinVersion is the minimum version of git-cx that can use this rule
+	MinVersion string `json:"minVersion,omitempty" yaml:",omitempty"`
+
+	// Extends is the rule file this rule is based on (a path relative to this file, ~/ or absolute, or an https URL)
+	Extends string `json:"extends,omitempty" yaml:",omitempty"`
+
+	// Disabled makes `git cx` a plain `git commit` and `git cx lint` accept any message in the repository
+	Disabled bool `json:"disabled,omitempty" yaml:",omitempty"`
+
 	HeaderFormat     string `json:"headerFormat"`
 	HeaderFormatHint string `json:"headerFormatHint"`
 
+	// BodyFormat is the template of the body, with the answers (default: the body as it is)
+	BodyFormat string `json:"bodyFormat,omitempty" yaml:",omitempty"`
+
+	// DescriptionSnippets are offered by the completion of the description, with placeholders like ${1:version} to jump to by Tab
+	DescriptionSnippets []string `json:"descriptionSnippets,omitempty" yaml:",omitempty"`
+
+	// DescriptionSnippetsFile is a file of more DescriptionSnippets, a line each (relative to the rule file)
+	DescriptionSnippetsFile string `json:"descriptionSnippetsFile,omitempty" yaml:",omitempty"`
+
+	// FooterFormat is the template of the footers, with the answers (default: the footers, a line each)
+	FooterFormat string `json:"footerFormat,omitempty" yaml:",omitempty"`
+
+	// EmojiRendering is what .emoji resolves to (unicode or shortcode, default: shortcode, unicode in a generated rule),
+	// or none to remove the emoji of .emoji, .emoji_unicode and .emoji_shortcode alike
+	EmojiRendering string `json:"emojiRendering"`
+
+	// EmojiOverrides replace the emoji of a type for the scopes matching a pattern, first match wins
+	EmojiOverrides []EmojiOverride `json:"emojiOverrides,omitempty" yaml:",omitempty"`
+
 	Types *orderedmap.OrderedMap[string, CommitType] `json:"types"` //map[string]CommitType
 
 	DenyEmptyType bool `json:"denyEmptyType"`
 	DenyAdlibType bool `json:"denyAdlibType"`
 
+	// DenyFixable makes what lint --fix corrects (type case, trailing period, ...) violations of the rule
+	DenyFixable bool `json:"denyFixable,omitempty" yaml:",omitempty"`
+
 	UseBreakingChange bool `json:"useBreakingChange"`
+
+	// Footers are asked after the body, in order
+	Footers []FooterDef `json:"footers,omitempty" yaml:",omitempty"`
+
+	// Prompts are asked after the standard prompts, in order, and their answers are variables of the templates
+	Prompts []PromptDef `json:"prompts,omitempty" yaml:",omitempty"`
+
+	// ExtraTrailers are appended to the footers of every commit, with values rendered at commit time
+	ExtraTrailers []TrailerDef `json:"extraTrailers,omitempty" yaml:",omitempty"`
+
+	// IgnoreSubmodules ignores changes of submodules like git's --ignore-submodules
+	IgnoreSubmodules bool `json:"ignoreSubmodules"`
+
+	// WarnPartiallyStaged asks before committing files that have unstaged changes on top of staged ones
+	WarnPartiallyStaged bool `json:"warnPartiallyStaged"`
+
+	// OfferUntracked asks to stage untracked files next to the staged ones (default: true)
+	OfferUntracked *bool `json:"offerUntracked,omitempty" yaml:",omitempty"`
+
+	// StagePrompt asks to stage the changes in the worktree when nothing is staged (default: true)
+	StagePrompt *bool `json:"stagePrompt,omitempty" yaml:",omitempty"`
+
+	// MaxHeaderLength is the maximum length of the header in characters (0: unlimited)
+	MaxHeaderLength int `json:"maxHeaderLength"`
+
+	// WarnOnlyHeaderLength allows a header longer than MaxHeaderLength after a confirmation
+	WarnOnlyHeaderLength bool `json:"warnOnlyHeaderLength,omitempty" yaml:",omitempty"`
+
+	// CheckSigning checks before the prompts that commit.gpgsign can sign (default: true)
+	CheckSigning *bool `json:"checkSigning,omitempty" yaml:",omitempty"`
+
+	// WrapBodyAt is the column the paragraphs of the body are wrapped at (default: 72, negative: not wrapped)
+	WrapBodyAt int `json:"wrapBodyAt,omitempty" yaml:",omitempty"`
+
+	// ShowVersionHint shows the version bump the commit would trigger from the latest tag (default: true)
+	ShowVersionHint *bool `json:"showVersionHint,omitempty" yaml:",omitempty"`
+
+	// ShowStagedSummary shows the staged files before the prompts (default: true)
+	ShowStagedSummary *bool `json:"showStagedSummary,omitempty" yaml:",omitempty"`
+
+	// FunFacts shows how many commits were made today and this week in the repository after committing
+	FunFacts bool `json:"funFacts,omitempty" yaml:",omitempty"`
+
+	// StagedDirsDepth is the depth of directories in .staged_dirs (default: 1)
+	StagedDirsDepth int `json:"stagedDirsDepth"`
+
+	// ScopeHistory records and suggests the scopes used (default: true)
+	ScopeHistory *bool `json:"scopeHistory,omitempty" yaml:",omitempty"`
+
+	// ScopeTimestampFormat is the format of timestamps in the scope history file (rfc3339, date or unix)
+	ScopeTimestampFormat string `json:"scopeTimestampFormat"`
+
+	// ScopeHistoryLimit is the number of entries kept in the scope history (default: 50, negative: unlimited)
+	ScopeHistoryLimit int `json:"scopeHistoryLimit,omitempty" yaml:",omitempty"`
+
+	// ScopeHistoryMaxAge drops scope history entries last used longer ago, like 90d, 2w or 3m (default: no limit)
+	ScopeHistoryMaxAge string `json:"scopeHistoryMaxAge,omitempty" yaml:",omitempty"`
+
+	// DefaultScope is the initial text of the scope prompt (lastCommit, lastUsed or none, default: none)
+	DefaultScope string `json:"defaultScope,omitempty" yaml:",omitempty"`
+
+	// TypeOrder is the order of type suggestions (rule, frequency in the recent history, or a list of types first, default: rule)
+	TypeOrder *TypeOrdering `json:"typeOrder,omitempty" yaml:",omitempty"`
+
+	// TypeSelect is how the type is asked (completion, or list to choose by the number, default: completion)
+	TypeSelect string `json:"typeSelect,omitempty" yaml:",omitempty"`
+
+	// CompletionMatch is how the completions of the type and the scope match the input, ignoring case
+	// (prefix, contains or fuzzy, default: contains for the type, prefix for the scope)
+	CompletionMatch string `json:"completionMatch,omitempty" yaml:",omitempty"`
+
+	// ScopeFilter ranks scope suggestions (stagedPaths: scopes used for the staged directories first)
+	ScopeFilter string `json:"scopeFilter,omitempty" yaml:",omitempty"`
+
+	// SuggestScopesFromPaths suggests the top-level directories of the staged files as scopes (default: true)
+	SuggestScopesFromPaths *bool `json:"suggestScopesFromPaths,omitempty" yaml:",omitempty"`
+
+	// ScopeSources are additional sources of scope suggestions (cochange: scopes of recent commits changing the staged files)
+	ScopeSources []string `json:"scopeSources,omitempty" yaml:",omitempty"`
+
+	// RenameScopes is which directories of a renamed file count for ScopeFilter (both: old and new, none: neither, default: both)
+	RenameScopes string `json:"renameScopes,omitempty" yaml:",omitempty"`
+
+	// MachineTrailers appends Cx-Type, Cx-Scope and Cx-Breaking trailers for bots
+	MachineTrailers bool `json:"machineTrailers,omitempty" yaml:",omitempty"`
+
+	// QuickCommitType is the type of `git cx wip` commits (default: wip)
+	QuickCommitType string `json:"quickCommitType,omitempty" yaml:",omitempty"`
+
+	// ProtectedBranches are globs of branches where `git cx wip` refuses to commit (default: main and master)
+	ProtectedBranches []string `json:"protectedBranches,omitempty" yaml:",omitempty"`
+
+	// ForbiddenOnBranches are types or subject prefixes (like fixup!) that must not land on branches (globs)
+	ForbiddenOnBranches map[string][]string `json:"forbiddenOnBranches,omitempty" yaml:",omitempty"`
+
+	// Extra holds keys unknown to this version, in the order of the file
+	Extra []rawField `json:"-" yaml:"-"`
+
+	// typeSources are the rule files the types come from, by key
+	typeSources map[string]string
+}
+
+// recordsScopes reports whether the scope history is used.
+func (r Rule) recordsScopes() bool {
+	return r.ScopeHistory == nil || *r.ScopeHistory
+}
+
+// typeNames returns the types of the rule, without comments.
+func (r Rule) typeNames() []string {
+	var names []string
+	for _, k := range r.Types.Keys() {
+		if !strings.HasPrefix(k, "#") {
+			names = append(names, k)
+		}
+	}
+	return names
+}
+
+// usesScopeSource reports whether source is in ScopeSources.
+func (r Rule) usesScopeSource(source string) bool {
+	return in(source, r.ScopeSources...)
+}
+
+// checksSigning reports whether to check commit signing before the prompts.
+func (r Rule) checksSigning() bool {
+	return r.CheckSigning == nil || *r.CheckSigning
+}
+
+// showsVersionHint reports whether to show the version bump after the prompts.
+func (r Rule) showsVersionHint() bool {
+	return r.ShowVersionHint == nil || *r.ShowVersionHint
+}
+
+// suggestsScopesFromPaths reports whether the directories of the staged files are suggested as scopes.
+func (r Rule) suggestsScopesFromPaths() bool {
+	return r.SuggestScopesFromPaths == nil || *r.SuggestScopesFromPaths
+}
+
+// showsStagedSummary reports whether to show the staged files before the prompts.
+func (r Rule) showsStagedSummary() bool {
+	return r.ShowStagedSummary == nil || *r.ShowStagedSummary
+}
+
+// offersUntracked reports whether to ask to stage untracked files next to the staged ones.
+func (r Rule) offersUntracked() bool {
+	return r.OfferUntracked == nil || *r.OfferUntracked
 }
 
-type Scopes map[string]time.Time
+// promptsStaging reports whether to ask to stage the changes in the worktree when nothing is staged.
+func (r Rule) promptsStaging() bool {
+	return r.StagePrompt == nil || *r.StagePrompt
+}
+
+const (
+	breakingNever   = "never"
+	breakingAllowed = "allowed"
+	breakingAsk     = "ask"
+)
+
+const (
+	emojiRenderingUnicode   = "unicode"
+	emojiRenderingShortcode = "shortcode"
+	emojiRenderingNone      = "none"
+)
+
+const (
+	scopeFilterStagedPaths = "stagedPaths"
+)
+
+const (
+	completionMatchPrefix   = "prefix"
+	completionMatchContains = "contains"
+	completionMatchFuzzy    = "fuzzy"
+)
+
+const (
+	scopeSourceCochange = "cochange"
+)
+
+const (
+	renameScopesBoth = "both"
+	renameScopesNone = "none"
+)
+
+const (
+	defaultScopeLastCommit = "lastCommit"
+	defaultScopeLastUsed   = "lastUsed"
+	defaultScopeNone       = "none"
+)
+
+type Scopes map[string]ScopeEntry
+
+type ScopeEntry struct {
+	LastUsed time.Time
+
+	// Paths are the staged directories when the scope was used last
+	Paths []string
+
+	// Commit is the hash of the commit that used the scope last, if known
+	Commit string
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	prompt "github.com/elk-language/go-prompt"
+)
+
+const (
+	typeSelectCompletion = "completion"
+	typeSelectList       = "list"
+)
+
+// typeListPageSize is the number of types shown at once with typeSelect: list.
+const typeListPageSize = 15
+
+// typeListEntry is a type in the list of typeSelect: list.
+type typeListEntry struct {
+	prompt.Suggest
+
+	// Section is the comment (a type starting with #) printed before the type, if any
+	Section string
+}
+
+// typeListEntries returns the entries of items.
+// In the rule order, the comments in Rule.Types are the sections of the types following them;
+// otherwise, sorted by Rule.TypeOrder, there are no sections.
+func (c globalCmd) typeListEntries(items []prompt.Suggest) []typeListEntry {
+	entries := make([]typeListEntry, 0, len(items))
+	if c.rule.typeOrderMode() != typeOrderRule || len(c.rule.typeOrderList()) > 0 {
+		for _, item := range items {
+			entries = append(entries, typeListEntry{Suggest: item})
+		}
+		return entries
+	}
+
+	byText := make(map[string]prompt.Suggest, len(items))
+	for _, item := range items {
+		byText[item.Text] = item
+	}
+
+	var section string
+	for _, k := range c.rule.Types.Keys() {
+		if strings.HasPrefix(k, "#") {
+			section = strings.TrimSpace(strings.TrimPrefix(k, "#"))
+			if ct, found := c.rule.Types.Get(k); found && ct.Desc != "" {
+				section = ct.Desc
+			}
+			continue
+		}
+		if item, found := byText[k]; found {
+			entries = append(entries, typeListEntry{Suggest: item, Section: section})
+			section = ""
+		}
+	}
+	return entries
+}
+
+// chooseType shows entries as a numbered list, a page at a time, and returns the type chosen by the number,
+// or the answer as it is if not a number.
+func chooseType(entries []typeListEntry, initial string) (string, error) {
+	pages := (len(entries) + typeListPageSize - 1) / typeListPageSize
+	page := 0
+
+	for {
+		from := page * typeListPageSize
+		to := min(from+typeListPageSize, len(entries))
+		for i := from; i < to; i++ {
+			if entries[i].Section != "" {
+				fmt.Fprintf(os.Stderr, "  -- %s --\n", entries[i].Section)
+			}
+			fmt.Fprintf(os.Stderr, "%3d: %-10s %s\n", i+1, entries[i].Text, entries[i].Description)
+		}
+
+		prefix := tr(msgPromptTypeList)
+		if pages > 1 {
+			prefix = tr(msgPromptTypeListPaged, page+1, pages)
+		}
+		answer, err := promptInput(prompt.WithPrefix(prefix), prompt.WithInitialText(initial))
+		if err != nil {
+			return "", err
+		}
+		answer = strings.TrimSpace(answer)
+
+		switch {
+		case pages > 1 && answer == "n":
+			page = (page + 1) % pages
+			continue
+		case pages > 1 && answer == "p":
+			page = (page + pages - 1) % pages
+			continue
+		}
+
+		n, err := strconv.Atoi(answer)
+		if err != nil {
+			return answer, nil
+		}
+		if n < 1 || n > len(entries) {
+			fmt.Fprintln(os.Stderr, tr(msgTypeNumberOutOfRange, n))
+			continue
+		}
+		return entries[n-1].Text, nil
+	}
+}
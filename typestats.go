@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	typeOrderRule      = "rule"
+	typeOrderFrequency = "frequency"
+)
+
+const (
+	typeStatsFileName = ".type-stats.json"
+	typeStatsTTL      = 24 * time.Hour
+
+	// typeStatsDepth is how many commits are counted.
+	typeStatsDepth = 500
+)
+
+// typeStats is the cached count of types in the history of a repository.
+type typeStats struct {
+	// TypesHash is of the types in the rule when counted
+	TypesHash string         `json:"typesHash"`
+	Updated   time.Time      `json:"updated"`
+	Counts    map[string]int `json:"counts"`
+}
+
+// typeFrequency returns how many times each type appears in the recent history.
+// The counts are cached per worktree in the user config dir, and recounted after typeStatsTTL or when the types in the rule change.
+// Errors result in nil, that is, the rule order.
+func (c globalCmd) typeFrequency(ctx context.Context) map[string]int {
+	if c.repository == nil {
+		return nil
+	}
+	wt, err := c.repository.Worktree()
+	if err != nil {
+		return nil
+	}
+	root := wt.Filesystem.Root()
+	hash := typesHash(c.rule)
+
+	cachePath := typeStatsPath()
+	cache := readTypeStats(cachePath)
+	if s, found := cache[root]; found && s.TypesHash == hash && time.Since(s.Updated) < typeStatsTTL {
+		return s.Counts
+	}
+
+	counts := countTypes(ctx, c.commits, typeStatsDepth)
+	if counts == nil {
+		return nil
+	}
+	if cachePath != "" {
+		cache[root] = typeStats{TypesHash: hash, Updated: time.Now(), Counts: counts}
+		writeTypeStats(cachePath, cache)
+	}
+	return counts
+}
+
+func typesHash(rule *Rule) string {
+	h := sha256.Sum256([]byte(strings.Join(rule.Types.Keys(), "\n")))
+	return hex.EncodeToString(h[:8])
+}
+
+// countTypes counts the types in the headers of the latest depth commits.
+// It returns nil if ctx is done, not to cache partial counts.
+func countTypes(ctx context.Context, log *commitLog, depth int) map[string]int {
+	counts := make(map[string]int)
+	for _, commit := range log.latest(ctx, depth) {
+		if cc, ok := log.parse(commit); ok {
+			counts[cc.Type]++
+		}
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	return counts
+}
+
+func typeStatsPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, userConfigFolder, typeStatsFileName)
+}
+
+func readTypeStats(filename string) map[string]typeStats {
+	cache := make(map[string]typeStats)
+	if filename == "" {
+		return cache
+	}
+
+	content, err := os.ReadFile(longPath(filename))
+	if err != nil {
+		return cache
+	}
+	if err := json.Unmarshal(content, &cache); err != nil {
+		return make(map[string]typeStats)
+	}
+	return cache
+}
+
+// writeTypeStats writes the cache. Errors are ignored since it is only a cache.
+func writeTypeStats(filename string, cache map[string]typeStats) {
+	content, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(longPath(filepath.Dir(filename)), os.ModePerm); err != nil {
+		return
+	}
+	os.WriteFile(longPath(filename), content, 0o644)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// checkMinVersion returns an error if this build is older than rule.MinVersion.
+// Development builds (no version embedded) always pass with a warning.
+func checkMinVersion(rule *Rule, rulePath string) error {
+	if rule.MinVersion == "" {
+		return nil
+	}
+
+	if Version == "" {
+		warn(tr(msgDevBuildMinVersion, rule.MinVersion))
+		return nil
+	}
+
+	c, err := compareVersions(Version, rule.MinVersion)
+	if err != nil {
+		return &RuleInvalidError{Path: rulePath, Cause: fmt.Errorf("minVersion: %w", err)}
+	}
+	if c < 0 {
+		return errors.New(tr(msgUpgradeRequired, rulePath, rule.MinVersion, Version))
+	}
+	return nil
+}
+
+// compareVersions compares semantic versions a and b (with or without leading v).
+// A pre-release version is lower than its release.
+func compareVersions(a, b string) (int, error) {
+	anums, apre, err := splitVersion(a)
+	if err != nil {
+		return 0, err
+	}
+	bnums, bpre, err := splitVersion(b)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := 0; i < 3; i++ {
+		if anums[i] != bnums[i] {
+			if anums[i] < bnums[i] {
+				return -1, nil
+			}
+			return 1, nil
+		}
+	}
+
+	switch {
+	case apre == bpre:
+		return 0, nil
+	case apre == "":
+		return 1, nil
+	case bpre == "":
+		return -1, nil
+	case apre < bpre:
+		return -1, nil
+	default:
+		return 1, nil
+	}
+}
+
+func splitVersion(v string) (nums [3]int, pre string, err error) {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	v, _, _ = strings.Cut(v, "+")
+	v, pre, _ = strings.Cut(v, "-")
+
+	elems := strings.Split(v, ".")
+	if len(elems) > 3 {
+		return nums, "", fmt.Errorf("invalid version %q", v)
+	}
+	for i, e := range elems {
+		n, err := strconv.Atoi(e)
+		if err != nil || n < 0 {
+			return nums, "", fmt.Errorf("invalid version %q", v)
+		}
+		nums[i] = n
+	}
+	return nums, pre, nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+const (
+	bumpMajor = "MAJOR"
+	bumpMinor = "MINOR"
+	bumpPatch = "PATCH"
+)
+
+// showVersionHint prints the version bump the message would trigger, with versionHint: true.
+func (c *globalCmd) showVersionHint(f *commitFlow) error {
+	if !c.rule.showsVersionHint() {
+		return nil
+	}
+	if hint := c.versionHint(f.ctx, f.msg); hint != "" {
+		fmt.Fprintln(os.Stderr, hint)
+	}
+	return nil
+}
+
+// versionHint tells the version bump msg would trigger from the latest version tag, or returns "".
+// It is advisory only: no tags, an unparsable tag or a type without a bump result in "".
+func (c globalCmd) versionHint(ctx context.Context, msg string) string {
+	cc, ok := parseCommitMessage(msg)
+	if !ok {
+		return ""
+	}
+	bump := bumpOf(cc.Type, cc.Breaking)
+	if bump == "" {
+		return ""
+	}
+
+	current := latestVersionTag(ctx, c.repository)
+	if current == "" {
+		return ""
+	}
+	next, err := nextVersion(current, bump)
+	if err != nil {
+		return ""
+	}
+	return tr(msgVersionHint, bump, current, next)
+}
+
+// bumpOf returns the version bump of a commit: MAJOR for breaking changes, MINOR for feat and PATCH for fix.
+func bumpOf(typ string, breaking bool) string {
+	switch {
+	case breaking:
+		return bumpMajor
+	case typ == "feat":
+		return bumpMinor
+	case typ == "fix":
+		return bumpPatch
+	default:
+		return ""
+	}
+}
+
+// latestVersionTag returns the highest release version among the local tags like v1.2.3, or "".
+// Pre-releases and tags that are not versions are ignored, and "" is returned if ctx is done.
+func latestVersionTag(ctx context.Context, repos *git.Repository) string {
+	if repos == nil {
+		return ""
+	}
+	tags, err := repos.Tags()
+	if err != nil {
+		return ""
+	}
+	defer tags.Close()
+
+	var latest string
+	if err := tags.ForEach(func(ref *plumbing.Reference) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		name := ref.Name().Short()
+		if _, pre, err := splitVersion(name); err != nil || pre != "" || strings.Count(name, ".") != 2 {
+			return nil
+		}
+		if latest == "" {
+			latest = name
+		} else if c, err := compareVersions(name, latest); err == nil && c > 0 {
+			latest = name
+		}
+		return nil
+	}); err != nil {
+		return ""
+	}
+	return latest
+}
+
+// nextVersion returns current bumped by bump, keeping the leading v if any.
+func nextVersion(current, bump string) (string, error) {
+	nums, _, err := splitVersion(current)
+	if err != nil {
+		return "", err
+	}
+
+	switch bump {
+	case bumpMajor:
+		nums = [3]int{nums[0] + 1, 0, 0}
+	case bumpMinor:
+		nums = [3]int{nums[0], nums[1] + 1, 0}
+	case bumpPatch:
+		nums[2]++
+	}
+
+	prefix := ""
+	if strings.HasPrefix(current, "v") {
+		prefix = "v"
+	}
+	return fmt.Sprintf("%s%d.%d.%d", prefix, nums[0], nums[1], nums[2]), nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Warnings are not written as they occur but collected, since writing to stderr while a prompt is rendering
+// breaks the rendering. They are written before the next prompt (see promptInput) and at the end of the run,
+// each message once, like `cx: warning: ...`. With --verbose, they are written as they occur.
+
+type warningCollector struct {
+	mu        sync.Mutex
+	w         io.Writer
+	immediate bool
+	pending   []string
+	seen      map[string]bool
+}
+
+var warnings = &warningCollector{w: os.Stderr, seen: make(map[string]bool)}
+
+// warn adds a warning, msg being translated already.
+func warn(msg string) {
+	warnings.add(msg)
+}
+
+// flushWarnings writes the warnings collected so far.
+func flushWarnings() {
+	warnings.flush()
+}
+
+func (c *warningCollector) add(msg string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.seen[msg] {
+		return
+	}
+	c.seen[msg] = true
+
+	if c.immediate {
+		fmt.Fprintln(c.w, tr(msgWarning, msg))
+		return
+	}
+	c.pending = append(c.pending, msg)
+}
+
+func (c *warningCollector) flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, msg := range c.pending {
+		fmt.Fprintln(c.w, tr(msgWarning, msg))
+	}
+	c.pending = nil
+}
+
+// setImmediate writes the warnings collected so far, and makes the rest written as they occur.
+func (c *warningCollector) setImmediate(immediate bool) {
+	c.flush()
+
+	c.mu.Lock()
+	c.immediate = immediate
+	c.mu.Unlock()
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"strings"
+
+	pstrings "github.com/elk-language/go-prompt/strings"
+)
+
+// defaultWrapBodyAt is the column the body is wrapped at if Rule.WrapBodyAt is 0.
+const defaultWrapBodyAt = 72
+
+// bodyWidth returns the column to wrap the body at, or 0 not to wrap.
+func (r Rule) bodyWidth() int {
+	switch {
+	case r.WrapBodyAt < 0:
+		return 0
+	case r.WrapBodyAt == 0:
+		return defaultWrapBodyAt
+	default:
+		return r.WrapBodyAt
+	}
+}
+
+// wrapBody re-wraps each paragraph of body (consecutive lines) at width columns.
+// Widths are of the terminal, so that a CJK character counts as 2.
+// Words are never broken, blank lines are kept, and list items (- or *) and URLs are left as they are.
+func wrapBody(body string, width int) string {
+	if width <= 0 {
+		return body
+	}
+
+	var lines []string
+	var paragraph []string
+	flush := func() {
+		if len(paragraph) > 0 {
+			lines = append(lines, wrapWords(strings.Fields(strings.Join(paragraph, " ")), width)...)
+			paragraph = nil
+		}
+	}
+
+	for _, line := range strings.Split(body, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || isListItem(trimmed) || looksLikeURL(trimmed) {
+			flush()
+			lines = append(lines, line)
+			continue
+		}
+		paragraph = append(paragraph, trimmed)
+	}
+	flush()
+
+	return strings.Join(lines, "\n")
+}
+
+// wrapWords fills lines of width columns with words. A word wider than width has a line of its own.
+func wrapWords(words []string, width int) []string {
+	var lines []string
+	var line string
+	for _, w := range words {
+		if line != "" && int(pstrings.GetWidth(line))+1+int(pstrings.GetWidth(w)) > width {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += w
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func isListItem(line string) bool {
+	return strings.HasPrefix(line, "-") || strings.HasPrefix(line, "*")
+}
+
+// looksLikeURL reports whether line is a URL, possibly with a label like `See: https://...`.
+func looksLikeURL(line string) bool {
+	i := strings.Index(line, "://")
+	return i >= 0 && !strings.ContainsAny(line[i:], " \t")
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestWrapBody(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		width int
+		want  string
+	}{
+		{name: "fill", body: "aaa bbb ccc", width: 7, want: "aaa bbb\nccc"},
+		{name: "refill a paragraph", body: "aaa\nbbb ccc", width: 7, want: "aaa bbb\nccc"},
+		{name: "blank lines are kept", body: "aaa bbb\nccc ddd\n\neee", width: 7, want: "aaa bbb\nccc ddd\n\neee"},
+		{name: "blank lines in a row", body: "a\n\n\nb", width: 10, want: "a\n\n\nb"},
+		{name: "long word is not broken", body: "aaaaaaaaaa bb", width: 5, want: "aaaaaaaaaa\nbb"},
+		{name: "list items", body: "- item one two three\n* another item here\nplain text here", width: 6, want: "- item one two three\n* another item here\nplain\ntext\nhere"},
+		{name: "URL", body: "https://example.com/a/b/c/d", width: 5, want: "https://example.com/a/b/c/d"},
+		{name: "URL with a label", body: "See: https://example.com/a/b/c/d", width: 5, want: "See: https://example.com/a/b/c/d"},
+		{name: "CJK counts 2 columns", body: "日本語 日本語 日本語", width: 10, want: "日本語\n日本語\n日本語"},
+		{name: "CJK within the width", body: "日本語 日本語 日本語", width: 13, want: "日本語 日本語\n日本語"},
+		{name: "not wrapped", body: "x y", width: 0, want: "x y"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := wrapBody(tt.body, tt.width); got != tt.want {
+				t.Errorf("wrapBody(%q, %d) = %q, want %q", tt.body, tt.width, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBodyWidth(t *testing.T) {
+	tests := []struct {
+		wrapBodyAt int
+		want       int
+	}{
+		{wrapBodyAt: 0, want: defaultWrapBodyAt},
+		{wrapBodyAt: 50, want: 50},
+		{wrapBodyAt: -1, want: 0},
+	}
+	for _, tt := range tests {
+		if got := (Rule{WrapBodyAt: tt.wrapBodyAt}).bodyWidth(); got != tt.want {
+			t.Errorf("bodyWidth() of %d = %d, want %d", tt.wrapBodyAt, got, tt.want)
+		}
+	}
+}